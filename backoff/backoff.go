@@ -0,0 +1,96 @@
+// Package backoff computes the delays between retries.  Strategies are
+// stateless, so one Strategy can be shared by concurrent retry loops.
+package backoff
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Strategy returns the delay before the retry following attempt (1 for the
+// first attempt).
+type Strategy interface {
+	Delay(attempt int) time.Duration
+}
+
+// Func adapts a function to a Strategy.
+type Func func(attempt int) time.Duration
+
+// Delay implements Strategy by calling f
+func (f Func) Delay(attempt int) time.Duration {
+	return f(attempt)
+}
+
+// Constant waits d between attempts.
+func Constant(d time.Duration) Strategy {
+	return Func(func(int) time.Duration {
+		return d
+	})
+}
+
+// Exponential waits base after the first attempt, multiplied by factor for
+// every attempt after that (2 when factor <= 1), e.g. 1s, 2s, 4s.
+func Exponential(base time.Duration, factor float64) Strategy {
+	if factor <= 1 {
+		factor = 2
+	}
+
+	return Func(func(attempt int) time.Duration {
+		return scale(base, math.Pow(factor, float64(max(attempt, 1)-1)))
+	})
+}
+
+// Fibonacci waits base times the Fibonacci numbers, e.g. 1s, 1s, 2s, 3s, 5s,
+// growing slower than Exponential.
+func Fibonacci(base time.Duration) Strategy {
+	return Func(func(attempt int) time.Duration {
+		a, b := 1.0, 1.0
+		for i := 1; i < attempt; i++ {
+			a, b = b, a+b
+		}
+		return scale(base, a)
+	})
+}
+
+// DecorrelatedJitter waits a random delay between base and 3 times the
+// previous delay, up to limit, as described in "Exponential Backoff And
+// Jitter" on the AWS architecture blog.  It spreads out clients retrying at
+// the same time better than Exponential.
+func DecorrelatedJitter(base, limit time.Duration) Strategy {
+	return Func(func(attempt int) time.Duration {
+		// replaying the previous delays keeps the strategy stateless, with
+		// the same distribution
+		d := base
+		for i := 1; i < attempt; i++ {
+			d = min(limit, between(base, scale(d, 3)))
+		}
+		return d
+	})
+}
+
+// Capped limits the delays of s to limit.
+func Capped(s Strategy, limit time.Duration) Strategy {
+	return Func(func(attempt int) time.Duration {
+		return min(s.Delay(attempt), limit)
+	})
+}
+
+// scale returns d*f, saturating rather than overflowing
+func scale(d time.Duration, f float64) time.Duration {
+	v := float64(d) * f
+	if v >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+
+	return time.Duration(v)
+}
+
+// between returns a random duration in [lo, hi)
+func between(lo, hi time.Duration) time.Duration {
+	if hi <= lo {
+		return lo
+	}
+
+	return lo + rand.N(hi-lo)
+}
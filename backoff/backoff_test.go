@@ -0,0 +1,68 @@
+package backoff_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/backoff"
+)
+
+func TestStrategies(t *testing.T) {
+	s := time.Second
+
+	tests := []struct {
+		name     string
+		strategy backoff.Strategy
+		want     []time.Duration
+	}{
+		{"constant", backoff.Constant(s), []time.Duration{s, s, s}},
+		{"exponential", backoff.Exponential(s, 2), []time.Duration{s, 2 * s, 4 * s, 8 * s}},
+		{"exponential 1.5", backoff.Exponential(s, 1.5), []time.Duration{s, 1500 * time.Millisecond, 2250 * time.Millisecond}},
+		{"exponential default", backoff.Exponential(s, 0), []time.Duration{s, 2 * s, 4 * s}},
+		{"fibonacci", backoff.Fibonacci(s), []time.Duration{s, s, 2 * s, 3 * s, 5 * s, 8 * s}},
+		{"capped", backoff.Capped(backoff.Exponential(s, 2), 3*s), []time.Duration{s, 2 * s, 3 * s, 3 * s}},
+		{"func", backoff.Func(func(n int) time.Duration { return time.Duration(n) * s }), []time.Duration{s, 2 * s}},
+	}
+
+	for _, tt := range tests {
+		for i, want := range tt.want {
+			if got := tt.strategy.Delay(i + 1); got != want {
+				t.Errorf("%s: expected %v after attempt %d, received %v", tt.name, want, i+1, got)
+			}
+		}
+	}
+}
+
+func TestExponentialOverflow(t *testing.T) {
+	if d := backoff.Exponential(time.Second, 2).Delay(100); d != math.MaxInt64 {
+		t.Errorf("Expected the delay to saturate, received %v", d)
+	}
+}
+
+func TestDecorrelatedJitter(t *testing.T) {
+	base, limit := 100*time.Millisecond, 2*time.Second
+	s := backoff.DecorrelatedJitter(base, limit)
+
+	if d := s.Delay(1); d != base {
+		t.Errorf("Expected the first delay to be %v, received %v", base, d)
+	}
+
+	varied := false
+	for attempt := 2; attempt < 20; attempt++ {
+		d := s.Delay(attempt)
+		if d < base || d > limit {
+			t.Errorf("Expected %v <= delay <= %v, received %v", base, limit, d)
+		}
+		if attempt == 2 && d > 3*base {
+			t.Errorf("Expected the second delay at most %v, received %v", 3*base, d)
+		}
+		if d != s.Delay(attempt) {
+			varied = true
+		}
+	}
+
+	if !varied {
+		t.Error("Expected random delays")
+	}
+}
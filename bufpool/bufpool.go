@@ -0,0 +1,73 @@
+// Package bufpool recycles buffers, to save allocations when reading and
+// formatting bodies under load, e.g.
+//
+//	buf := bufpool.Get()
+//	defer bufpool.Put(buf)
+//	buf.ReadFrom(resp.Body)
+//
+// Nothing handed out may be used after being put back, including the
+// slices returned by Buffer.Bytes.
+package bufpool
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+const (
+	// ChunkSize is the size of the slices of GetChunk, as used by io.Copy
+	ChunkSize = 32 * 1024
+
+	// maxSize is the capacity above which buffers are left to the garbage
+	// collector, so a few large bodies don't stay in memory
+	maxSize = 1 << 20
+)
+
+var buffers = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+var chunks = sync.Pool{
+	New: func() any {
+		b := make([]byte, ChunkSize)
+		return &b
+	},
+}
+
+// Get returns an empty buffer from the pool.
+func Get() *bytes.Buffer {
+	return buffers.Get().(*bytes.Buffer)
+}
+
+// Put returns buf to the pool.
+func Put(buf *bytes.Buffer) {
+	if buf.Cap() > maxSize {
+		return
+	}
+
+	buf.Reset()
+	buffers.Put(buf)
+}
+
+// GetChunk returns a slice of ChunkSize bytes from the pool.
+func GetChunk() *[]byte {
+	return chunks.Get().(*[]byte)
+}
+
+// PutChunk returns a slice of GetChunk to the pool.
+func PutChunk(b *[]byte) {
+	chunks.Put(b)
+}
+
+// Copy is like io.Copy, with a chunk from the pool.
+func Copy(w io.Writer, r io.Reader) (int64, error) {
+	b := GetChunk()
+	defer PutChunk(b)
+
+	// hide the ReaderFrom and WriterTo of w and r, for the copy to use b
+	// rather than a buffer of their own
+	return io.CopyBuffer(struct{ io.Writer }{w}, struct{ io.Reader }{r}, *b)
+}
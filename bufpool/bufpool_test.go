@@ -0,0 +1,53 @@
+package bufpool_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sspencer/goal/bufpool"
+)
+
+func TestBuffer(t *testing.T) {
+	buf := bufpool.Get()
+	buf.WriteString("hello")
+	bufpool.Put(buf)
+
+	for i := 0; i < 10; i++ {
+		buf := bufpool.Get()
+		if buf.Len() != 0 {
+			t.Fatalf("Expected an empty buffer, received %q", buf.String())
+		}
+		bufpool.Put(buf)
+	}
+}
+
+func TestChunk(t *testing.T) {
+	b := bufpool.GetChunk()
+	if len(*b) != bufpool.ChunkSize {
+		t.Errorf("Expected %d bytes, received %d", bufpool.ChunkSize, len(*b))
+	}
+	bufpool.PutChunk(b)
+}
+
+func TestCopy(t *testing.T) {
+	src := strings.Repeat("0123456789", bufpool.ChunkSize/4)
+
+	var dst bytes.Buffer
+	n, err := bufpool.Copy(&dst, strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(src)) || dst.String() != src {
+		t.Errorf("Expected %d bytes copied, received %d", len(src), n)
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := bufpool.Get()
+		buf.WriteString("hello")
+		bufpool.Put(buf)
+	}
+}
@@ -0,0 +1,219 @@
+// Package cache is an in-memory LRU cache with optional expiration, e.g.
+//
+//	users := cache.New[int, *User](cache.MaxSize(1000), cache.TTL(time.Minute))
+//	u, err := users.GetOrLoad(id, func(id int) (*User, error) {
+//		return fetchUser(id)
+//	})
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+// errPanicked is the error of callers waiting for a load that panicked
+var errPanicked = errors.New("cache: load panicked")
+
+// Option configures a Cache.
+type Option func(*options)
+
+type options struct {
+	maxSize int
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// MaxSize bounds the number of entries.  Past it, the least recently used
+// entries are evicted.  The size is unbounded by default.
+func MaxSize(n int) Option {
+	return func(o *options) {
+		o.maxSize = n
+	}
+}
+
+// TTL expires entries d after they are set.  Entries never expire by
+// default.
+func TTL(d time.Duration) Option {
+	return func(o *options) {
+		o.ttl = d
+	}
+}
+
+// Clock replaces time.Now, to test expiration.
+func Clock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// Cache maps keys to values, evicting the least recently used entries when
+// full, and expired entries when read.  It is safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	mu    sync.Mutex
+	o     options
+	lru   *list.List // of *entry, most recently used first
+	items map[K]*list.Element
+	loads map[K]*load[V]
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time // never when zero
+}
+
+// load is a GetOrLoad in progress, waited on by other callers for the key
+type load[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// New returns an empty Cache.
+func New[K comparable, V any](opts ...Option) *Cache[K, V] {
+	o := options{now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Cache[K, V]{
+		o:     o,
+		lru:   list.New(),
+		items: make(map[K]*list.Element),
+		loads: make(map[K]*load[V]),
+	}
+}
+
+// Get returns the value of key, if it is cached and not expired.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.get(key)
+}
+
+// Set caches value for key, expiring after the TTL option.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetTTL(key, value, c.o.ttl)
+}
+
+// SetTTL caches value for key, expiring after ttl (never when 0).
+func (c *Cache[K, V]) SetTTL(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(key, value, ttl)
+}
+
+// GetOrLoad returns the value of key, calling load to get and cache it when
+// missing.  Concurrent calls for the same key wait for a single load.
+// Errors are returned to every waiting caller, but not cached.
+func (c *Cache[K, V]) GetOrLoad(key K, fn func(K) (V, error)) (V, error) {
+	c.mu.Lock()
+	if v, ok := c.get(key); ok {
+		c.mu.Unlock()
+		return v, nil
+	}
+
+	if l, ok := c.loads[key]; ok {
+		c.mu.Unlock()
+		<-l.done
+		return l.value, l.err
+	}
+
+	l := &load[V]{done: make(chan struct{})}
+	c.loads[key] = l
+	c.mu.Unlock()
+
+	loaded := false
+	defer func() {
+		if !loaded {
+			l.err = errPanicked // fn panicked, and the panic goes on
+		}
+
+		c.mu.Lock()
+		delete(c.loads, key)
+		if l.err == nil {
+			c.set(key, l.value, c.o.ttl)
+		}
+		c.mu.Unlock()
+		close(l.done)
+	}()
+
+	l.value, l.err = fn(key)
+	loaded = true
+	return l.value, l.err
+}
+
+// Delete removes key.
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.remove(e)
+	}
+}
+
+// Len returns the number of entries, including expired entries not read
+// since.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+// Clear removes every entry.
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.Init()
+	clear(c.items)
+}
+
+func (c *Cache[K, V]) get(key K) (V, bool) {
+	e, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	ent := e.Value.(*entry[K, V])
+	if !ent.expires.IsZero() && !c.o.now().Before(ent.expires) {
+		c.remove(e)
+		var zero V
+		return zero, false
+	}
+
+	c.lru.MoveToFront(e)
+	return ent.value, true
+}
+
+func (c *Cache[K, V]) set(key K, value V, ttl time.Duration) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.o.now().Add(ttl)
+	}
+
+	if e, ok := c.items[key]; ok {
+		ent := e.Value.(*entry[K, V])
+		ent.value, ent.expires = value, expires
+		c.lru.MoveToFront(e)
+		return
+	}
+
+	c.items[key] = c.lru.PushFront(&entry[K, V]{key, value, expires})
+
+	for c.o.maxSize > 0 && c.lru.Len() > c.o.maxSize {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *Cache[K, V]) remove(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.items, e.Value.(*entry[K, V]).key)
+}
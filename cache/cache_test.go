@@ -0,0 +1,130 @@
+package cache_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/cache"
+)
+
+func TestLRU(t *testing.T) {
+	c := cache.New[string, int](cache.MaxSize(2))
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a") // b is now the least recently used
+	c.Set("c", 3)
+
+	tests := []struct {
+		key   string
+		value int
+		ok    bool
+	}{
+		{"a", 1, true},
+		{"b", 0, false},
+		{"c", 3, true},
+	}
+
+	for _, tt := range tests {
+		if v, ok := c.Get(tt.key); v != tt.value || ok != tt.ok {
+			t.Errorf("%s: expected %d %v, received %d %v", tt.key, tt.value, tt.ok, v, ok)
+		}
+	}
+
+	if c.Len() != 2 {
+		t.Errorf("Expected 2 entries, received %d", c.Len())
+	}
+
+	c.Delete("a")
+	if _, ok := c.Get("a"); ok || c.Len() != 1 {
+		t.Errorf("Expected a to be deleted")
+	}
+
+	c.Clear()
+	if c.Len() != 0 {
+		t.Errorf("Expected no entries after Clear, received %d", c.Len())
+	}
+}
+
+func TestTTL(t *testing.T) {
+	now := time.Now()
+	c := cache.New[string, int](cache.TTL(time.Minute), cache.Clock(func() time.Time { return now }))
+
+	c.Set("a", 1)
+	c.SetTTL("b", 2, time.Hour)
+	c.SetTTL("c", 3, 0)
+
+	now = now.Add(2 * time.Minute)
+
+	if _, ok := c.Get("a"); ok {
+		t.Error("Expected a to expire")
+	}
+	if v, ok := c.Get("b"); !ok || v != 2 {
+		t.Errorf("Expected b to be cached, received %d %v", v, ok)
+	}
+
+	now = now.Add(24 * time.Hour)
+	if _, ok := c.Get("b"); ok {
+		t.Error("Expected b to expire")
+	}
+	if v, ok := c.Get("c"); !ok || v != 3 {
+		t.Errorf("Expected c to never expire, received %d %v", v, ok)
+	}
+}
+
+func TestGetOrLoad(t *testing.T) {
+	c := cache.New[int, int]()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	load := func(n int) (int, error) {
+		calls.Add(1)
+		<-release
+		return n * n, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = c.GetOrLoad(3, load)
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected a single load, received %d", calls.Load())
+	}
+	for _, r := range results {
+		if r != 9 {
+			t.Errorf("Expected 9, received %d", r)
+		}
+	}
+
+	if v, ok := c.Get(3); !ok || v != 9 {
+		t.Errorf("Expected 9 to be cached, received %d %v", v, ok)
+	}
+}
+
+func TestGetOrLoadError(t *testing.T) {
+	c := cache.New[int, int]()
+	failed := errors.New("failed")
+
+	if _, err := c.GetOrLoad(1, func(int) (int, error) { return 0, failed }); err != failed {
+		t.Errorf("Expected %v, received %v", failed, err)
+	}
+	if _, ok := c.Get(1); ok {
+		t.Error("Expected errors not to be cached")
+	}
+
+	if v, err := c.GetOrLoad(1, func(int) (int, error) { return 1, nil }); v != 1 || err != nil {
+		t.Errorf("Expected 1 after the error, received %d %v", v, err)
+	}
+}
@@ -0,0 +1,180 @@
+// Package cli dispatches subcommands with their own flags, and writes their
+// help, e.g.
+//
+//	app := cli.New("movies", "search movies")
+//	search := app.Command("search", "search movies by title", func(ctx context.Context, args []string) error {
+//		...
+//	})
+//	page := search.Int("page", 1, "page of results")
+//	app.Main()
+//
+// Every Command is a flag.FlagSet, so flags are typed and parsed by the
+// flag package.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sspencer/goal/timeu"
+)
+
+// ErrUsage is returned by Run for unknown commands and invalid flags, after
+// writing the help.
+var ErrUsage = errors.New("invalid usage")
+
+// App is a program made of subcommands.
+type App struct {
+	Name        string
+	Description string
+
+	// Stdout receives help asked for, and Stderr help after usage errors
+	Stdout io.Writer
+	Stderr io.Writer
+
+	commands []*Command
+}
+
+// Command is a subcommand, with the flags defined on its FlagSet.
+type Command struct {
+	*flag.FlagSet
+	Name      string
+	Usage     string
+	ArgsUsage string // describes the arguments after the flags in the help, e.g. "URL..."
+
+	run func(ctx context.Context, args []string) error
+}
+
+// New returns an App without commands.
+func New(name, description string) *App {
+	return &App{Name: name, Description: description, Stdout: os.Stdout, Stderr: os.Stderr}
+}
+
+// Command adds the subcommand name, running run with the arguments left
+// after its flags.
+func (a *App) Command(name, usage string, run func(ctx context.Context, args []string) error) *Command {
+	c := &Command{FlagSet: flag.NewFlagSet(a.Name+" "+name, flag.ContinueOnError), Name: name, Usage: usage, run: run}
+	c.FlagSet.Usage = func() {
+		w := c.FlagSet.Output()
+		usage := strings.TrimSpace(fmt.Sprintf("%s %s [flags] %s", a.Name, c.Name, c.ArgsUsage))
+		fmt.Fprintf(w, "%s\n\nUsage:\n  %s\n", c.Usage, usage)
+		if c.hasFlags() {
+			fmt.Fprintf(w, "\nFlags:\n")
+			c.PrintDefaults()
+		}
+	}
+
+	a.commands = append(a.commands, c)
+	return c
+}
+
+// HumanDuration defines a duration flag accepting days and unit names, like
+// timeu.ParseHuman, e.g. "2d" or "90s".
+func (c *Command) HumanDuration(name string, value time.Duration, usage string) *time.Duration {
+	d := timeu.Duration(value)
+	c.Var(&d, name, usage)
+	return (*time.Duration)(&d)
+}
+
+func (c *Command) hasFlags() bool {
+	has := false
+	c.VisitAll(func(*flag.Flag) { has = true })
+	return has
+}
+
+// Run runs the command named by args[0] with the rest of args.  "help",
+// "-h" and no arguments at all write the help of the app, and "help cmd"
+// that of cmd.
+func (a *App) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		a.help(a.Stderr)
+		return ErrUsage
+	}
+
+	name := args[0]
+	switch name {
+	case "help", "-h", "-help", "--help":
+		if len(args) > 1 {
+			if c := a.find(args[1]); c != nil {
+				c.SetOutput(a.Stdout)
+				c.FlagSet.Usage()
+				return nil
+			}
+		}
+		a.help(a.Stdout)
+		return nil
+	}
+
+	c := a.find(name)
+	if c == nil {
+		fmt.Fprintf(a.Stderr, "%s: unknown command %q\n\n", a.Name, name)
+		a.help(a.Stderr)
+		return ErrUsage
+	}
+
+	c.SetOutput(a.Stderr)
+	if err := c.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return ErrUsage
+	}
+
+	return c.run(ctx, c.FlagSet.Args())
+}
+
+// Main runs the app with the arguments of the program, canceling the context
+// on interrupt, and exits with status 1 when the command fails, or 2 for
+// usage errors.
+func (a *App) Main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := a.Run(ctx, os.Args[1:])
+	stop()
+
+	switch {
+	case errors.Is(err, ErrUsage):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintf(a.Stderr, "%s: %v\n", a.Name, err)
+		os.Exit(1)
+	}
+}
+
+func (a *App) find(name string) *Command {
+	for _, c := range a.commands {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// help writes the commands of the app
+func (a *App) help(w io.Writer) {
+	if a.Description != "" {
+		fmt.Fprintf(w, "%s\n\n", a.Description)
+	}
+	fmt.Fprintf(w, "Usage:\n  %s <command> [flags] [args]\n\nCommands:\n", a.Name)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 3, ' ', 0)
+	for _, c := range a.commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.Name, firstLine(c.Usage))
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nRun '%s help <command>' for the flags of a command.\n", a.Name)
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,81 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/cli"
+)
+
+func newApp() (*cli.App, *bytes.Buffer) {
+	var out bytes.Buffer
+
+	app := cli.New("tool", "a test tool")
+	app.Stdout, app.Stderr = &out, &out
+
+	get := app.Command("get", "fetch URLs", func(ctx context.Context, args []string) error {
+		return nil
+	})
+	get.ArgsUsage = "URL..."
+	get.Int("n", 4, "concurrent requests")
+	get.HumanDuration("timeout", 30*time.Second, "request timeout")
+
+	app.Command("fail", "always fails", func(ctx context.Context, args []string) error {
+		return errors.New("failed")
+	})
+
+	return app, &out
+}
+
+func TestRun(t *testing.T) {
+	app := cli.New("tool", "")
+	var args []string
+
+	get := app.Command("get", "fetch URLs", func(ctx context.Context, a []string) error {
+		args = a
+		return nil
+	})
+	n := get.Int("n", 4, "concurrent requests")
+	timeout := get.HumanDuration("timeout", 30*time.Second, "request timeout")
+
+	if err := app.Run(context.Background(), []string{"get", "-n", "8", "-timeout", "2m", "a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if *n != 8 || *timeout != 2*time.Minute || strings.Join(args, ",") != "a,b" {
+		t.Errorf("Expected 8, 2m and [a b], received %d, %v and %v", *n, *timeout, args)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  string
+		out  []string
+	}{
+		{nil, "invalid usage", []string{"Commands:", "get", "fetch URLs", "fail"}},
+		{[]string{"help"}, "", []string{"a test tool", "Commands:"}},
+		{[]string{"help", "get"}, "", []string{"tool get [flags] URL...", "-n int", "concurrent requests", "-timeout"}},
+		{[]string{"get", "-h"}, "", []string{"tool get [flags] URL..."}},
+		{[]string{"nope"}, "invalid usage", []string{`unknown command "nope"`}},
+		{[]string{"get", "-n", "x"}, "invalid usage", []string{"invalid value"}},
+		{[]string{"fail"}, "failed", nil},
+	}
+
+	for _, tt := range tests {
+		app, out := newApp()
+		err := app.Run(context.Background(), tt.args)
+
+		if (err == nil && tt.err != "") || (err != nil && err.Error() != tt.err) {
+			t.Errorf("%v: expected error %q, received %v", tt.args, tt.err, err)
+		}
+		for _, want := range tt.out {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("%v: expected %q in output %q", tt.args, want, out.String())
+			}
+		}
+	}
+}
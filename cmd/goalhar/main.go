@@ -0,0 +1,257 @@
+// Command goalhar inspects HAR files saved by browser devtools, and replays
+// their requests with the req client, e.g. to check an API still answers
+// like it did when recorded:
+//
+//	goalhar list session.har
+//	goalhar replay -entries 3,5-7 -curl session.har
+//	goalhar diff -match /api/ session.har
+//
+// Requests are replayed with their method, URL, headers and body, except
+// the headers of the recorded connection and Accept-Encoding.
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sspencer/goal/cli"
+	"github.com/sspencer/goal/har"
+	"github.com/sspencer/goal/jsonu"
+	"github.com/sspencer/goal/req"
+)
+
+// maxChanges is the number of JSON changes shown per response by diff
+const maxChanges = 20
+
+func main() {
+	app := cli.New("goalhar", "Inspect and replay the requests of HAR files.")
+
+	var listMatch *string
+	list := app.Command("list", "List the entries of a HAR file.", func(ctx context.Context, args []string) error {
+		entries, err := load(args, "", *listMatch)
+		if err != nil {
+			return err
+		}
+		return listEntries(os.Stdout, entries)
+	})
+	list.ArgsUsage = "FILE"
+	listMatch = list.String("match", "", "only entries whose URL contains this")
+
+	var replayEntries, replayMatch *string
+	var curl *bool
+	replay := app.Command("replay", "Replay entries, and show their status.", func(ctx context.Context, args []string) error {
+		entries, err := load(args, *replayEntries, *replayMatch)
+		if err != nil {
+			return err
+		}
+
+		r := req.New()
+		if *curl {
+			r.Curl()
+		}
+		return replayAll(ctx, os.Stdout, r, entries, false)
+	})
+	replay.ArgsUsage = "FILE"
+	replayEntries = replay.String("entries", "", "entries to replay, e.g. 1,3-5 (all by default)")
+	replayMatch = replay.String("match", "", "only entries whose URL contains this")
+	curl = replay.Bool("curl", false, "log the requests as curl commands")
+
+	var diffEntries, diffMatch *string
+	diff := app.Command("diff", "Replay entries, and compare the responses to those recorded.", func(ctx context.Context, args []string) error {
+		entries, err := load(args, *diffEntries, *diffMatch)
+		if err != nil {
+			return err
+		}
+		return replayAll(ctx, os.Stdout, req.New(), entries, true)
+	})
+	diff.ArgsUsage = "FILE"
+	diffEntries = diff.String("entries", "", "entries to compare, e.g. 1,3-5 (all by default)")
+	diffMatch = diff.String("match", "", "only entries whose URL contains this")
+
+	app.Main()
+}
+
+// entry is an entry of the HAR file, with its position
+type entry struct {
+	har.Entry
+	index int
+}
+
+// load reads the HAR file of args, and returns the entries selected
+func load(args []string, selected, match string) ([]entry, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%w: expected a HAR file", cli.ErrUsage)
+	}
+
+	h, err := har.Load(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	keep, err := parseEntries(selected, len(h.Log.Entries))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []entry
+	for i, e := range h.Log.Entries {
+		if keep != nil && !keep[i] || !strings.Contains(e.Request.URL, match) {
+			continue
+		}
+		entries = append(entries, entry{e, i})
+	}
+
+	return entries, nil
+}
+
+// parseEntries parses a list of indexes and ranges, e.g. "1,3-5", nil
+// when empty
+func parseEntries(s string, n int) (map[int]bool, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	keep := make(map[int]bool)
+	for _, part := range strings.Split(s, ",") {
+		loStr, hiStr, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		lo, err := strconv.Atoi(loStr)
+		hi := lo
+		if err == nil && isRange {
+			hi, err = strconv.Atoi(hiStr)
+		}
+		if err != nil || lo < 0 || hi < lo || hi >= n {
+			return nil, fmt.Errorf("invalid entries %q, the file has entries 0 to %d", part, n-1)
+		}
+
+		for i := lo; i <= hi; i++ {
+			keep[i] = true
+		}
+	}
+
+	return keep, nil
+}
+
+func listEntries(w io.Writer, entries []entry) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tMETHOD\tSTATUS\tSIZE\tTIME\tURL")
+	for _, e := range entries {
+		d := time.Duration(e.Time * float64(time.Millisecond)).Round(time.Millisecond)
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%s\t%s\n", e.index, e.Request.Method, e.Response.Status, e.Response.Content.Size, d, e.Request.URL)
+	}
+
+	return tw.Flush()
+}
+
+// outcome is the response to a replayed request
+type outcome struct {
+	status int
+	body   []byte
+}
+
+// replayAll replays the entries in order, comparing the responses to those
+// recorded when diffing
+func replayAll(ctx context.Context, w io.Writer, r *req.Request, entries []entry, diffing bool) error {
+	differ := 0
+	for _, e := range entries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		start := time.Now()
+		out, err := send(ctx, r, e.Request)
+		if err != nil {
+			fmt.Fprintf(w, "%d %s %s: %v\n", e.index, e.Request.Method, e.Request.URL, err)
+			differ++
+			continue
+		}
+
+		fmt.Fprintf(w, "%d %s %s -> %d (recorded %d) in %s\n", e.index, e.Request.Method, e.Request.URL,
+			out.status, e.Response.Status, time.Since(start).Round(time.Millisecond))
+
+		if diffing {
+			if diffs := compare(e.Response, out); len(diffs) > 0 {
+				differ++
+				for _, d := range diffs {
+					fmt.Fprintf(w, "    %s\n", d)
+				}
+			}
+		}
+	}
+
+	if differ > 0 {
+		if diffing {
+			return fmt.Errorf("%d of %d responses differ", differ, len(entries))
+		}
+		return fmt.Errorf("%d of %d requests failed", differ, len(entries))
+	}
+
+	return nil
+}
+
+// send replays the request, returning the response even if not 2XX
+func send(ctx context.Context, r *req.Request, rec har.Request) (outcome, error) {
+	var body io.Reader
+	if b := rec.Body(); b != nil {
+		body = bytes.NewReader(b)
+	}
+
+	resp, err := r.DoCtx(ctx, req.Method(rec.Method), rec.URL, body, req.Headers(rec.Header()))
+	var httpErr req.HTTPError
+	if errors.As(err, &httpErr) {
+		return outcome{httpErr.StatusCode, httpErr.Body}, nil
+	}
+	if err != nil {
+		return outcome{}, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	return outcome{resp.StatusCode, b}, err
+}
+
+// compare returns the differences between the recorded response and out
+func compare(recorded har.Response, out outcome) []string {
+	var diffs []string
+	if out.status != recorded.Status {
+		diffs = append(diffs, fmt.Sprintf("status: %d -> %d", recorded.Status, out.status))
+	}
+
+	want, err := recorded.Content.Body()
+	if err != nil || recorded.Content.Text == "" && recorded.Content.Size > 0 {
+		return append(diffs, "body: not recorded")
+	}
+
+	if isJSON(recorded.Content.MimeType) {
+		changes, err := jsonu.Diff(want, out.body)
+		if err == nil {
+			for i, c := range changes {
+				if i == maxChanges {
+					diffs = append(diffs, fmt.Sprintf("... and %d more changes", len(changes)-maxChanges))
+					break
+				}
+				diffs = append(diffs, c.String())
+			}
+			return diffs
+		}
+	}
+
+	if string(want) != string(out.body) {
+		diffs = append(diffs, fmt.Sprintf("body: %d bytes -> %d bytes, different", len(want), len(out.body)))
+	}
+
+	return diffs
+}
+
+func isJSON(mimeType string) bool {
+	t, _, _ := mime.ParseMediaType(mimeType)
+	return t == "application/json" || strings.HasSuffix(t, "+json")
+}
@@ -0,0 +1,106 @@
+// Command goalproxy is a reverse proxy logging every request it forwards as
+// a runnable curl command, followed by the response, to debug what opaque
+// SDKs send, e.g.
+//
+//	goalproxy run -addr :8080 https://api.example.com
+//
+// then point the SDK at http://localhost:8080.
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/sspencer/goal/cli"
+	"github.com/sspencer/goal/graceful"
+	"github.com/sspencer/goal/req"
+)
+
+// bodyKey is the context key of the body of the request forwarded
+type bodyKey struct{}
+
+func main() {
+	app := cli.New("goalproxy", "Forward requests to a target, logging them as curl commands.")
+
+	var addr *string
+	var headers, sortKeys, color *bool
+	var indent *int
+	cmd := app.Command("run", "Run the proxy to the target URL.", func(ctx context.Context, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("%w: expected the target URL", cli.ErrUsage)
+		}
+
+		r := req.New().JSONIndent(*indent)
+		if *headers {
+			r.CurlHeader()
+		}
+		if *sortKeys {
+			r.SortKeys()
+		}
+		if *color {
+			r.Color()
+		}
+
+		return run(ctx, *addr, args[0], r)
+	})
+	cmd.ArgsUsage = "URL"
+	addr = cmd.String("addr", "localhost:8080", "address to listen on")
+	headers = cmd.Bool("headers", false, "log response headers")
+	sortKeys = cmd.Bool("sort", false, "sort the keys of JSON bodies")
+	color = cmd.Bool("color", false, "colorize JSON bodies on terminals")
+	indent = cmd.Int("indent", 3, "indent width of JSON bodies")
+
+	app.Main()
+}
+
+// run proxies the requests received on addr to target until ctx is done
+func run(ctx context.Context, addr, target string, r *req.Request) error {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid target URL %q", target)
+	}
+
+	log.Printf("goalproxy: forwarding %s to %s", addr, u)
+	return graceful.RunContext(ctx, &http.Server{Addr: addr, Handler: newProxy(u, r)})
+}
+
+// newProxy returns a reverse proxy to target logging every exchange with r
+func newProxy(target *url.URL, r *req.Request) http.Handler {
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.Out.Host = target.Host
+
+			// compressed bodies can't be logged, the transport decompresses
+			// responses when it asks for compression itself
+			pr.Out.Header.Del("Accept-Encoding")
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			body, _ := resp.Request.Context().Value(bodyKey{}).([]byte)
+			log.Println("\n" + r.Format(resp.Request, body, resp))
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, in *http.Request, err error) {
+			log.Printf("goalproxy: %s %s: %v", in.Method, in.URL, err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, in *http.Request) {
+		// keep the body for the log, and forward a copy
+		body, err := io.ReadAll(in.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		in.Body = io.NopCloser(bytes.NewReader(body))
+
+		proxy.ServeHTTP(w, in.WithContext(context.WithValue(in.Context(), bodyKey{}, body)))
+	})
+}
@@ -0,0 +1,443 @@
+// Package config loads the configuration of a program into a struct, from
+// layers each overriding the ones before:
+//
+//  1. defaults, from default tags
+//  2. a file, by the JSON names of fields
+//  3. environment variables, from env tags
+//  4. command line flags, from flag tags
+//
+// e.g.
+//
+//	type Config struct {
+//		Addr    string        `json:"addr" env:"ADDR" flag:"addr" default:":8080" usage:"listen address"`
+//		Timeout time.Duration `json:"timeout" env:"TIMEOUT" default:"5s"`
+//		DB      struct {
+//			URL string `json:"url" env:"DB_URL,required"`
+//		} `json:"db"`
+//	}
+//
+//	cfg, err := config.Load[Config](config.File("app.json"), config.Flags(flag.CommandLine, os.Args[1:]))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	cfg.OnChange(func(old, new Config) { ... })
+//	go cfg.Watch(ctx, 10*time.Second)
+//
+//	timeout := cfg.Get().Timeout
+//
+// Files are JSON, unless another Decoder, such as a YAML one, is given.
+// Their string values are parsed like environment variables, so durations
+// can be written "5s".  The result is checked against validate tags.
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sspencer/goal/env"
+	"github.com/sspencer/goal/logx"
+	"github.com/sspencer/goal/validate"
+)
+
+// Error is the error of a value that can't be parsed, or of a required
+// variable that no layer set.
+type Error struct {
+	Source string // default, env, flag, or the file name
+	Name   string // of the variable, flag or file key, or the default value
+	Field  string
+	Err    error
+}
+
+// Error implements the Error method for Errors
+func (e *Error) Error() string {
+	return fmt.Sprintf("config %s %s (%s): %v", e.Source, e.Name, e.Field, e.Err)
+}
+
+// Unwrap returns the parse error, or env.ErrMissing
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Option configures the loading of a Config.
+type Option func(*options)
+
+type options struct {
+	file   string
+	decode func(data []byte, v any) error
+	lookup func(string) (string, bool)
+	flags  *flag.FlagSet
+	args   []string
+	log    logx.Logger
+}
+
+// File loads the file at path, between defaults and the environment.
+func File(path string) Option {
+	return func(o *options) {
+		o.file = path
+	}
+}
+
+// Decoder decodes the file with fn instead of json.Unmarshal, e.g. to read
+// YAML.  fn is given a *map[string]any.
+func Decoder(fn func(data []byte, v any) error) Option {
+	return func(o *options) {
+		o.decode = fn
+	}
+}
+
+// Lookup looks environment variables up with fn instead of os.LookupEnv,
+// e.g. to load from a map in tests.
+func Lookup(fn func(string) (string, bool)) Option {
+	return func(o *options) {
+		o.lookup = fn
+	}
+}
+
+// Flags defines the flags of fields on fs, with their usage tags, and
+// parses args with it.  Only the flags given in args override the other
+// layers.
+func Flags(fs *flag.FlagSet, args []string) Option {
+	return func(o *options) {
+		o.flags = fs
+		o.args = args
+	}
+}
+
+// Logger logs the reloads of Watch to l, logx.Default() by default.
+func Logger(l logx.Logger) Option {
+	return func(o *options) {
+		o.log = l
+	}
+}
+
+// Config holds the current configuration, of type T.
+type Config[T any] struct {
+	opts  options
+	flags map[string]string // given on the command line, by name
+
+	mu      sync.Mutex // serializes reloads
+	current atomic.Pointer[T]
+	hooks   []func(old, new T)
+	modTime time.Time // of the file when last loaded
+}
+
+// Load loads a configuration of type T, which must be a struct.  Every
+// value is checked, so the error lists all the problems at once.
+func Load[T any](opts ...Option) (*Config[T], error) {
+	c := &Config[T]{
+		opts: options{
+			decode: json.Unmarshal,
+			lookup: os.LookupEnv,
+			log:    logx.Default(),
+		},
+	}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config: expected a struct, received %s", t)
+	}
+
+	if c.opts.flags != nil {
+		if err := c.parseFlags(t); err != nil {
+			return nil, err
+		}
+	}
+
+	v, modTime, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+
+	c.modTime = modTime
+	c.current.Store(v)
+	return c, nil
+}
+
+// Get returns the current configuration.
+func (c *Config[T]) Get() T {
+	return *c.current.Load()
+}
+
+// OnChange calls fn with the old and new configurations when a reload
+// changes them.  Hooks are called in order, one reload at a time.
+func (c *Config[T]) OnChange(fn func(old, new T)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.hooks = append(c.hooks, fn)
+}
+
+// Reload loads the file and environment again, with the flags parsed by
+// Load.  On error, the current configuration is kept.
+func (c *Config[T]) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, modTime, err := c.load()
+	if !modTime.IsZero() {
+		c.modTime = modTime // don't retry a bad file until it changes
+	}
+	if err != nil {
+		return err
+	}
+
+	old := c.current.Swap(v)
+	if reflect.DeepEqual(*old, *v) {
+		return nil
+	}
+
+	for _, fn := range c.hooks {
+		fn(*old, *v)
+	}
+
+	return nil
+}
+
+// Watch reloads the configuration when its file changes, checking every
+// interval until ctx is done.  Failed reloads are logged as errors.
+func (c *Config[T]) Watch(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if !c.changed() {
+			continue
+		}
+
+		if err := c.Reload(); err != nil {
+			c.opts.log.Log(ctx, slog.LevelError, "config reload failed", "file", c.opts.file, "error", err)
+			continue
+		}
+		c.opts.log.Log(ctx, slog.LevelInfo, "config reloaded", "file", c.opts.file)
+	}
+}
+
+// changed reports whether the file was modified since it was last loaded
+func (c *Config[T]) changed() bool {
+	if c.opts.file == "" {
+		return false
+	}
+
+	info, err := os.Stat(c.opts.file)
+	if err != nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return !info.ModTime().Equal(c.modTime)
+}
+
+// parseFlags defines the flags of the fields of t and parses the command line
+func (c *Config[T]) parseFlags(t reflect.Type) error {
+	c.flags = map[string]string{}
+
+	fields(reflect.New(t).Elem(), "", nil, func(f field) {
+		name := f.Tag.Get("flag")
+		if name == "" {
+			return
+		}
+
+		set := func(value string) error {
+			// parse now, for errors to be reported with the usage
+			if err := env.Set(reflect.New(f.Type).Elem(), value); err != nil {
+				return err
+			}
+			c.flags[name] = value
+			return nil
+		}
+
+		if f.Type.Kind() == reflect.Bool {
+			c.opts.flags.BoolFunc(name, f.Tag.Get("usage"), set)
+		} else {
+			c.opts.flags.Func(name, f.Tag.Get("usage"), set)
+		}
+	})
+
+	return c.opts.flags.Parse(c.opts.args)
+}
+
+// load builds a configuration from all the layers, returning the
+// modification time of the file
+func (c *Config[T]) load() (*T, time.Time, error) {
+	v := new(T)
+	s := reflect.ValueOf(v).Elem()
+
+	var errs []error
+	add := func(source, name string, f field, err error) {
+		if err != nil {
+			errs = append(errs, &Error{source, name, f.name, err})
+		}
+	}
+
+	fields(s, "", nil, func(f field) {
+		if value, ok := f.Tag.Lookup("default"); ok {
+			add("default", value, f, env.Set(f.value, value))
+		}
+	})
+
+	var modTime time.Time
+	if c.opts.file != "" {
+		info, err := os.Stat(c.opts.file)
+		if err != nil {
+			return nil, modTime, fmt.Errorf("config: %w", err)
+		}
+		modTime = info.ModTime()
+
+		data, err := os.ReadFile(c.opts.file)
+		if err != nil {
+			return nil, modTime, fmt.Errorf("config: %w", err)
+		}
+
+		m := map[string]any{}
+		if err := c.opts.decode(data, &m); err != nil {
+			return nil, modTime, fmt.Errorf("config %s: %w", c.opts.file, err)
+		}
+
+		fields(s, "", nil, func(f field) {
+			if value, ok := lookup(m, f.keys); ok {
+				add(c.opts.file, strings.Join(f.keys, "."), f, decode(f.value, value))
+			}
+		})
+	}
+
+	fields(s, "", nil, func(f field) {
+		name, _, _ := strings.Cut(f.Tag.Get("env"), ",")
+		if name == "" {
+			return
+		}
+		if value, ok := c.opts.lookup(name); ok && value != "" {
+			add("env", name, f, env.Set(f.value, value))
+		}
+	})
+
+	fields(s, "", nil, func(f field) {
+		name := f.Tag.Get("flag")
+		if value, ok := c.flags[name]; ok && name != "" {
+			add("flag", name, f, env.Set(f.value, value))
+		}
+	})
+
+	fields(s, "", nil, func(f field) {
+		name, opt, _ := strings.Cut(f.Tag.Get("env"), ",")
+		if opt == "required" && f.value.IsZero() {
+			add("env", name, f, env.ErrMissing)
+		}
+	})
+
+	if len(errs) > 0 {
+		return nil, modTime, errors.Join(errs...)
+	}
+
+	if err := validate.Struct(v); err != nil {
+		return nil, modTime, err
+	}
+
+	return v, modTime, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// field is a field of a configuration, other than a nested struct
+type field struct {
+	reflect.StructField
+	value reflect.Value
+	name  string   // Go path, e.g. DB.URL
+	keys  []string // path in the file, e.g. [db url]
+}
+
+// fields calls fn with the fields of the struct s, and of its nested structs
+func fields(s reflect.Value, name string, keys []string, fn func(field)) {
+	t := s.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		key, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if key == "" {
+			key = f.Name
+		}
+
+		path := keys[:len(keys):len(keys)]
+		if !f.Anonymous || f.Tag.Get("json") != "" {
+			path = append(path, key)
+		}
+
+		fname := f.Name
+		if name != "" {
+			fname = name + "." + f.Name
+		}
+
+		if f.Type.Kind() == reflect.Struct && f.Type != timeType {
+			fields(s.Field(i), fname, path, fn)
+			continue
+		}
+
+		fn(field{f, s.Field(i), fname, path})
+	}
+}
+
+// lookup returns the value at keys in the decoded file m, matching keys
+// case insensitively like encoding/json
+func lookup(m map[string]any, keys []string) (any, bool) {
+	var value any = m
+	for _, key := range keys {
+		m, ok := value.(map[string]any)
+		if !ok || key == "-" {
+			return nil, false
+		}
+
+		if value, ok = m[key]; ok {
+			continue
+		}
+
+		found := false
+		for k, v := range m {
+			if strings.EqualFold(k, key) {
+				value, found = v, true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+
+	return value, value != nil
+}
+
+// decode sets v to a value from the file
+func decode(v reflect.Value, value any) error {
+	if s, ok := value.(string); ok {
+		return env.Set(v, s)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v.Addr().Interface())
+}
@@ -0,0 +1,165 @@
+package config_test
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/config"
+	"github.com/sspencer/goal/env"
+)
+
+type settings struct {
+	Addr    string        `json:"addr" env:"ADDR" flag:"addr" default:":8080" usage:"listen address"`
+	Timeout time.Duration `json:"timeout" env:"TIMEOUT" default:"5s"`
+	Hosts   []string      `json:"hosts" env:"HOSTS"`
+	Debug   bool          `json:"debug" flag:"debug"`
+	Workers int           `json:"workers" env:"WORKERS" validate:"max=64"`
+	DB      struct {
+		URL string `json:"url" env:"DB_URL,required"`
+	} `json:"db"`
+}
+
+func lookup(vars map[string]string) config.Option {
+	return config.Lookup(func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	})
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func newFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+func TestLoad(t *testing.T) {
+	file := writeFile(t, `{"addr": ":9000", "timeout": "1m", "hosts": ["a.com"], "workers": 4, "db": {"url": "file://db"}}`)
+
+	tests := []struct {
+		name string
+		opts []config.Option
+		want settings
+	}{
+		{"defaults", []config.Option{lookup(map[string]string{"DB_URL": "env://db"})},
+			settings{Addr: ":8080", Timeout: 5 * time.Second}},
+		{"file", []config.Option{config.File(file), lookup(nil)},
+			settings{Addr: ":9000", Timeout: time.Minute, Hosts: []string{"a.com"}, Workers: 4}},
+		{"env over file", []config.Option{config.File(file), lookup(map[string]string{"ADDR": ":7000", "HOSTS": "b.com, c.com", "DB_URL": "env://db", "WORKERS": ""})},
+			settings{Addr: ":7000", Timeout: time.Minute, Hosts: []string{"b.com", "c.com"}, Workers: 4}},
+		{"flags over env", []config.Option{config.File(file), lookup(map[string]string{"ADDR": ":7000"}), config.Flags(newFlags(), []string{"-addr", ":6000", "-debug"})},
+			settings{Addr: ":6000", Timeout: time.Minute, Hosts: []string{"a.com"}, Debug: true, Workers: 4}},
+	}
+
+	for _, tt := range tests {
+		c, err := config.Load[settings](tt.opts...)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		got := c.Get()
+		tt.want.DB.URL = got.DB.URL // checked below
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %+v, received %+v", tt.name, tt.want, got)
+		}
+	}
+
+	c, err := config.Load[settings](config.File(file), lookup(map[string]string{"DB_URL": "env://db"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url := c.Get().DB.URL; url != "env://db" {
+		t.Errorf("Expected env://db, received %s", url)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		vars   map[string]string
+		args   []string
+		errors []string
+	}{
+		{"missing", `{}`, nil, nil, []string{"DB_URL"}},
+		{"parse", `{"timeout": "soon", "workers": "many"}`, map[string]string{"DB_URL": "x"}, nil, []string{"timeout (Timeout)", "workers (Workers)"}},
+		{"env", `{}`, map[string]string{"DB_URL": "x", "WORKERS": "-"}, nil, []string{"env WORKERS"}},
+		{"validate", `{"workers": 100}`, map[string]string{"DB_URL": "x"}, nil, []string{"workers"}},
+		{"syntax", `{`, nil, nil, []string{"unexpected end"}},
+	}
+
+	for _, tt := range tests {
+		_, err := config.Load[settings](config.File(writeFile(t, tt.file)), lookup(tt.vars))
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+			continue
+		}
+
+		for _, s := range tt.errors {
+			if !strings.Contains(err.Error(), s) {
+				t.Errorf("%s: expected %q in %v", tt.name, s, err)
+			}
+		}
+	}
+
+	_, err := config.Load[settings](lookup(nil))
+	if !errors.Is(err, env.ErrMissing) {
+		t.Errorf("Expected %v, received %v", env.ErrMissing, err)
+	}
+
+	_, err = config.Load[settings](lookup(map[string]string{"DB_URL": "x"}), config.Flags(newFlags(), []string{"-debug=maybe"}))
+	if err == nil {
+		t.Error("Expected a flag error")
+	}
+}
+
+func TestReload(t *testing.T) {
+	file := writeFile(t, `{"addr": ":9000"}`)
+	c, err := config.Load[settings](config.File(file), lookup(map[string]string{"DB_URL": "x"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var changes []string
+	c.OnChange(func(old, new settings) {
+		changes = append(changes, old.Addr+" "+new.Addr)
+	})
+
+	os.WriteFile(file, []byte(`{"addr": ":9001"}`), 0o644)
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Reload(); err != nil { // unchanged
+		t.Fatal(err)
+	}
+
+	os.WriteFile(file, []byte(`{"addr": 1}`), 0o644)
+	if err := c.Reload(); err == nil {
+		t.Error("Expected a reload error")
+	}
+
+	if addr := c.Get().Addr; addr != ":9001" {
+		t.Errorf("Expected :9001 to be kept, received %s", addr)
+	}
+	if !reflect.DeepEqual(changes, []string{":9000 :9001"}) {
+		t.Errorf("Expected one change, received %v", changes)
+	}
+}
@@ -0,0 +1,108 @@
+// Package ctxutil carries request-scoped values in contexts with typed
+// helpers, e.g. the request ID set by the mw.RequestID middleware, which
+// req forwards to the services it calls:
+//
+//	ctx = ctxutil.WithUserID(ctx, user.ID)
+//	...
+//	ctxutil.Logger(ctx).Log(ctx, slog.LevelInfo, "order placed", "user", ctxutil.UserID(ctx))
+package ctxutil
+
+import (
+	"context"
+	"time"
+
+	"github.com/sspencer/goal/logx"
+)
+
+// Key is a typed context key, so values are stored and retrieved without
+// type assertions, e.g.
+//
+//	var tenantKey = ctxutil.NewKey[*Tenant]("tenant")
+//
+//	ctx = tenantKey.With(ctx, tenant)
+//	tenant, ok := tenantKey.Value(ctx)
+//
+// Keys are unique: two keys with the same name don't collide.
+type Key[T any] struct {
+	name *string
+}
+
+// NewKey returns a new key.  The name is only used by String.
+func NewKey[T any](name string) Key[T] {
+	return Key[T]{&name}
+}
+
+// With returns a copy of ctx carrying v.
+func (k Key[T]) With(ctx context.Context, v T) context.Context {
+	return context.WithValue(ctx, k.name, v)
+}
+
+// Value returns the value of ctx, if it has one.
+func (k Key[T]) Value(ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(k.name).(T)
+	return v, ok
+}
+
+// String returns the name of the key.
+func (k Key[T]) String() string {
+	return *k.name
+}
+
+// RequestIDHeader is the header carrying request IDs between services.
+const RequestIDHeader = "X-Request-ID"
+
+var (
+	requestIDKey = NewKey[string]("request ID")
+	userIDKey    = NewKey[string]("user ID")
+	loggerKey    = NewKey[logx.Logger]("logger")
+)
+
+// WithRequestID returns a copy of ctx carrying the request ID rid.
+func WithRequestID(ctx context.Context, rid string) context.Context {
+	return requestIDKey.With(ctx, rid)
+}
+
+// RequestID returns the request ID of ctx, or "" if it has none.
+func RequestID(ctx context.Context) string {
+	rid, _ := requestIDKey.Value(ctx)
+	return rid
+}
+
+// WithUserID returns a copy of ctx carrying the ID of the user making the
+// request.
+func WithUserID(ctx context.Context, uid string) context.Context {
+	return userIDKey.With(ctx, uid)
+}
+
+// UserID returns the user ID of ctx, or "" if it has none.
+func UserID(ctx context.Context) string {
+	uid, _ := userIDKey.Value(ctx)
+	return uid
+}
+
+// WithLogger returns a copy of ctx carrying l, e.g. a logger with the
+// attributes of the request.
+func WithLogger(ctx context.Context, l logx.Logger) context.Context {
+	return loggerKey.With(ctx, l)
+}
+
+// Logger returns the logger of ctx, or logx.Default() if it has none.
+func Logger(ctx context.Context) logx.Logger {
+	if l, ok := loggerKey.Value(ctx); ok && l != nil {
+		return l
+	}
+
+	return logx.Default()
+}
+
+// Detach returns a context with the values of ctx, but neither its
+// deadline nor its cancellation, for background work that must outlive a
+// request, e.g. sending an email after the response.
+func Detach(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
+
+// DetachTimeout is like Detach, bounding the background work to d.
+func DetachTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), d)
+}
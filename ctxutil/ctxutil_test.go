@@ -0,0 +1,77 @@
+package ctxutil_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/ctxutil"
+	"github.com/sspencer/goal/logx"
+)
+
+func TestKey(t *testing.T) {
+	a := ctxutil.NewKey[int]("n")
+	b := ctxutil.NewKey[int]("n")
+
+	ctx := a.With(context.Background(), 1)
+
+	tests := []struct {
+		name  string
+		key   ctxutil.Key[int]
+		value int
+		ok    bool
+	}{
+		{"set", a, 1, true},
+		{"same name", b, 0, false},
+	}
+
+	for _, tt := range tests {
+		if v, ok := tt.key.Value(ctx); v != tt.value || ok != tt.ok {
+			t.Errorf("%s: expected %d %v, received %d %v", tt.name, tt.value, tt.ok, v, ok)
+		}
+	}
+
+	if a.String() != "n" {
+		t.Errorf("Expected n, received %s", a)
+	}
+}
+
+func TestValues(t *testing.T) {
+	ctx := context.Background()
+	if ctxutil.RequestID(ctx) != "" || ctxutil.UserID(ctx) != "" || ctxutil.Logger(ctx) == nil {
+		t.Error("Expected empty IDs and the default logger")
+	}
+
+	log := &logx.TestLogger{}
+	ctx = ctxutil.WithRequestID(ctx, "r1")
+	ctx = ctxutil.WithUserID(ctx, "u1")
+	ctx = ctxutil.WithLogger(ctx, log)
+
+	if ctxutil.RequestID(ctx) != "r1" || ctxutil.UserID(ctx) != "u1" {
+		t.Errorf("Expected r1 and u1, received %q and %q", ctxutil.RequestID(ctx), ctxutil.UserID(ctx))
+	}
+
+	ctxutil.Logger(ctx).Log(ctx, slog.LevelInfo, "hello")
+	if len(log.Find("hello")) != 1 {
+		t.Error("Expected the logger of the context to be used")
+	}
+}
+
+func TestDetach(t *testing.T) {
+	ctx, cancel := context.WithTimeout(ctxutil.WithRequestID(context.Background(), "r1"), time.Millisecond)
+	detached := ctxutil.Detach(ctx)
+	bounded, stop := ctxutil.DetachTimeout(ctx, time.Hour)
+	defer stop()
+	cancel()
+
+	if detached.Err() != nil || bounded.Err() != nil {
+		t.Error("Expected detached contexts to outlive their parent")
+	}
+	if ctxutil.RequestID(detached) != "r1" || ctxutil.RequestID(bounded) != "r1" {
+		t.Error("Expected detached contexts to keep the values")
+	}
+	if _, ok := bounded.Deadline(); !ok {
+		t.Error("Expected a deadline")
+	}
+}
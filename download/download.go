@@ -0,0 +1,436 @@
+// Package download fetches many files concurrently with the req client and
+// the str worker pool, resuming partial downloads and verifying checksums,
+// e.g.
+//
+//	m := download.New(nil, download.Workers(4), download.OnProgress(show))
+//	report, err := m.Run(ctx, []download.File{
+//		{URL: "https://example.com/a.tar.gz", Path: "a.tar.gz", Checksum: "sha256:9f86d0..."},
+//	})
+//	fmt.Println(report)
+//
+// Files are written to Path + ".part" until complete and verified, then
+// renamed, so a rerun after a crash or a failure resumes with a Range
+// request where the download stopped.  Files that changed meanwhile are
+// downloaded again from the start.
+package download
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sspencer/goal/bufpool"
+	"github.com/sspencer/goal/fsu"
+	"github.com/sspencer/goal/req"
+	"github.com/sspencer/goal/str"
+)
+
+// ErrChecksum is the error of files whose checksum doesn't match.
+var ErrChecksum = errors.New("download: checksum mismatch")
+
+const (
+	// partSuffix is added to the path of files being downloaded
+	partSuffix = ".part"
+
+	// validatorSuffix is added to the path of part files for the ETag or
+	// Last-Modified date of their download, to resume it with If-Range
+	validatorSuffix = ".validator"
+)
+
+// File is a file to download.
+type File struct {
+	URL  string
+	Path string
+
+	// Checksum of the file, e.g. "sha256:<hex>", with sha256 when there is
+	// no algorithm.  md5, sha1, sha256 and sha512 are supported.  Not
+	// verified when empty.
+	Checksum string
+}
+
+// Progress is the state of the download of a file.
+type Progress struct {
+	File       File
+	Downloaded int64 // bytes, including those of an earlier run
+	Total      int64 // bytes, -1 when unknown
+}
+
+// Result is the outcome of the download of a file.
+type Result struct {
+	File     File
+	Bytes    int64 // bytes downloaded by this run
+	Resumed  bool  // continued a partial download
+	Skipped  bool  // already downloaded
+	Duration time.Duration
+	Err      error
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// Workers is the number of files downloaded at a time, 4 by default.
+func Workers(n int) Option {
+	return func(m *Manager) {
+		m.workers = n
+	}
+}
+
+// OnProgress calls fn as files are downloaded, from the workers.
+func OnProgress(fn func(Progress)) Option {
+	return func(m *Manager) {
+		m.progress = fn
+	}
+}
+
+// Overwrite downloads files again even when they exist.
+func Overwrite() Option {
+	return func(m *Manager) {
+		m.overwrite = true
+	}
+}
+
+// Batch passes options to the str batch, e.g. str.RetryPolicy to retry
+// failed downloads (which resume where they stopped), or str.RateLimit.
+func Batch(opts ...str.Option) Option {
+	return func(m *Manager) {
+		m.opts = append(m.opts, opts...)
+	}
+}
+
+// Manager downloads files.
+type Manager struct {
+	r         *req.Request
+	workers   int
+	progress  func(Progress)
+	overwrite bool
+	opts      []str.Option
+}
+
+// New returns a Manager downloading with r, or req.New() without a timeout
+// when nil, as large files take a while.
+func New(r *req.Request, opts ...Option) *Manager {
+	if r == nil {
+		r = req.New().Timeout(0)
+	}
+
+	m := &Manager{r: r, workers: 4}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Run downloads files, and reports the outcome of each.  Existing files
+// are skipped, unless they don't match their checksum.  The error joins
+// the errors of the files that failed, and that of ctx.
+func (m *Manager) Run(ctx context.Context, files []File) (*Report, error) {
+	start := time.Now()
+
+	results, err := str.MapCtx(ctx, m.workers, files, func(ctx context.Context, f File) (Result, error) {
+		r := m.download(ctx, f)
+		return r, r.Err
+	}, m.opts...)
+
+	report := &Report{Duration: time.Since(start)}
+	for _, r := range results {
+		out := r.Output
+		out.File, out.Err = r.Input, r.Err
+		report.add(out)
+	}
+
+	return report, err
+}
+
+// download fetches a file, resuming its part file if any
+func (m *Manager) download(ctx context.Context, f File) Result {
+	start := time.Now()
+	r := Result{File: f}
+
+	sum, err := parseChecksum(f.Checksum)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+
+	if !m.overwrite && fsu.Exists(f.Path) {
+		if sum == nil || verify(f.Path, sum) == nil {
+			r.Skipped = true
+			return r
+		}
+	}
+
+	r.Bytes, r.Resumed, r.Err = m.fetch(ctx, f)
+	if r.Err == nil && sum != nil {
+		if r.Err = verify(f.Path+partSuffix, sum); r.Err != nil {
+			removePart(f) // start over next time
+		}
+	}
+	if r.Err == nil {
+		r.Err = os.Rename(f.Path+partSuffix, f.Path)
+		os.Remove(f.Path + partSuffix + validatorSuffix)
+	}
+
+	r.Duration = time.Since(start)
+	return r
+}
+
+// fetch appends the rest of the file to its part file.  Resuming, the
+// ETag or Last-Modified date of the first response is sent with If-Range,
+// and the part file is started over when the server sends another range,
+// or sizes don't match.
+func (m *Manager) fetch(ctx context.Context, f File) (int64, bool, error) {
+	part := f.Path + partSuffix
+	if err := fsu.EnsureDir(filepath.Dir(part)); err != nil {
+		return 0, false, err
+	}
+
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+
+	var opts []req.RequestFunc
+	if offset > 0 {
+		opts = append(opts, req.Range(offset))
+		if v, err := os.ReadFile(part + validatorSuffix); err == nil && len(v) > 0 {
+			opts = append(opts, req.Header("If-Range", string(v)))
+		}
+	}
+
+	resp, err := m.r.DoCtx(ctx, req.MethodGet, f.URL, nil, opts...)
+	var httpErr req.HTTPError
+	if offset > 0 && errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		if _, size, ok := contentRange(httpErr.Header.Get("Content-Range")); ok && size == offset {
+			return 0, true, nil // the part file is complete
+		}
+		return m.restart(ctx, f) // the file shrank
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	total := resp.ContentLength
+	resumed := offset > 0 && resp.StatusCode == http.StatusPartialContent
+	if resumed {
+		start, size, ok := contentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			resp.Body.Close()
+			return m.restart(ctx, f)
+		}
+		total = size
+	} else {
+		offset = 0
+		flags |= os.O_TRUNC // the server sent the whole file
+		if err := saveValidator(part, resp.Header); err != nil {
+			return 0, false, err
+		}
+	}
+
+	out, err := os.OpenFile(part, flags, 0o644)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var progress func(copied, total int64)
+	if m.progress != nil {
+		progress = func(copied, total int64) {
+			m.progress(Progress{File: f, Downloaded: offset + copied, Total: total})
+		}
+	}
+
+	n, err := fsu.CopyProgress(out, resp.Body, total, progress)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && total >= 0 && offset+n != total {
+		err = fmt.Errorf("download: %s is %d bytes, expected %d", filepath.Base(f.Path), offset+n, total)
+	}
+
+	return n, resumed, err
+}
+
+// restart downloads a file from the start, when its part file can't be
+// resumed
+func (m *Manager) restart(ctx context.Context, f File) (int64, bool, error) {
+	if err := removePart(f); err != nil {
+		return 0, false, err
+	}
+
+	return m.fetch(ctx, f)
+}
+
+// removePart removes the part file of f, and its validator
+func removePart(f File) error {
+	os.Remove(f.Path + partSuffix + validatorSuffix)
+	if err := os.Remove(f.Path + partSuffix); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// saveValidator records the version of the file being downloaded to part:
+// its strong ETag, or else its Last-Modified date (see If-Range)
+func saveValidator(part string, h http.Header) error {
+	v := h.Get("ETag")
+	if v == "" || strings.HasPrefix(v, "W/") {
+		v = h.Get("Last-Modified")
+	}
+
+	if v == "" {
+		if err := os.Remove(part + validatorSuffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	return os.WriteFile(part+validatorSuffix, []byte(v), 0o644)
+}
+
+// contentRange parses a Content-Range header, e.g. "bytes 300-999/1000"
+// or "bytes */1000", returning the first byte (-1 for "*") and the size of
+// the file (-1 when unknown)
+func contentRange(s string) (start, size int64, ok bool) {
+	s, ok = strings.CutPrefix(s, "bytes ")
+	if !ok {
+		return 0, 0, false
+	}
+
+	rng, total, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, 0, false
+	}
+
+	start, size = -1, -1
+	var err error
+	if rng != "*" {
+		first, _, _ := strings.Cut(rng, "-")
+		if start, err = strconv.ParseInt(first, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	if total != "*" {
+		if size, err = strconv.ParseInt(total, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+
+	return start, size, true
+}
+
+// checksum is an expected digest, with its hash
+type checksum struct {
+	hash   func() hash.Hash
+	digest string
+}
+
+var algorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// parseChecksum parses "algorithm:hex", nil when empty
+func parseChecksum(s string) (*checksum, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	alg, digest, ok := strings.Cut(s, ":")
+	if !ok {
+		alg, digest = "sha256", s
+	}
+
+	h, ok := algorithms[strings.ToLower(alg)]
+	if !ok {
+		return nil, fmt.Errorf("download: unknown checksum algorithm %q", alg)
+	}
+
+	return &checksum{h, strings.ToLower(digest)}, nil
+}
+
+// verify returns ErrChecksum if the file at path doesn't match sum
+func verify(path string, sum *checksum) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sum.hash()
+	if _, err := bufpool.Copy(h, f); err != nil {
+		return err
+	}
+
+	if got := hex.EncodeToString(h.Sum(nil)); got != sum.digest {
+		return fmt.Errorf("%w: %s is %s, expected %s", ErrChecksum, filepath.Base(path), got, sum.digest)
+	}
+
+	return nil
+}
+
+// Report summarizes a Run.
+type Report struct {
+	Results    []Result // in the order they completed
+	Downloaded int      // files downloaded, including resumed
+	Resumed    int
+	Skipped    int
+	Failed     int
+	Bytes      int64 // downloaded by this run
+	Duration   time.Duration
+}
+
+func (r *Report) add(res Result) {
+	r.Results = append(r.Results, res)
+	switch {
+	case res.Err != nil:
+		r.Failed++
+	case res.Skipped:
+		r.Skipped++
+	default:
+		r.Downloaded++
+		if res.Resumed {
+			r.Resumed++
+		}
+	}
+	r.Bytes += res.Bytes
+}
+
+// String summarizes the report on a line, e.g.
+// "3 downloaded (1 resumed), 1 skipped, 0 failed, 12.5 MB in 4.2s"
+func (r *Report) String() string {
+	return fmt.Sprintf("%d downloaded (%d resumed), %d skipped, %d failed, %s in %s",
+		r.Downloaded, r.Resumed, r.Skipped, r.Failed, formatBytes(r.Bytes), r.Duration.Round(100*time.Millisecond))
+}
+
+// formatBytes formats n in decimal units, e.g. 12.5 MB
+func formatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
@@ -0,0 +1,157 @@
+package download_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/download"
+)
+
+var content = bytes.Repeat([]byte("0123456789"), 100)
+
+func sha(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestRun(t *testing.T) {
+	var ranges atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Range") != "" {
+			ranges.Add(1)
+		}
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(content))
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	// a partial download, and a file already there
+	os.WriteFile(path("resumed")+".part", content[:300], 0o644)
+	os.WriteFile(path("skipped"), content, 0o644)
+
+	var progress atomic.Int32
+	m := download.New(nil, download.Workers(2), download.OnProgress(func(p download.Progress) {
+		if p.Total != int64(len(content)) {
+			t.Errorf("Expected a total of %d, received %d", len(content), p.Total)
+		}
+		progress.Add(1)
+	}))
+
+	report, err := m.Run(context.Background(), []download.File{
+		{URL: ts.URL + "/a", Path: path("fresh"), Checksum: sha(content)},
+		{URL: ts.URL + "/a", Path: path("resumed"), Checksum: sha(content)},
+		{URL: ts.URL + "/a", Path: path("skipped"), Checksum: sha(content)},
+		{URL: ts.URL + "/a", Path: path("corrupt"), Checksum: sha([]byte("other"))},
+		{URL: ts.URL + "/missing", Path: path("missing")},
+	})
+
+	if !errors.Is(err, download.ErrChecksum) {
+		t.Errorf("Expected ErrChecksum, received %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		exists bool
+	}{
+		{"fresh", true},
+		{"resumed", true},
+		{"skipped", true},
+		{"corrupt", false},
+		{"corrupt.part", false},
+		{"missing", false},
+	}
+
+	for _, tt := range tests {
+		data, err := os.ReadFile(path(tt.name))
+		if exists := err == nil; exists != tt.exists {
+			t.Errorf("%s: expected exists %v, received %v", tt.name, tt.exists, exists)
+		}
+		if err == nil && !bytes.Equal(data, content) {
+			t.Errorf("%s: expected the content, received %d bytes", tt.name, len(data))
+		}
+	}
+
+	if report.Downloaded != 2 || report.Resumed != 1 || report.Skipped != 1 || report.Failed != 2 {
+		t.Errorf("Unexpected report: %s", report)
+	}
+	if expected := int64(len(content)*2 - 300 + len(content)); report.Bytes != expected {
+		t.Errorf("Expected %d bytes, received %d", expected, report.Bytes)
+	}
+	if ranges.Load() != 1 {
+		t.Errorf("Expected 1 range request, received %d", ranges.Load())
+	}
+	if progress.Load() == 0 {
+		t.Error("Expected progress to be reported")
+	}
+	if s := report.String(); !strings.HasPrefix(s, "2 downloaded (1 resumed), 1 skipped, 2 failed, 2.7 kB in ") {
+		t.Errorf("Unexpected summary: %s", s)
+	}
+}
+
+func TestResume(t *testing.T) {
+	other := bytes.Repeat([]byte("abcdefghij"), 50)
+
+	tests := []struct {
+		name      string
+		served    []byte // by the server now
+		etag      string
+		part      []byte // downloaded by an earlier run
+		validator string
+		resumed   bool
+	}{
+		{"unchanged", content, `"v1"`, content[:300], `"v1"`, true},
+		{"changed", other, `"v2"`, content[:300], `"v1"`, false},
+		{"shrank", other, "", content[:800], "", false},
+		{"complete", content, "", content, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.etag != "" {
+					w.Header().Set("ETag", tt.etag)
+				}
+				http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(tt.served))
+			}))
+			defer ts.Close()
+
+			path := filepath.Join(t.TempDir(), "file")
+			os.WriteFile(path+".part", tt.part, 0o644)
+			if tt.validator != "" {
+				os.WriteFile(path+".part.validator", []byte(tt.validator), 0o644)
+			}
+
+			report, err := download.New(nil).Run(context.Background(), []download.File{{URL: ts.URL, Path: path}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if data, _ := os.ReadFile(path); !bytes.Equal(data, tt.served) {
+				t.Errorf("Expected the file served, received %d bytes", len(data))
+			}
+			if (report.Resumed == 1) != tt.resumed {
+				t.Errorf("Expected resumed %t, received %s", tt.resumed, report)
+			}
+			if _, err := os.Stat(path + ".part.validator"); !os.IsNotExist(err) {
+				t.Errorf("Expected the validator to be removed, received %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+// Package env loads configuration from environment variables into a struct,
+// using field tags:
+//
+//	type Config struct {
+//		Port    int           `env:"PORT" default:"8080"`
+//		APIKey  string        `env:"API_KEY,required"`
+//		Timeout time.Duration `env:"TIMEOUT" default:"5s"`
+//		Hosts   []string      `env:"HOSTS"` // comma separated
+//	}
+//
+//	var cfg Config
+//	if err := env.Load(&cfg); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Fields without an env tag are left alone, except structs, whose fields are
+// loaded too.
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrMissing is the error of required variables that are not set.
+var ErrMissing = errors.New("required but not set")
+
+// Error is the error of a variable that is missing or can't be parsed.
+type Error struct {
+	Var   string
+	Field string
+	Err   error
+}
+
+// Error implements the Error method for Errors
+func (e *Error) Error() string {
+	return fmt.Sprintf("env %s (%s): %v", e.Var, e.Field, e.Err)
+}
+
+// Unwrap returns the parse error, or ErrMissing
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Load sets the fields of the struct pointed to by v from the environment.
+// Every variable is checked, so the error lists all the problems at once.
+func Load(v any) error {
+	return LoadFrom(v, os.LookupEnv)
+}
+
+// LoadFrom is like Load, but looks variables up with lookup, e.g. to load
+// from a map in tests.
+func LoadFrom(v any, lookup func(string) (string, bool)) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("env: expected a pointer to a struct, received %T", v)
+	}
+
+	var errs []error
+	load(rv.Elem(), lookup, &errs)
+	return errors.Join(errs...)
+}
+
+// load sets the fields of the struct s
+func load(s reflect.Value, lookup func(string) (string, bool), errs *[]error) {
+	t := s.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tag, ok := f.Tag.Lookup("env")
+		if !ok {
+			if f.Type.Kind() == reflect.Struct && f.Type != reflect.TypeOf(time.Time{}) {
+				load(s.Field(i), lookup, errs)
+			}
+			continue
+		}
+
+		name, flags, _ := strings.Cut(tag, ",")
+		value, ok := lookup(name)
+		if !ok || value == "" {
+			value, ok = f.Tag.Lookup("default")
+		}
+
+		if !ok {
+			if flags == "required" {
+				*errs = append(*errs, &Error{name, f.Name, ErrMissing})
+			}
+			continue
+		}
+
+		if err := Set(s.Field(i), value); err != nil {
+			*errs = append(*errs, &Error{name, f.Name, err})
+		}
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Set parses value into v, as Load does for fields: durations as in
+// time.ParseDuration, numbers in any base, and slices comma separated.
+func Set(v reflect.Value, value string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		parts := strings.Split(value, ",")
+		s := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, p := range parts {
+			if err := Set(s.Index(i), strings.TrimSpace(p)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package env_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/env"
+)
+
+type config struct {
+	Port    int           `env:"PORT" default:"8080"`
+	APIKey  string        `env:"API_KEY,required"`
+	Timeout time.Duration `env:"TIMEOUT" default:"5s"`
+	Hosts   []string      `env:"HOSTS"`
+	Debug   bool          `env:"DEBUG"`
+	Ratio   float64       `env:"RATIO" default:"0.5"`
+	DB      struct {
+		URL string `env:"DB_URL"`
+	}
+	ignored string
+}
+
+func lookup(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv("API_KEY", "secret")
+	t.Setenv("HOSTS", "a.com, b.com")
+	t.Setenv("DB_URL", "postgres://db")
+
+	var cfg config
+	if err := env.Load(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	want := config{Port: 8080, APIKey: "secret", Timeout: 5 * time.Second, Hosts: []string{"a.com", "b.com"}, Ratio: 0.5}
+	want.DB.URL = "postgres://db"
+
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Expected %+v, received %+v", want, cfg)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		vars    map[string]string
+		missing bool
+		errors  []string
+	}{
+		{map[string]string{}, true, []string{"API_KEY"}},
+		{map[string]string{"API_KEY": "k", "PORT": "http"}, false, []string{"PORT (Port)"}},
+		{map[string]string{"PORT": "x", "TIMEOUT": "5", "DEBUG": "maybe"}, true, []string{"API_KEY", "PORT", "TIMEOUT", "DEBUG"}},
+	}
+
+	for _, tt := range tests {
+		var cfg config
+		err := env.LoadFrom(&cfg, lookup(tt.vars))
+		if err == nil {
+			t.Errorf("%v: expected an error", tt.vars)
+			continue
+		}
+
+		if errors.Is(err, env.ErrMissing) != tt.missing {
+			t.Errorf("%v: expected missing %v, received %v", tt.vars, tt.missing, err)
+		}
+
+		for _, e := range tt.errors {
+			if !strings.Contains(err.Error(), e) {
+				t.Errorf("%v: expected %q in %q", tt.vars, e, err)
+			}
+		}
+
+		var envErr *env.Error
+		if !errors.As(err, &envErr) {
+			t.Errorf("%v: expected an *env.Error, received %T", tt.vars, err)
+		}
+	}
+}
+
+func TestLoadNotStruct(t *testing.T) {
+	var n int
+	if err := env.Load(&n); err == nil {
+		t.Error("Expected an error loading into an int")
+	}
+}
@@ -0,0 +1,276 @@
+// Package errorsx attaches machine readable codes, mapped to HTTP statuses,
+// and optionally stack traces to errors, e.g.
+//
+//	user, err := db.User(id)
+//	if err != nil {
+//		return errorsx.Wrap(err, errorsx.NotFound, "no such user")
+//	}
+//
+// and, handling the request:
+//
+//	if errors.Is(err, errorsx.NotFound) { ... }
+//	resp.Error(w, errorsx.Status(err), err.Error())
+//
+// Codes are errors themselves, matched by errors.Is.  Any error with a
+// Code() Code method shares the taxonomy, such as req.HTTPError.
+package errorsx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+)
+
+// Code classifies errors, e.g. to choose the status of an HTTP response.
+type Code string
+
+// Codes, after those of gRPC
+const (
+	Unknown            Code = "unknown"
+	InvalidArgument    Code = "invalid_argument"
+	Unauthenticated    Code = "unauthenticated"
+	PermissionDenied   Code = "permission_denied"
+	NotFound           Code = "not_found"
+	Conflict           Code = "conflict"
+	FailedPrecondition Code = "failed_precondition"
+	RateLimited        Code = "rate_limited"
+	Canceled           Code = "canceled"
+	Internal           Code = "internal"
+	Unimplemented      Code = "unimplemented"
+	Unavailable        Code = "unavailable"
+	DeadlineExceeded   Code = "deadline_exceeded"
+)
+
+var statuses = map[Code]int{
+	Unknown:            http.StatusInternalServerError,
+	InvalidArgument:    http.StatusBadRequest,
+	Unauthenticated:    http.StatusUnauthorized,
+	PermissionDenied:   http.StatusForbidden,
+	NotFound:           http.StatusNotFound,
+	Conflict:           http.StatusConflict,
+	FailedPrecondition: http.StatusPreconditionFailed,
+	RateLimited:        http.StatusTooManyRequests,
+	Canceled:           499, // client closed request, as nginx
+	Internal:           http.StatusInternalServerError,
+	Unimplemented:      http.StatusNotImplemented,
+	Unavailable:        http.StatusServiceUnavailable,
+	DeadlineExceeded:   http.StatusGatewayTimeout,
+}
+
+// Error implements the Error method for Codes
+func (c Code) Error() string {
+	return string(c)
+}
+
+// Status returns the HTTP status of c, 500 for unknown codes.
+func (c Code) Status() int {
+	if status, ok := statuses[c]; ok {
+		return status
+	}
+
+	return http.StatusInternalServerError
+}
+
+// FromStatus returns the code of an HTTP error status.
+func FromStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return InvalidArgument
+	case http.StatusUnauthorized:
+		return Unauthenticated
+	case http.StatusForbidden:
+		return PermissionDenied
+	case http.StatusNotFound, http.StatusGone:
+		return NotFound
+	case http.StatusConflict:
+		return Conflict
+	case http.StatusPreconditionFailed:
+		return FailedPrecondition
+	case http.StatusTooManyRequests:
+		return RateLimited
+	case 499:
+		return Canceled
+	case http.StatusNotImplemented:
+		return Unimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return Unavailable
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return DeadlineExceeded
+	}
+
+	switch {
+	case status >= 400 && status < 500:
+		return InvalidArgument
+	case status >= 500:
+		return Internal
+	}
+
+	return Unknown
+}
+
+// Error is an error with a code, and maybe the error it wraps.
+type Error struct {
+	code Code
+	msg  string
+	err  error
+}
+
+// New returns an error with code and msg.
+func New(code Code, msg string) error {
+	return &Error{code: code, msg: msg}
+}
+
+// Newf is like New, formatting the message as in fmt.Sprintf.
+func Newf(code Code, format string, args ...any) error {
+	return &Error{code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+// Wrap returns err with code and msg, or nil when err is nil.
+func Wrap(err error, code Code, msg string) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{code, msg, err}
+}
+
+// Wrapf is like Wrap, formatting the message as in fmt.Sprintf.
+func Wrapf(err error, code Code, format string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{code, fmt.Sprintf(format, args...), err}
+}
+
+// Error implements the Error method for Errors: the message, then that of
+// the wrapped error
+func (e *Error) Error() string {
+	switch {
+	case e.err == nil && e.msg == "":
+		return string(e.code)
+	case e.err == nil:
+		return e.msg
+	case e.msg == "":
+		return e.err.Error()
+	}
+
+	return e.msg + ": " + e.err.Error()
+}
+
+// Code returns the code of e.
+func (e *Error) Code() Code {
+	return e.code
+}
+
+// Unwrap returns the wrapped error, if any
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Is matches the code of e.
+func (e *Error) Is(target error) bool {
+	c, ok := target.(Code)
+	return ok && c == e.code
+}
+
+// CodeOf returns the code of the first error in the chain of err having
+// one, Canceled or DeadlineExceeded for context errors, and Unknown
+// otherwise.  The code of nil is "".
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+
+	var coder interface{ Code() Code }
+	if errors.As(err, &coder) {
+		return coder.Code()
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return DeadlineExceeded
+	}
+
+	return Unknown
+}
+
+// Status returns the HTTP status for err, 200 for nil.
+func Status(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	return CodeOf(err).Status()
+}
+
+// maxDepth is the number of frames kept by WithStack
+const maxDepth = 32
+
+// stack is an error with the stack trace of where it was annotated
+type stack struct {
+	err error
+	pcs []uintptr
+}
+
+// WithStack annotates err with the stack trace of its caller, printed by
+// the %+v verb and StackTrace.  Errors already annotated are returned as
+// they are, as is nil.
+func WithStack(err error) error {
+	var s *stack
+	if err == nil || errors.As(err, &s) {
+		return err
+	}
+
+	pcs := make([]uintptr, maxDepth)
+	n := runtime.Callers(2, pcs)
+	return &stack{err, pcs[:n]}
+}
+
+func (s *stack) Error() string {
+	return s.err.Error()
+}
+
+func (s *stack) Unwrap() error {
+	return s.err
+}
+
+// Format prints the stack trace after the error with %+v.
+func (s *stack) Format(f fmt.State, verb rune) {
+	io.WriteString(f, s.err.Error())
+	if verb == 'v' && f.Flag('+') {
+		io.WriteString(f, "\n")
+		io.WriteString(f, s.trace())
+	}
+}
+
+// trace formats the frames, as in panics
+func (s *stack) trace() string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(s.pcs)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+
+	return b.String()
+}
+
+// StackTrace returns the stack trace annotating err, "" if none.
+func StackTrace(err error) string {
+	var s *stack
+	if !errors.As(err, &s) {
+		return ""
+	}
+
+	return s.trace()
+}
@@ -0,0 +1,120 @@
+package errorsx_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sspencer/goal/errorsx"
+	"github.com/sspencer/goal/req"
+)
+
+func TestCodeOf(t *testing.T) {
+	notFound := errorsx.New(errorsx.NotFound, "no such user")
+
+	tests := []struct {
+		name   string
+		err    error
+		code   errorsx.Code
+		status int
+		msg    string
+	}{
+		{"nil", nil, "", 200, ""},
+		{"plain", io.EOF, errorsx.Unknown, 500, "EOF"},
+		{"new", notFound, errorsx.NotFound, 404, "no such user"},
+		{"newf", errorsx.Newf(errorsx.Conflict, "user %d exists", 7), errorsx.Conflict, 409, "user 7 exists"},
+		{"wrap", errorsx.Wrap(io.EOF, errorsx.InvalidArgument, "reading body"), errorsx.InvalidArgument, 400, "reading body: EOF"},
+		{"outer code", errorsx.Wrap(notFound, errorsx.Internal, "loading"), errorsx.Internal, 500, "loading: no such user"},
+		{"fmt wrapped", fmt.Errorf("handler: %w", notFound), errorsx.NotFound, 404, "handler: no such user"},
+		{"code only", errorsx.New(errorsx.RateLimited, ""), errorsx.RateLimited, 429, "rate_limited"},
+		{"canceled", fmt.Errorf("query: %w", context.Canceled), errorsx.Canceled, 499, "query: context canceled"},
+		{"deadline", context.DeadlineExceeded, errorsx.DeadlineExceeded, 504, "context deadline exceeded"},
+		{"http", req.HTTPError{StatusCode: 401}, errorsx.Unauthenticated, 401, "Error making HTTP request.  HTTP Status 401: "},
+		{"http 5XX", req.HTTPError{StatusCode: 502}, errorsx.Unavailable, 503, "Error making HTTP request.  HTTP Status 502: "},
+	}
+
+	for _, tt := range tests {
+		if code := errorsx.CodeOf(tt.err); code != tt.code {
+			t.Errorf("%s: expected code %s, received %s", tt.name, tt.code, code)
+		}
+		if status := errorsx.Status(tt.err); status != tt.status {
+			t.Errorf("%s: expected status %d, received %d", tt.name, tt.status, status)
+		}
+		if tt.err != nil && tt.err.Error() != tt.msg {
+			t.Errorf("%s: expected %q, received %q", tt.name, tt.msg, tt.err.Error())
+		}
+	}
+}
+
+func TestIs(t *testing.T) {
+	err := errorsx.Wrap(errorsx.New(errorsx.NotFound, "no such user"), errorsx.Internal, "loading")
+
+	if !errors.Is(err, errorsx.Internal) || !errors.Is(err, errorsx.NotFound) {
+		t.Errorf("Expected %v to match both of its codes", err)
+	}
+	if errors.Is(err, errorsx.Conflict) {
+		t.Errorf("Expected %v not to match %s", err, errorsx.Conflict)
+	}
+	if !errors.Is(fmt.Errorf("fetch: %w", req.HTTPError{StatusCode: 404}), errorsx.NotFound) {
+		t.Error("Expected a 404 HTTPError to match NotFound")
+	}
+	if errorsx.Wrap(nil, errorsx.Internal, "nothing") != nil {
+		t.Error("Expected wrapping nil to return nil")
+	}
+
+	var e *errorsx.Error
+	if !errors.As(err, &e) || e.Code() != errorsx.Internal {
+		t.Errorf("Expected an Error with code %s, received %v", errorsx.Internal, e)
+	}
+}
+
+func TestFromStatus(t *testing.T) {
+	tests := map[int]errorsx.Code{
+		400: errorsx.InvalidArgument,
+		418: errorsx.InvalidArgument,
+		404: errorsx.NotFound,
+		410: errorsx.NotFound,
+		429: errorsx.RateLimited,
+		500: errorsx.Internal,
+		503: errorsx.Unavailable,
+		504: errorsx.DeadlineExceeded,
+		200: errorsx.Unknown,
+	}
+
+	for status, want := range tests {
+		if code := errorsx.FromStatus(status); code != want {
+			t.Errorf("%d: expected %s, received %s", status, want, code)
+		}
+	}
+}
+
+func TestWithStack(t *testing.T) {
+	err := errorsx.WithStack(errorsx.New(errorsx.Internal, "boom"))
+
+	if errorsx.WithStack(err) != err {
+		t.Error("Expected an annotated error to be returned as is")
+	}
+	if errorsx.WithStack(nil) != nil {
+		t.Error("Expected nil to be returned as is")
+	}
+	if !errors.Is(err, errorsx.Internal) || err.Error() != "boom" {
+		t.Errorf("Expected the annotated error to behave as the original, received %v", err)
+	}
+
+	trace := errorsx.StackTrace(err)
+	if !strings.Contains(trace, "errorsx_test.TestWithStack") {
+		t.Errorf("Expected the test in the stack trace, received %s", trace)
+	}
+	if out := fmt.Sprintf("%+v", err); !strings.HasPrefix(out, "boom\n") || !strings.Contains(out, "errorsx_test.go:") {
+		t.Errorf("Expected the error and its stack trace, received %s", out)
+	}
+	if out := fmt.Sprintf("%v", err); out != "boom" {
+		t.Errorf("Expected boom, received %s", out)
+	}
+	if errorsx.StackTrace(io.EOF) != "" {
+		t.Error("Expected no stack trace for plain errors")
+	}
+}
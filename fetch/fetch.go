@@ -0,0 +1,79 @@
+// Package fetch runs many HTTP requests concurrently, combining the req
+// client with the str worker pool, and decodes their JSON responses.
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/sspencer/goal/req"
+	"github.com/sspencer/goal/str"
+)
+
+// Spec describes a request of a bulk fetch.
+type Spec struct {
+	Method      req.Method // GET when empty
+	URL         string
+	Body        []byte
+	ContentType string
+}
+
+// URLs returns GET specs for urls.
+func URLs(urls ...string) []Spec {
+	specs := make([]Spec, len(urls))
+	for i, url := range urls {
+		specs[i] = Spec{URL: url}
+	}
+
+	return specs
+}
+
+// All performs every request with r (req.New() when nil), up to
+// 'numWorkers' at a time, and decodes the JSON body of every response into
+// a T.  Empty bodies leave the zero T.  Options of the str package apply,
+// e.g. str.RetryPolicy to retry failed requests, str.RateLimit to respect
+// the quota of an API, or str.Ordered.  Errors are reported per spec, as a
+// req.HTTPError for non 2XX responses.
+func All[T any](ctx context.Context, r *req.Request, numWorkers int, specs []Spec, opts ...str.Option) ([]str.Result[Spec, T], error) {
+	if r == nil {
+		r = req.New()
+	}
+
+	return str.MapCtx(ctx, numWorkers, specs, func(ctx context.Context, s Spec) (T, error) {
+		return do[T](ctx, r, s)
+	}, opts...)
+}
+
+// Get is like All, for GET requests of urls with a default req.Request.
+func Get[T any](ctx context.Context, numWorkers int, urls []string, opts ...str.Option) ([]str.Result[Spec, T], error) {
+	return All[T](ctx, nil, numWorkers, URLs(urls...), opts...)
+}
+
+// do performs a single request, decoding its response
+func do[T any](ctx context.Context, r *req.Request, s Spec) (T, error) {
+	var out T
+
+	method := s.Method
+	if method == "" {
+		method = req.MethodGet
+	}
+
+	var body io.Reader
+	if s.Body != nil {
+		body = bytes.NewReader(s.Body)
+	}
+
+	var opts []req.RequestFunc
+	if s.ContentType != "" {
+		opts = append(opts, req.ContentType(s.ContentType))
+	}
+
+	resp, err := r.DoCtx(ctx, method, s.URL, body, opts...)
+	if err != nil {
+		return out, err
+	}
+
+	err = req.UnmarshalAllowEmpty(resp.Body, &out)
+	return out, err
+}
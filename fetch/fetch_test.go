@@ -0,0 +1,66 @@
+package fetch_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sspencer/goal/fetch"
+	"github.com/sspencer/goal/req"
+	"github.com/sspencer/goal/str"
+)
+
+type item struct {
+	ID int `json:"id"`
+}
+
+func TestGet(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			fmt.Fprintf(w, `{"id": %s}`, r.URL.Path[1:])
+		}
+	}))
+	defer ts.Close()
+
+	urls := []string{ts.URL + "/1", ts.URL + "/2", ts.URL + "/missing", ts.URL + "/empty"}
+	results, err := fetch.Get[item](context.Background(), 2, urls, str.Ordered())
+
+	var httpErr req.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a 404 HTTPError, received %v", err)
+	}
+
+	for i, expected := range []int{1, 2, 0, 0} {
+		if results[i].Output.ID != expected {
+			t.Errorf("For %s, expected ID %d, received %d", results[i].Input.URL, expected, results[i].Output.ID)
+		}
+	}
+
+	if results[3].Err != nil {
+		t.Errorf("Expected an empty body to decode, received %v", results[3].Err)
+	}
+}
+
+func TestAll(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.Header.Get("Content-Type") != req.JSONContentType {
+			t.Errorf("Unexpected %s request of %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		fmt.Fprint(w, `{"id": 7}`)
+	}))
+	defer ts.Close()
+
+	specs := []fetch.Spec{{Method: req.MethodPut, URL: ts.URL, Body: []byte(`{}`), ContentType: req.JSONContentType}}
+	results, err := fetch.All[item](context.Background(), req.New(), 1, specs)
+	if err != nil || results[0].Output.ID != 7 {
+		t.Errorf("Expected ID 7, received %+v, %v", results, err)
+	}
+}
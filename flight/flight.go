@@ -0,0 +1,158 @@
+// Package flight coalesces concurrent calls for the same key into a single
+// execution, whose result is then memoized for a while, e.g.
+//
+//	tokens := flight.New[string, string](time.Minute)
+//	token, err := tokens.Do(ctx, "api", func(ctx context.Context) (string, error) {
+//		return fetchToken(ctx)
+//	})
+//
+// Unlike a cache, a Group only holds the keys called recently: expired
+// results are swept as calls go, at most every TTL, so none is held much
+// longer than twice the TTL.  Results expire as soon as the TTL is up.
+package flight
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Group runs one call per key at a time, sharing its result with every
+// caller for the same key until the TTL is up.  Errors are shared with the
+// callers waiting, but not memoized, and so are panics, raised again in
+// every caller.  It is safe for concurrent use.
+type Group[K comparable, V any] struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	calls map[K]*call[V]
+	swept time.Time
+}
+
+// call is a call in progress, or done and memoized until expires
+type call[V any] struct {
+	done    chan struct{}
+	value   V
+	err     error
+	expires time.Time
+}
+
+// New returns a Group memoizing results for ttl, or only sharing calls in
+// progress when 0.
+func New[K comparable, V any](ttl time.Duration) *Group[K, V] {
+	return &Group[K, V]{ttl: ttl, calls: make(map[K]*call[V])}
+}
+
+// Do returns the result of fn for key, calling it unless a call for key is
+// in progress or memoized.  Callers sharing a call in progress stop waiting
+// when their ctx is done, but the call goes on.  The ctx of fn is that of
+// the caller that started it, without its cancellation, so one caller
+// giving up doesn't fail the others.
+func (g *Group[K, V]) Do(ctx context.Context, key K, fn func(context.Context) (V, error)) (V, error) {
+	v, err, _ := g.DoShared(ctx, key, fn)
+	return v, err
+}
+
+// DoShared is like Do, and also reports whether the result came from
+// another call.
+func (g *Group[K, V]) DoShared(ctx context.Context, key K, fn func(context.Context) (V, error)) (V, error, bool) {
+	g.mu.Lock()
+	now := time.Now()
+	if g.ttl > 0 && now.Sub(g.swept) >= g.ttl {
+		g.sweep(now)
+	}
+
+	if c, ok := g.calls[key]; ok {
+		if c.expires.IsZero() || now.Before(c.expires) {
+			g.mu.Unlock()
+			v, err := c.wait(ctx)
+			return v, err, true
+		}
+		delete(g.calls, key)
+	}
+
+	c := &call[V]{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	go g.run(context.WithoutCancel(ctx), key, c, fn)
+	v, err := c.wait(ctx)
+	return v, err, false
+}
+
+// Forget drops the memoized result of key, so the next call for it runs
+// again.  Callers sharing a call in progress still get its result.
+func (g *Group[K, V]) Forget(key K) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.calls, key)
+}
+
+// Len returns the number of keys with a call in progress or memoized,
+// including results expired but not swept yet.
+func (g *Group[K, V]) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return len(g.calls)
+}
+
+// sweep drops the expired results, with g.mu held
+func (g *Group[K, V]) sweep(now time.Time) {
+	for key, c := range g.calls {
+		if !c.expires.IsZero() && !now.Before(c.expires) {
+			delete(g.calls, key)
+		}
+	}
+	g.swept = now
+}
+
+// run calls fn, then memoizes its result, or forgets it after an error or
+// a panic
+func (g *Group[K, V]) run(ctx context.Context, key K, c *call[V], fn func(context.Context) (V, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.err = &panicError{r, debug.Stack()}
+		}
+
+		g.mu.Lock()
+		if g.calls[key] == c {
+			if c.err != nil || g.ttl <= 0 {
+				delete(g.calls, key)
+			} else {
+				c.expires = time.Now().Add(g.ttl)
+			}
+		}
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	c.value, c.err = fn(ctx)
+}
+
+// panicError is a panic of fn, with its stack, raised again in the callers
+type panicError struct {
+	value any
+	stack []byte
+}
+
+func (p *panicError) Error() string {
+	return fmt.Sprintf("flight: %v\n\n%s", p.value, p.stack)
+}
+
+// wait returns the result of the call, or the error of ctx when it is done
+// first.  A panic of the call is raised again.
+func (c *call[V]) wait(ctx context.Context) (V, error) {
+	select {
+	case <-c.done:
+		if p, ok := c.err.(*panicError); ok {
+			panic(p)
+		}
+		return c.value, c.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
@@ -0,0 +1,162 @@
+package flight_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/flight"
+)
+
+func TestDo(t *testing.T) {
+	g := flight.New[string, int](50 * time.Millisecond)
+
+	var calls atomic.Int32
+	fn := func(ctx context.Context) (int, error) {
+		time.Sleep(10 * time.Millisecond)
+		return int(calls.Add(1)), nil
+	}
+
+	// concurrent callers share a call
+	var wg sync.WaitGroup
+	var shared atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err, ok := g.DoShared(context.Background(), "a", fn)
+			if v != 1 || err != nil {
+				t.Errorf("Expected 1, received %d %v", v, err)
+			}
+			if ok {
+				shared.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if shared.Load() != 9 {
+		t.Errorf("Expected 9 shared results, received %d", shared.Load())
+	}
+
+	tests := []struct {
+		name  string
+		key   string
+		sleep time.Duration
+		value int
+	}{
+		{"memoized", "a", 0, 1},
+		{"other key", "b", 0, 2},
+		{"expired", "a", 60 * time.Millisecond, 3},
+	}
+
+	for _, tt := range tests {
+		time.Sleep(tt.sleep)
+		if v, _ := g.Do(context.Background(), tt.key, fn); v != tt.value {
+			t.Errorf("%s: expected %d, received %d", tt.name, tt.value, v)
+		}
+	}
+
+	g.Forget("a")
+	if v, _ := g.Do(context.Background(), "a", fn); v != 4 {
+		t.Errorf("Expected a forgotten key to run again, received %d", v)
+	}
+}
+
+func TestDoError(t *testing.T) {
+	g := flight.New[string, int](time.Minute)
+	fail := errors.New("fail")
+
+	calls := 0
+	fn := func(ctx context.Context) (int, error) {
+		calls++
+		return 0, fail
+	}
+
+	g.Do(context.Background(), "a", fn)
+	if _, err := g.Do(context.Background(), "a", fn); err != fail {
+		t.Errorf("Expected %v, received %v", fail, err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected errors not to be memoized, received %d calls", calls)
+	}
+}
+
+func TestDoCanceled(t *testing.T) {
+	g := flight.New[string, int](time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := g.Do(ctx, "a", func(ctx context.Context) (int, error) {
+		time.Sleep(30 * time.Millisecond)
+		return 1, ctx.Err()
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected the caller to give up, received %v", err)
+	}
+
+	// the call went on without the deadline, and is shared
+	v, err := g.Do(context.Background(), "a", nil)
+	if v != 1 || err != nil {
+		t.Errorf("Expected 1, received %d %v", v, err)
+	}
+}
+
+func TestDoPanic(t *testing.T) {
+	g := flight.New[string, int](time.Minute)
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	var recovered atomic.Int32
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					recovered.Add(1)
+				}
+			}()
+			g.Do(context.Background(), "a", func(ctx context.Context) (int, error) {
+				<-release
+				panic("boom")
+			})
+		}()
+	}
+
+	for g.Len() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond) // for every caller to wait
+	close(release)
+	wg.Wait()
+
+	if recovered.Load() != 3 {
+		t.Errorf("Expected the panic in every caller, received %d", recovered.Load())
+	}
+	if v, err := g.Do(context.Background(), "a", func(ctx context.Context) (int, error) { return 1, nil }); v != 1 || err != nil {
+		t.Errorf("Expected panics not to be memoized, received %d, %v", v, err)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	g := flight.New[int, int](10 * time.Millisecond)
+	fn := func(ctx context.Context) (int, error) { return 1, nil }
+
+	for key := 0; key < 5; key++ {
+		g.Do(context.Background(), key, fn)
+	}
+	if g.Len() != 5 {
+		t.Errorf("Expected 5 keys, received %d", g.Len())
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	g.Do(context.Background(), 5, fn)
+	if g.Len() != 1 {
+		t.Errorf("Expected the expired keys to be swept, received %d keys", g.Len())
+	}
+}
@@ -0,0 +1,61 @@
+package fsu
+
+import (
+	"io"
+	"os"
+
+	"github.com/sspencer/goal/bufpool"
+)
+
+// Copy copies the file src to dst atomically, keeping its permissions.
+// progress, when not nil, is called after every chunk with the bytes copied
+// so far and the size of src.
+func Copy(dst, src string, progress func(copied, total int64)) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	return WriteAtomic(dst, info.Mode().Perm(), func(w io.Writer) error {
+		_, err := CopyProgress(w, in, info.Size(), progress)
+		return err
+	})
+}
+
+// CopyProgress copies r to w like io.Copy, calling progress, when not nil,
+// after every chunk with the bytes copied so far and total, e.g. the size
+// of a download (-1 if unknown).
+func CopyProgress(w io.Writer, r io.Reader, total int64, progress func(copied, total int64)) (int64, error) {
+	if progress == nil {
+		return bufpool.Copy(w, r)
+	}
+
+	chunk := bufpool.GetChunk()
+	defer bufpool.PutChunk(chunk)
+
+	buf := *chunk
+	var copied int64
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return copied, werr
+			}
+			copied += int64(n)
+			progress(copied, total)
+		}
+
+		if err == io.EOF {
+			return copied, nil
+		}
+		if err != nil {
+			return copied, err
+		}
+	}
+}
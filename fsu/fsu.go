@@ -0,0 +1,117 @@
+// Package fsu writes files atomically, copies them with progress, and
+// manages directories and temporary files.
+package fsu
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile writes data to path atomically (see WriteAtomic).
+func WriteFile(path string, data []byte, perm fs.FileMode) error {
+	return WriteAtomic(path, perm, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// WriteAtomic writes path with fn: the output of fn goes to a temporary file
+// in the same directory, which is synced and renamed to path once fn
+// succeeds.  Readers see the old or the new file, never a partial one, even
+// after a crash.  The file gets the permissions perm.
+func WriteAtomic(path string, perm fs.FileMode, fn func(w io.Writer) error) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	w := bufio.NewWriter(tmp)
+	if err := fn(w); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// EnsureDir creates the directory at path, along with its parents, unless
+// it exists.  It fails when path is a file.
+func EnsureDir(path string) error {
+	info, err := os.Stat(path)
+	if err == nil {
+		if !info.IsDir() {
+			return &fs.PathError{Op: "mkdir", Path: path, Err: errors.New("not a directory")}
+		}
+		return nil
+	}
+
+	return os.MkdirAll(path, 0o755)
+}
+
+// IsTerminal returns TRUE if w is a file on a character device, e.g. a
+// TTY, to decide whether to write colors or redraw lines.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Exists returns TRUE if there is a file or directory at path.
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// WithTempFile calls fn with a new temporary file in dir (the default
+// temporary directory when empty), named after pattern like os.CreateTemp.
+// The file is closed and removed once fn returns.
+func WithTempFile(dir, pattern string, fn func(f *os.File) error) error {
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	return fn(f)
+}
+
+// WithTempDir calls fn with a new temporary directory, removed with its
+// content once fn returns.
+func WithTempDir(pattern string, fn func(dir string) error) error {
+	dir, err := os.MkdirTemp("", pattern)
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(dir)
+
+	return fn(dir)
+}
@@ -0,0 +1,137 @@
+package fsu_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sspencer/goal/fsu"
+)
+
+func TestWriteAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.txt")
+
+	if err := fsu.WriteFile(path, []byte("one"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// a failed write leaves the file alone
+	failed := errors.New("failed")
+	err := fsu.WriteAtomic(path, 0o600, func(w io.Writer) error {
+		w.Write([]byte("partial"))
+		return failed
+	})
+	if err != failed {
+		t.Errorf("Expected %v, received %v", failed, err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "one" {
+		t.Errorf("Expected one, received %q", data)
+	}
+
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected permissions 0600, received %v", info.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary file left, received %d files", len(entries))
+	}
+}
+
+func TestCopy(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "src"), filepath.Join(dir, "dst")
+
+	data := bytes.Repeat([]byte("x"), 600*1024)
+	if err := os.WriteFile(src, data, 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	var reports []int64
+	err := fsu.Copy(dst, src, func(copied, total int64) {
+		if total != int64(len(data)) {
+			t.Errorf("Expected total %d, received %d", len(data), total)
+		}
+		reports = append(reports, copied)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	copied, _ := os.ReadFile(dst)
+	if !bytes.Equal(copied, data) {
+		t.Error("Expected identical copy")
+	}
+	if len(reports) < 3 || reports[len(reports)-1] != int64(len(data)) {
+		t.Errorf("Expected progress up to %d, received %v", len(data), reports)
+	}
+
+	if info, _ := os.Stat(dst); info.Mode().Perm() != 0o640 {
+		t.Errorf("Expected permissions 0640, received %v", info.Mode().Perm())
+	}
+
+	if err := fsu.Copy(dst, filepath.Join(dir, "missing"), nil); !os.IsNotExist(err) {
+		t.Errorf("Expected a not exist error, received %v", err)
+	}
+}
+
+func TestEnsureDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "a", "b")
+
+	for i := 0; i < 2; i++ {
+		if err := fsu.EnsureDir(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !fsu.Exists(dir) {
+		t.Error("Expected the directory to exist")
+	}
+
+	file := filepath.Join(dir, "file")
+	os.WriteFile(file, nil, 0o644)
+	if err := fsu.EnsureDir(file); err == nil {
+		t.Error("Expected an error for a file")
+	}
+}
+
+func TestTemp(t *testing.T) {
+	var name, dir string
+
+	fsu.WithTempFile("", "fsu-*.txt", func(f *os.File) error {
+		name = f.Name()
+		_, err := f.WriteString("temp")
+		return err
+	})
+
+	fsu.WithTempDir("fsu-*", func(d string) error {
+		dir = d
+		return os.WriteFile(filepath.Join(d, "file"), nil, 0o644)
+	})
+
+	if name == "" || fsu.Exists(name) {
+		t.Errorf("Expected %q to be removed", name)
+	}
+	if dir == "" || fsu.Exists(dir) {
+		t.Errorf("Expected %q to be removed", dir)
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	if fsu.IsTerminal(&bytes.Buffer{}) {
+		t.Error("Expected a buffer not to be a terminal")
+	}
+
+	fsu.WithTempFile("", "fsu-*", func(f *os.File) error {
+		if fsu.IsTerminal(f) {
+			t.Error("Expected a file not to be a terminal")
+		}
+		return nil
+	})
+}
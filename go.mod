@@ -1,3 +1,3 @@
 module github.com/sspencer/goal
 
-go 1.13
+go 1.23
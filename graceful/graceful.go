@@ -0,0 +1,135 @@
+// Package graceful runs an HTTP server until the program is interrupted,
+// then shuts it down without dropping the requests in flight, e.g.
+//
+//	srv := &http.Server{Addr: ":8080", Handler: mux}
+//	if err := graceful.Run(srv, graceful.BeforeShutdown(markNotReady)); err != nil {
+//		log.Fatal(err)
+//	}
+package graceful
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// defaultGracePeriod is how long requests in flight get to complete
+const defaultGracePeriod = 30 * time.Second
+
+// Option configures Run.
+type Option func(*config)
+
+type config struct {
+	grace    time.Duration
+	signals  []os.Signal
+	hooks    []func(context.Context)
+	listener net.Listener
+}
+
+// GracePeriod is how long requests in flight get to complete once shutting
+// down, 30 seconds by default.  Past it, their context is canceled and
+// their connections closed.
+func GracePeriod(d time.Duration) Option {
+	return func(c *config) {
+		c.grace = d
+	}
+}
+
+// Signals replaces the signals shutting down the server, SIGINT and
+// SIGTERM by default.  With none, only the ctx of RunContext does, e.g.
+// when the program handles signals itself.
+func Signals(sig ...os.Signal) Option {
+	return func(c *config) {
+		c.signals = sig
+	}
+}
+
+// BeforeShutdown calls fn when shutting down, before the server stops
+// accepting connections, e.g. to fail readiness checks so load balancers
+// stop sending traffic.  Hooks run in order, and share the grace period.
+func BeforeShutdown(fn func(ctx context.Context)) Option {
+	return func(c *config) {
+		c.hooks = append(c.hooks, fn)
+	}
+}
+
+// Listener serves on l, rather than listening on the Addr of the server.
+func Listener(l net.Listener) Option {
+	return func(c *config) {
+		c.listener = l
+	}
+}
+
+// Run serves srv until an interrupt or terminate signal.  See RunContext.
+func Run(srv *http.Server, opts ...Option) error {
+	return RunContext(context.Background(), srv, opts...)
+}
+
+// RunContext serves srv until ctx is done or a signal arrives, then shuts
+// it down gracefully: the BeforeShutdown hooks run, the server stops
+// accepting connections, and requests in flight get the grace period to
+// complete.  Request contexts derive from ctx, without its cancellation,
+// and are canceled when the grace period is over.  It returns nil after a
+// graceful shutdown, and the error of the server otherwise.
+func RunContext(ctx context.Context, srv *http.Server, opts ...Option) error {
+	c := config{grace: defaultGracePeriod, signals: []os.Signal{os.Interrupt, syscall.SIGTERM}}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	// NotifyContext catches every signal when given none, SIGURG included,
+	// which the runtime sends to preempt goroutines
+	stopCtx, stop := ctx, context.CancelFunc(func() {})
+	if len(c.signals) > 0 {
+		stopCtx, stop = signal.NotifyContext(ctx, c.signals...)
+	}
+	defer stop()
+
+	// requests outlive ctx until the grace period is over
+	base, cancelBase := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelBase()
+	srv.BaseContext = func(net.Listener) context.Context {
+		return base
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		if c.listener != nil {
+			served <- srv.Serve(c.listener)
+		} else {
+			served <- srv.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-served:
+		return err // failed to start, or closed by someone else
+	case <-stopCtx.Done():
+	}
+	stop() // a second signal kills the program
+
+	graceCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.grace)
+	defer cancel()
+
+	for _, hook := range c.hooks {
+		hook(graceCtx)
+	}
+
+	err := srv.Shutdown(graceCtx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		cancelBase()
+		srv.Close()
+		return err
+	}
+
+	if serr := <-served; !errors.Is(serr, http.ErrServerClosed) {
+		return serr
+	}
+
+	return err
+}
@@ -0,0 +1,103 @@
+package graceful_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/graceful"
+)
+
+func serve(t *testing.T, ctx context.Context, h http.Handler, opts ...graceful.Option) (string, <-chan error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- graceful.RunContext(ctx, &http.Server{Handler: h}, append(opts, graceful.Listener(l))...)
+	}()
+
+	return "http://" + l.Addr().String(), done
+}
+
+func TestDrain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	started := make(chan bool)
+	var hooked bool
+	url, done := serve(t, ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- true
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("done"))
+	}), graceful.BeforeShutdown(func(context.Context) { hooked = true }))
+
+	body := make(chan string)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		body <- string(b)
+	}()
+
+	<-started
+	cancel()
+
+	if b := <-body; b != "done" {
+		t.Errorf("Expected the request in flight to complete, received %q", b)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Expected a graceful shutdown, received %v", err)
+	}
+	if !hooked {
+		t.Error("Expected the BeforeShutdown hook to run")
+	}
+}
+
+func TestGracePeriod(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	started := make(chan bool)
+	canceled := make(chan bool, 1)
+	url, done := serve(t, ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- true
+		<-r.Context().Done()
+		canceled <- true
+	}), graceful.GracePeriod(20*time.Millisecond))
+
+	go http.Get(url)
+	<-started
+	cancel()
+
+	if err := <-done; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the grace period to expire, received %v", err)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("Expected the request context to be canceled")
+	}
+}
+
+func TestListenError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	srv := &http.Server{Addr: l.Addr().String()}
+	if err := graceful.Run(srv); err == nil {
+		t.Error("Expected an error listening on a busy address")
+	}
+}
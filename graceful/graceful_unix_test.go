@@ -0,0 +1,45 @@
+//go:build unix
+
+package graceful_test
+
+import (
+	"context"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/graceful"
+)
+
+func TestNoSignals(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	url, done := serve(t, ctx, http.NotFoundHandler(), graceful.Signals())
+
+	// once serving, signals are handled
+	if resp, err := http.Get(url); err == nil {
+		resp.Body.Close()
+	}
+
+	// sent by the runtime to preempt goroutines, and ignored by default
+	syscall.Kill(syscall.Getpid(), syscall.SIGURG)
+
+	select {
+	case err := <-done:
+		t.Fatalf("Expected only ctx to shut the server down, received %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if resp, err := http.Get(url); err != nil {
+		t.Errorf("Expected the server to be up, received %v", err)
+	} else {
+		resp.Body.Close()
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected a graceful shutdown, received %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+// Package har reads HTTP Archive (HAR 1.2) files, as saved by the devtools
+// of browsers, to inspect and replay the requests they recorded.
+package har
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// HAR is the root of a HAR file.
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+// Log holds the entries of a HAR file.
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+// Creator is the application that wrote the file.
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Entry is an exchange: a request and its response.
+type Entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Time            float64   `json:"time"` // milliseconds
+	Request         Request   `json:"request"`
+	Response        Response  `json:"response"`
+}
+
+// Request is a request recorded.
+type Request struct {
+	Method      string    `json:"method"`
+	URL         string    `json:"url"`
+	HTTPVersion string    `json:"httpVersion"`
+	Headers     []NVP     `json:"headers"`
+	QueryString []NVP     `json:"queryString"`
+	PostData    *PostData `json:"postData,omitempty"`
+	BodySize    int64     `json:"bodySize"`
+}
+
+// Response is a response recorded.
+type Response struct {
+	Status      int     `json:"status"`
+	StatusText  string  `json:"statusText"`
+	HTTPVersion string  `json:"httpVersion"`
+	Headers     []NVP   `json:"headers"`
+	Content     Content `json:"content"`
+	RedirectURL string  `json:"redirectURL"`
+	BodySize    int64   `json:"bodySize"`
+}
+
+// NVP is a name and value pair, e.g. a header.
+type NVP struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PostData is the body of a request.
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// Content is the body of a response.
+type Content struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"` // "base64" for binary bodies
+}
+
+// Load reads the HAR file at path.
+func Load(path string) (*HAR, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// Read decodes a HAR file from r.
+func Read(r io.Reader) (*HAR, error) {
+	var h HAR
+	if err := json.NewDecoder(r).Decode(&h); err != nil {
+		return nil, fmt.Errorf("har: %w", err)
+	}
+
+	return &h, nil
+}
+
+// hopHeaders are not replayed: they describe the recorded connection, or
+// are pseudo headers of HTTP/2
+var hopHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Host":              true,
+	"Keep-Alive":        true,
+	"Transfer-Encoding": true,
+	"Accept-Encoding":   true, // so bodies can be compared
+}
+
+// Header returns the headers of r, without those of the connection.
+func (r Request) Header() http.Header {
+	h := make(http.Header)
+	for _, nvp := range r.Headers {
+		name := http.CanonicalHeaderKey(nvp.Name)
+		if strings.HasPrefix(nvp.Name, ":") || hopHeaders[name] {
+			continue
+		}
+		h.Add(name, nvp.Value)
+	}
+
+	return h
+}
+
+// Body returns the body of r, nil when it has none.
+func (r Request) Body() []byte {
+	if r.PostData == nil || r.PostData.Text == "" {
+		return nil
+	}
+
+	return []byte(r.PostData.Text)
+}
+
+// HTTPRequest returns a request repeating r.
+func (r Request) HTTPRequest() (*http.Request, error) {
+	req, err := http.NewRequest(r.Method, r.URL, bytes.NewReader(r.Body()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = r.Header()
+
+	return req, nil
+}
+
+// Body returns the decoded body of the response.
+func (c Content) Body() ([]byte, error) {
+	if c.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(c.Text)
+	}
+
+	return []byte(c.Text), nil
+}
+
+// Header returns the headers of r.
+func (r Response) Header() http.Header {
+	h := make(http.Header)
+	for _, nvp := range r.Headers {
+		if !strings.HasPrefix(nvp.Name, ":") {
+			h.Add(http.CanonicalHeaderKey(nvp.Name), nvp.Value)
+		}
+	}
+
+	return h
+}
@@ -0,0 +1,66 @@
+package har_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sspencer/goal/har"
+)
+
+const file = `{"log": {"version": "1.2", "creator": {"name": "test", "version": "1"}, "entries": [
+	{"startedDateTime": "2025-01-15T10:30:00.000Z", "time": 12.5,
+	 "request": {"method": "POST", "url": "https://api.example.com/orders?dry=1", "httpVersion": "HTTP/2",
+		"headers": [{"name": ":authority", "value": "api.example.com"}, {"name": "content-type", "value": "application/json"},
+			{"name": "accept-encoding", "value": "gzip"}, {"name": "x-trace", "value": "a"}, {"name": "x-trace", "value": "b"}],
+		"postData": {"mimeType": "application/json", "text": "{\"id\":1}"}},
+	 "response": {"status": 201, "statusText": "Created", "headers": [{"name": "content-type", "value": "application/json"}],
+		"content": {"size": 5, "mimeType": "application/json", "text": "eyJvayI6dHJ1ZX0=", "encoding": "base64"}}}
+]}}`
+
+func TestRead(t *testing.T) {
+	h, err := har.Read(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Log.Entries) != 1 {
+		t.Fatalf("Expected 1 entry, received %d", len(h.Log.Entries))
+	}
+	e := h.Log.Entries[0]
+
+	r, err := e.Request.HTTPRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	tests := []struct {
+		name, expected, received string
+	}{
+		{"method", "POST", r.Method},
+		{"url", "https://api.example.com/orders?dry=1", r.URL.String()},
+		{"content type", "application/json", r.Header.Get("Content-Type")},
+		{"repeated header", "a,b", strings.Join(r.Header.Values("X-Trace"), ",")},
+		{"pseudo header", "", r.Header.Get(":authority")},
+		{"accept encoding", "", r.Header.Get("Accept-Encoding")},
+		{"body", `{"id":1}`, string(body)},
+		{"started", "10:30:00", e.StartedDateTime.Format("15:04:05")},
+		{"response type", "application/json", e.Response.Header().Get("Content-Type")},
+	}
+
+	for _, tt := range tests {
+		if tt.received != tt.expected {
+			t.Errorf("%s: expected %q, received %q", tt.name, tt.expected, tt.received)
+		}
+	}
+
+	if b, err := e.Response.Content.Body(); err != nil || string(b) != `{"ok":true}` {
+		t.Errorf("Expected the decoded response body, received %q %v", b, err)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	if _, err := har.Read(strings.NewReader("{")); err == nil {
+		t.Error("Expected an error")
+	}
+}
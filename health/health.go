@@ -0,0 +1,199 @@
+// Package health serves the liveness (/healthz) and readiness (/readyz)
+// endpoints of a service, aggregating named checks, e.g.
+//
+//	h := health.New()
+//	h.Ready("db", db.PingContext)
+//	h.Ready("billing", health.Ping("http://billing/healthz"))
+//	h.Register(mux)
+package health
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sspencer/goal/req"
+	"github.com/sspencer/goal/resp"
+)
+
+// Status of a check, or of all of them.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// defaultTimeout bounds how long a check may take
+const defaultTimeout = 5 * time.Second
+
+// Check returns an error when the service, or one of its dependencies, is
+// unhealthy.  It must return once ctx is done.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of a check.
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the body of the health endpoints: "ok" if every check
+// passed, "fail" otherwise.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Health holds the checks of the endpoints.  It is safe for concurrent use.
+type Health struct {
+	// Timeout bounds every check, 5 seconds when 0
+	Timeout time.Duration
+
+	mu        sync.Mutex
+	liveness  map[string]Check
+	readiness map[string]Check
+}
+
+// New returns a Health without checks, whose endpoints are always ok.
+func New() *Health {
+	return &Health{liveness: make(map[string]Check), readiness: make(map[string]Check)}
+}
+
+// Live adds a liveness check, failing when the service should be restarted,
+// e.g. deadlocked.  Liveness checks are readiness checks too.
+func (h *Health) Live(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.liveness[name] = check
+}
+
+// Ready adds a readiness check, failing when the service can't serve
+// traffic for now, e.g. its database is down.
+func (h *Health) Ready(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.readiness[name] = check
+}
+
+// Liveness serves the report of the liveness checks, with 503 Service
+// Unavailable when one fails.
+func (h *Health) Liveness() http.Handler {
+	return h.handler(false)
+}
+
+// Readiness serves the report of the liveness and readiness checks, with
+// 503 Service Unavailable when one fails.
+func (h *Health) Readiness() http.Handler {
+	return h.handler(true)
+}
+
+// Register serves Liveness on GET /healthz, and Readiness on GET /readyz.
+func (h *Health) Register(mux *http.ServeMux) {
+	mux.Handle("GET /healthz", h.Liveness())
+	mux.Handle("GET /readyz", h.Readiness())
+}
+
+// Run runs the checks concurrently, readiness checks included if ready.
+func (h *Health) Run(ctx context.Context, ready bool) Report {
+	checks := h.checks(ready)
+
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = r
+			if r.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+// Names returns the names of the checks, sorted.
+func (h *Health) Names(ready bool) []string {
+	var names []string
+	for name := range h.checks(ready) {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func (h *Health) checks(ready bool) map[string]Check {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	checks := make(map[string]Check, len(h.liveness)+len(h.readiness))
+	for name, c := range h.liveness {
+		checks[name] = c
+	}
+	if ready {
+		for name, c := range h.readiness {
+			checks[name] = c
+		}
+	}
+
+	return checks
+}
+
+func (h *Health) handler(ready bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := h.Run(r.Context(), ready)
+
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		resp.JSON(w, status, report)
+	})
+}
+
+// run runs a check, timing it
+func run(ctx context.Context, check Check) Result {
+	start := time.Now()
+	err := check(ctx)
+	r := Result{Status: StatusOK, LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err() // finished too late
+	}
+	if err != nil {
+		r.Status = StatusFail
+		r.Error = err.Error()
+	}
+
+	return r
+}
+
+// Ping checks that a GET of url succeeds with a 2XX status, e.g. the health
+// endpoint of an upstream service.
+func Ping(url string) Check {
+	return func(ctx context.Context) error {
+		return req.New().Timeout(defaultTimeout).PingCtx(ctx, url)
+	}
+}
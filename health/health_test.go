@@ -0,0 +1,82 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/health"
+)
+
+func TestHealth(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+
+	h := health.New()
+	h.Timeout = 50 * time.Millisecond
+	h.Live("loop", func(ctx context.Context) error { return nil })
+	h.Ready("db", func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})
+	h.Ready("upstream", health.Ping(upstream.URL))
+	h.Ready("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	mux := http.NewServeMux()
+	h.Register(mux)
+
+	tests := []struct {
+		path   string
+		status int
+		checks map[string]string
+	}{
+		{"/healthz", 200, map[string]string{"loop": "ok"}},
+		{"/readyz", 503, map[string]string{"loop": "ok", "db": "fail", "upstream": "ok", "slow": "fail"}},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected %d, received %d", tt.path, tt.status, w.Code)
+		}
+
+		var report health.Report
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(report.Checks) != len(tt.checks) {
+			t.Errorf("%s: expected %d checks, received %v", tt.path, len(tt.checks), report.Checks)
+		}
+		for name, status := range tt.checks {
+			if report.Checks[name].Status != status {
+				t.Errorf("%s: expected %s %s, received %+v", tt.path, name, status, report.Checks[name])
+			}
+		}
+	}
+
+	if r := h.Run(context.Background(), true).Checks["db"]; r.Error != "connection refused" {
+		t.Errorf("Expected the error of db, received %+v", r)
+	}
+
+	if names := h.Names(true); len(names) != 4 || names[0] != "db" {
+		t.Errorf("Expected 4 sorted names, received %v", names)
+	}
+}
+
+func TestHealthEmpty(t *testing.T) {
+	w := httptest.NewRecorder()
+	health.New().Readiness().ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+
+	if w.Code != 200 {
+		t.Errorf("Expected 200 without checks, received %d", w.Code)
+	}
+}
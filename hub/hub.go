@@ -0,0 +1,209 @@
+// Package hub pushes messages to many connected clients, e.g. WebSocket
+// connections.  Every client gets its own send queue and writer goroutine,
+// so a slow client never holds back the others.  The hub doesn't depend on
+// a WebSocket library: wrap connections in the Conn interface, e.g. with
+// gorilla/websocket
+//
+//	type wsConn struct{ *websocket.Conn }
+//
+//	func (c wsConn) Send(ctx context.Context, msg []byte) error {
+//		deadline, _ := ctx.Deadline()
+//		c.SetWriteDeadline(deadline)
+//		return c.WriteMessage(websocket.TextMessage, msg)
+//	}
+package hub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is returned by Client.Send when the send queue is full.
+var ErrQueueFull = errors.New("hub: send queue full")
+
+// ErrClosed is returned by Client.Send once the client is unregistered.
+var ErrClosed = errors.New("hub: client closed")
+
+// Conn is a connection to a client.
+type Conn interface {
+	// Send writes a message, giving up when ctx is done
+	Send(ctx context.Context, msg []byte) error
+
+	// Close closes the connection
+	Close() error
+}
+
+// Policy decides what happens to clients whose send queue is full.
+type Policy int
+
+const (
+	// Disconnect unregisters and closes the client, which can reconnect
+	// and resync (the default)
+	Disconnect Policy = iota
+
+	// DropMessage drops the message for the client, which stays connected
+	DropMessage
+)
+
+// Option configures a Hub.
+type Option func(*Hub)
+
+// QueueSize is the number of messages queued per client, 64 by default.
+func QueueSize(n int) Option {
+	return func(h *Hub) {
+		h.queue = max(n, 1)
+	}
+}
+
+// WriteTimeout bounds how long a message may take to send, 10 seconds by
+// default.  Clients failing to send are unregistered.
+func WriteTimeout(d time.Duration) Option {
+	return func(h *Hub) {
+		h.timeout = d
+	}
+}
+
+// OnFull sets the policy for clients whose queue is full.
+func OnFull(p Policy) Option {
+	return func(h *Hub) {
+		h.policy = p
+	}
+}
+
+// Hub holds the clients connected.  It is safe for concurrent use.
+type Hub struct {
+	queue   int
+	timeout time.Duration
+	policy  Policy
+
+	mu      sync.Mutex
+	clients map[*Client]struct{}
+}
+
+// New returns a Hub without clients.
+func New(opts ...Option) *Hub {
+	h := &Hub{queue: 64, timeout: 10 * time.Second, clients: make(map[*Client]struct{})}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// Register adds a client on conn, until it is unregistered or fails to
+// send.  The connection is closed then.
+func (h *Hub) Register(conn Conn) *Client {
+	c := &Client{hub: h, conn: conn, send: make(chan []byte, h.queue), done: make(chan struct{})}
+
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+
+	go c.write()
+	return c
+}
+
+// Unregister removes the client c, closing its connection once the
+// messages queued are sent.
+func (h *Hub) Unregister(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(c)
+}
+
+// Broadcast queues msg for every client.  Clients with a full queue are
+// handled according to the OnFull policy.
+func (h *Hub) Broadcast(msg []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for c := range h.clients {
+		select {
+		case c.send <- msg:
+		default:
+			if h.policy == Disconnect {
+				h.remove(c)
+			}
+		}
+	}
+}
+
+// Len returns the number of clients.
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.clients)
+}
+
+// Close unregisters every client.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for c := range h.clients {
+		h.remove(c)
+	}
+}
+
+// remove unregisters c, with h.mu held
+func (h *Hub) remove(c *Client) {
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		close(c.send)
+	}
+}
+
+// Client is a client registered with a Hub.
+type Client struct {
+	hub  *Hub
+	conn Conn
+	send chan []byte
+	done chan struct{}
+}
+
+// Send queues msg for c alone, returning ErrQueueFull if its queue is full,
+// whatever the OnFull policy.
+func (c *Client) Send(msg []byte) error {
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+
+	if _, ok := c.hub.clients[c]; !ok {
+		return ErrClosed
+	}
+
+	select {
+	case c.send <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Done is closed once the client is unregistered and its connection closed.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// write sends the queued messages until the client is unregistered
+func (c *Client) write() {
+	defer close(c.done)
+	defer c.conn.Close()
+
+	for msg := range c.send {
+		ctx, cancel := context.WithTimeout(context.Background(), c.hub.timeout)
+		err := c.conn.Send(ctx, msg)
+		cancel()
+
+		if err != nil {
+			c.hub.Unregister(c)
+			for range c.send {
+				// drain
+			}
+			return
+		}
+	}
+}
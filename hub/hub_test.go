@@ -0,0 +1,140 @@
+package hub_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/hub"
+)
+
+// conn records the messages sent, blocking while gate is set
+type conn struct {
+	mu     sync.Mutex
+	msgs   []string
+	closed bool
+	gate   chan struct{}
+	err    error
+}
+
+func (c *conn) Send(ctx context.Context, msg []byte) error {
+	if c.gate != nil {
+		select {
+		case <-c.gate:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.msgs = append(c.msgs, string(msg))
+	return nil
+}
+
+func (c *conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *conn) received() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.msgs...)
+}
+
+func TestBroadcast(t *testing.T) {
+	h := hub.New()
+	a, b := &conn{}, &conn{}
+	ca, cb := h.Register(a), h.Register(b)
+
+	h.Broadcast([]byte("one"))
+	h.Broadcast([]byte("two"))
+	cb.Send([]byte("only b"))
+
+	h.Close()
+	<-ca.Done()
+	<-cb.Done()
+
+	if got := a.received(); len(got) != 2 || got[0] != "one" || got[1] != "two" {
+		t.Errorf("Expected [one two], received %v", got)
+	}
+	if got := b.received(); len(got) != 3 || got[2] != "only b" {
+		t.Errorf("Expected [one two only b], received %v", got)
+	}
+	if !a.closed || !b.closed || h.Len() != 0 {
+		t.Error("Expected the connections to be closed")
+	}
+	if err := ca.Send([]byte("late")); err != hub.ErrClosed {
+		t.Errorf("Expected %v, received %v", hub.ErrClosed, err)
+	}
+}
+
+func TestBackpressure(t *testing.T) {
+	tests := []struct {
+		policy    hub.Policy
+		connected bool
+	}{
+		{hub.Disconnect, false},
+		{hub.DropMessage, true},
+	}
+
+	for _, tt := range tests {
+		h := hub.New(hub.QueueSize(2), hub.OnFull(tt.policy))
+		slow, fast := &conn{gate: make(chan struct{})}, &conn{}
+		cs, cf := h.Register(slow), h.Register(fast)
+
+		for i := 0; i < 5; i++ {
+			h.Broadcast([]byte("x"))
+			time.Sleep(time.Millisecond) // let fast keep up
+		}
+
+		if err := cs.Send([]byte("y")); tt.connected && err != hub.ErrQueueFull {
+			t.Errorf("Expected %v, received %v", hub.ErrQueueFull, err)
+		}
+		if h.Len() != map[bool]int{true: 2, false: 1}[tt.connected] {
+			t.Errorf("Policy %d: unexpected number of clients %d", tt.policy, h.Len())
+		}
+
+		close(slow.gate)
+		h.Close()
+		<-cs.Done()
+		<-cf.Done()
+
+		if got := fast.received(); len(got) != 5 {
+			t.Errorf("Expected the fast client to receive 5 messages, received %d", len(got))
+		}
+	}
+}
+
+func TestSendError(t *testing.T) {
+	h := hub.New()
+	c := h.Register(&conn{err: errors.New("broken pipe")})
+
+	h.Broadcast([]byte("x"))
+	<-c.Done()
+
+	if h.Len() != 0 {
+		t.Error("Expected the failing client to be unregistered")
+	}
+}
+
+func TestWriteTimeout(t *testing.T) {
+	h := hub.New(hub.WriteTimeout(10 * time.Millisecond))
+	c := h.Register(&conn{gate: make(chan struct{})})
+
+	h.Broadcast([]byte("x"))
+
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected the stuck client to be unregistered")
+	}
+}
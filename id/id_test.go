@@ -0,0 +1,106 @@
+package id_test
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/id"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[47][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestUUID(t *testing.T) {
+	tests := []struct {
+		uuid    id.UUID
+		version int
+	}{
+		{id.NewV4(), 4},
+		{id.NewV7(), 7},
+	}
+
+	for _, tt := range tests {
+		s := tt.uuid.String()
+		if !uuidPattern.MatchString(s) || tt.uuid.Version() != tt.version {
+			t.Errorf("Expected a version %d UUID, received %s", tt.version, s)
+		}
+
+		parsed, err := id.ParseUUID(s)
+		if err != nil || parsed != tt.uuid {
+			t.Errorf("Expected %s to parse, received %s (%v)", s, parsed, err)
+		}
+	}
+
+	if d := time.Since(id.NewV7().Time()); d < 0 || d > time.Second {
+		t.Errorf("Expected a v7 UUID of now, received one %v old", d)
+	}
+
+	for _, s := range []string{"", "f81d4fae7dec11d0a76500a0c91e6bf6", "g81d4fae-7dec-11d0-a765-00a0c91e6bf6"} {
+		if _, err := id.ParseUUID(s); err != id.ErrInvalid {
+			t.Errorf("Expected %q to be invalid, received %v", s, err)
+		}
+	}
+}
+
+func TestShort(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		s := id.Short(12)
+		if len(s) != 12 || !regexp.MustCompile(`^[A-Za-z0-9_-]+$`).MatchString(s) || seen[s] {
+			t.Fatalf("Expected a unique URL-safe ID, received %q", s)
+		}
+		seen[s] = true
+	}
+}
+
+func TestULID(t *testing.T) {
+	var ids []string
+	for i := 0; i < 1000; i++ {
+		ids = append(ids, id.NewULID().String())
+	}
+
+	if !sort.StringsAreSorted(ids) {
+		t.Error("Expected ULIDs to sort in creation order")
+	}
+
+	u := id.NewULID()
+	parsed, err := id.ParseULID(u.String())
+	if err != nil || parsed != u {
+		t.Errorf("Expected %s to parse, received %s (%v)", u, parsed, err)
+	}
+
+	if d := time.Since(u.Time()); d < 0 || d > time.Second {
+		t.Errorf("Expected a ULID of now, received one %v old", d)
+	}
+
+	known, err := id.ParseULID("01arz3ndektsv4rrffq69g5fav")
+	if err != nil || known.String() != "01ARZ3NDEKTSV4RRFFQ69G5FAV" || known.Time().UnixMilli() != 1469922850259 {
+		t.Errorf("Unexpected parse of a known ULID: %s %v (%v)", known, known.Time().UnixMilli(), err)
+	}
+
+	for _, s := range []string{"", "01ARZ3NDEKTSV4RRFFQ69G5FAU", "81ARZ3NDEKTSV4RRFFQ69G5FAV"} {
+		if _, err := id.ParseULID(s); err != id.ErrInvalid {
+			t.Errorf("Expected %q to be invalid, received %v", s, err)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type record struct {
+		ID  id.UUID
+		Key id.ULID
+	}
+
+	in := record{id.NewV4(), id.NewULID()}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out record
+	if err := json.Unmarshal(data, &out); err != nil || out != in {
+		t.Errorf("Expected %+v after a round trip, received %+v (%v)", in, out, err)
+	}
+}
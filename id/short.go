@@ -0,0 +1,22 @@
+package id
+
+// urlSafe is the alphabet of Short IDs, 64 characters so every random byte
+// maps to one without bias
+const urlSafe = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz-_"
+
+// Short returns a random ID of n URL-safe characters (letters, digits, '-'
+// and '_'), with 6 bits of randomness each, e.g. 16 characters for 96
+// bits.
+func Short(n int) string {
+	if n <= 0 {
+		return ""
+	}
+
+	b := make([]byte, n)
+	random(b)
+	for i := range b {
+		b[i] = urlSafe[b[i]&63]
+	}
+
+	return string(b)
+}
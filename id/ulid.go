@@ -0,0 +1,127 @@
+package id
+
+import (
+	"encoding/binary"
+	"strings"
+	"sync"
+	"time"
+)
+
+// crockford is the base32 alphabet of ULIDs, without I, L, O and U
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID is a Universally Unique Lexicographically Sortable Identifier: a 48
+// bit millisecond timestamp followed by 80 random bits, written as 26
+// characters sorting in creation order.
+type ULID [16]byte
+
+var monotonic struct {
+	sync.Mutex
+	last ULID
+}
+
+// NewULID returns a ULID for the current time.  ULIDs created in the same
+// millisecond increment the random part of the previous one, so ULIDs from
+// a process always sort in the order they were created.
+func NewULID() ULID {
+	ms := uint64(time.Now().UnixMilli())
+
+	monotonic.Lock()
+	defer monotonic.Unlock()
+
+	var u ULID
+	if last := monotonic.last.ms(); ms <= last {
+		// same (or earlier, if the clock went back) millisecond
+		u = monotonic.last
+		for i := 15; i >= 6; i-- {
+			u[i]++
+			if u[i] != 0 {
+				break
+			}
+		}
+	} else {
+		u.setMs(ms)
+		random(u[6:])
+	}
+
+	monotonic.last = u
+	return u
+}
+
+// ParseULID parses the 26 characters of a ULID, in either case.
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	if len(s) != 26 || s[0] > '7' {
+		return u, ErrInvalid
+	}
+
+	// 26 characters of 5 bits are 130 bits, the first 2 always 0
+	var hi, lo uint64 // 128 bits, accumulated 5 bits at a time
+	for i := 0; i < 26; i++ {
+		v := strings.IndexByte(crockford, upper(s[i]))
+		if v < 0 {
+			return u, ErrInvalid
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+
+	binary.BigEndian.PutUint64(u[:8], hi)
+	binary.BigEndian.PutUint64(u[8:], lo)
+	return u, nil
+}
+
+// String returns the 26 characters of u.
+func (u ULID) String() string {
+	hi, lo := binary.BigEndian.Uint64(u[:8]), binary.BigEndian.Uint64(u[8:])
+
+	var buf [26]byte
+	for i := 25; i >= 0; i-- {
+		buf[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+
+	return string(buf[:])
+}
+
+// Time returns the creation time of u, to the millisecond.
+func (u ULID) Time() time.Time {
+	return time.UnixMilli(int64(u.ms()))
+}
+
+// MarshalText implements encoding.TextMarshaler, so ULIDs are strings in JSON.
+func (u ULID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (u *ULID) UnmarshalText(b []byte) error {
+	v, err := ParseULID(string(b))
+	if err != nil {
+		return err
+	}
+
+	*u = v
+	return nil
+}
+
+func (u ULID) ms() uint64 {
+	var b [8]byte
+	copy(b[2:], u[:6])
+	return binary.BigEndian.Uint64(b[:])
+}
+
+func (u *ULID) setMs(ms uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], ms)
+	copy(u[:6], b[2:])
+}
+
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+
+	return c
+}
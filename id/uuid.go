@@ -0,0 +1,118 @@
+// Package id generates identifiers: UUIDs, short URL-safe random IDs, and
+// ULIDs, which sort by creation time.
+package id
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// ErrInvalid is returned when parsing malformed IDs.
+var ErrInvalid = errors.New("invalid id")
+
+// UUID is a universally unique identifier (RFC 9562).
+type UUID [16]byte
+
+// NewV4 returns a random UUID (version 4).
+func NewV4() UUID {
+	var u UUID
+	random(u[:])
+	u.setVersion(4)
+	return u
+}
+
+// NewV7 returns a UUID starting with the current unix time in milliseconds
+// (version 7), so UUIDs sort by creation time, down to the millisecond,
+// which makes them friendly to database indexes.
+func NewV7() UUID {
+	var u UUID
+	random(u[6:])
+
+	ms := uint64(time.Now().UnixMilli())
+	u[0], u[1], u[2] = byte(ms>>40), byte(ms>>32), byte(ms>>24)
+	u[3], u[4], u[5] = byte(ms>>16), byte(ms>>8), byte(ms)
+
+	u.setVersion(7)
+	return u
+}
+
+// ParseUUID parses the canonical form of a UUID, e.g.
+// "f81d4fae-7dec-11d0-a765-00a0c91e6bf6", in either case.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, ErrInvalid
+	}
+
+	digits := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	if _, err := hex.Decode(u[:], []byte(digits)); err != nil {
+		return u, ErrInvalid
+	}
+
+	return u, nil
+}
+
+// String returns the canonical form of u, e.g.
+// "f81d4fae-7dec-11d0-a765-00a0c91e6bf6".
+func (u UUID) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+// Version returns the version of u, e.g. 4 for random UUIDs.
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// Time returns the creation time of version 7 UUIDs, and the zero time
+// otherwise.
+func (u UUID) Time() time.Time {
+	if u.Version() != 7 {
+		return time.Time{}
+	}
+
+	var b [8]byte
+	copy(b[2:], u[:6])
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(b[:])))
+}
+
+// MarshalText implements encoding.TextMarshaler, so UUIDs are strings in JSON.
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (u *UUID) UnmarshalText(b []byte) error {
+	v, err := ParseUUID(string(b))
+	if err != nil {
+		return err
+	}
+
+	*u = v
+	return nil
+}
+
+// setVersion sets the version and the RFC 9562 variant
+func (u *UUID) setVersion(v byte) {
+	u[6] = u[6]&0x0f | v<<4
+	u[8] = u[8]&0x3f | 0x80
+}
+
+// random fills b from crypto/rand, which never fails on supported platforms
+func random(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic("id: crypto/rand failed: " + err.Error())
+	}
+}
@@ -0,0 +1,73 @@
+// Package jsonu reads, writes, pretty prints, merges and compares JSON
+// documents.
+package jsonu
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+
+	"github.com/sspencer/goal/fsu"
+)
+
+// ReadFile decodes the JSON file at path into a T.
+func ReadFile[T any](path string) (T, error) {
+	var v T
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return v, err
+	}
+
+	err = json.Unmarshal(data, &v)
+	return v, err
+}
+
+// WriteFile writes v to path as indented JSON.  The file is written to a
+// temporary file first and renamed, so readers never see a partial file,
+// even after a crash.
+func WriteFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return fsu.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Pretty indents the JSON document data with indent, sorting the keys of
+// objects so the output is stable, e.g. to diff responses.  Numbers are kept
+// as written and HTML characters are not escaped.
+func Pretty(data []byte, indent string) ([]byte, error) {
+	v, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return encode(v, indent)
+}
+
+// decode parses data, keeping numbers as written
+func decode(data []byte) (any, error) {
+	var v any
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// encode formats v, sorting the keys of objects
+func encode(v any, indent string) ([]byte, error) {
+	var out bytes.Buffer
+	e := json.NewEncoder(&out)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", indent)
+	if err := e.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimRight(out.Bytes(), "\n"), nil
+}
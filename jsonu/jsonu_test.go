@@ -0,0 +1,108 @@
+package jsonu_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sspencer/goal/jsonu"
+)
+
+type user struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "user.json")
+
+	if err := jsonu.WriteFile(path, user{"ann", 42}); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := jsonu.ReadFile[user](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u != (user{"ann", 42}) {
+		t.Errorf("Expected ann 42, received %+v", u)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary file left, received %d files", len(entries))
+	}
+
+	if _, err := jsonu.ReadFile[user](filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("Expected a not exist error, received %v", err)
+	}
+}
+
+func TestPretty(t *testing.T) {
+	out, err := jsonu.Pretty([]byte(`{"b":1.50,"a":{"d":"<x>","c":null}}`), "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "{\n  \"a\": {\n    \"c\": null,\n    \"d\": \"<x>\"\n  },\n  \"b\": 1.50\n}"
+	if string(out) != want {
+		t.Errorf("Expected\n%s\nreceived\n%s", want, out)
+	}
+
+	if _, err := jsonu.Pretty([]byte(`{"a":`), ""); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":1,"b":2}`, `{"b":3,"c":4}`, `{"a":1,"b":3,"c":4}`},
+		{`{"a":{"x":1,"y":2}}`, `{"a":{"y":null,"z":3}}`, `{"a":{"x":1,"z":3}}`},
+		{`{"a":[1,2]}`, `{"a":[3]}`, `{"a":[3]}`},
+		{`{"a":1}`, `[1]`, `[1]`},
+		{`"x"`, `{"a":1}`, `{"a":1}`},
+	}
+
+	for _, tt := range tests {
+		out, err := jsonu.Merge([]byte(tt.doc), []byte(tt.patch))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != tt.want {
+			t.Errorf("Merge(%s, %s): expected %s, received %s", tt.doc, tt.patch, tt.want, out)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := `{"name":"ann","tags":["a","b"],"meta":{"x/y":1},"gone":true}`
+	b := `{"name":"bob","tags":["a"],"meta":{"x/y":2},"new":null}`
+
+	changes, err := jsonu.Diff([]byte(a), []byte(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.String())
+	}
+
+	want := []string{
+		`/gone: removed true`,
+		`/meta/x~1y: 1 -> 2`,
+		`/name: "ann" -> "bob"`,
+		`/new: added null`,
+		`/tags/1: removed "b"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, received %q", want, got)
+	}
+
+	if changes, _ := jsonu.Diff([]byte(`{"a":[1]}`), []byte(` { "a" : [ 1 ] } `)); len(changes) != 0 {
+		t.Errorf("Expected no changes, received %v", changes)
+	}
+}
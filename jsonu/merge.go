@@ -0,0 +1,144 @@
+package jsonu
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Merge deep merges the JSON document patch into doc: objects are merged
+// key by key, any other value in patch replaces the one in doc, and null
+// removes the key, like a JSON Merge Patch (RFC 7386).
+func Merge(doc, patch []byte) ([]byte, error) {
+	d, err := decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("doc: %w", err)
+	}
+
+	p, err := decode(patch)
+	if err != nil {
+		return nil, fmt.Errorf("patch: %w", err)
+	}
+
+	return encode(merge(d, p), "")
+}
+
+func merge(doc, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	d, ok := doc.(map[string]any)
+	if !ok {
+		d = map[string]any{}
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(d, k)
+		} else {
+			d[k] = merge(d[k], v)
+		}
+	}
+
+	return d
+}
+
+// Change is a difference between two JSON documents, at Path, a JSON
+// Pointer (RFC 6901) like "/users/0/name".  Old is missing when the value
+// was added, and New when it was removed.
+type Change struct {
+	Path string
+	Old  json.RawMessage `json:",omitempty"`
+	New  json.RawMessage `json:",omitempty"`
+}
+
+// String formats the change, e.g. "/name: "ann" -> "bob""
+func (c Change) String() string {
+	switch {
+	case c.Old == nil:
+		return fmt.Sprintf("%s: added %s", c.Path, c.New)
+	case c.New == nil:
+		return fmt.Sprintf("%s: removed %s", c.Path, c.Old)
+	default:
+		return fmt.Sprintf("%s: %s -> %s", c.Path, c.Old, c.New)
+	}
+}
+
+// Diff returns the changes from the JSON document a to b, sorted by path.
+// Objects are compared key by key, and arrays index by index.  Numbers are
+// equal when written the same.
+func Diff(a, b []byte) ([]Change, error) {
+	va, err := decode(a)
+	if err != nil {
+		return nil, fmt.Errorf("a: %w", err)
+	}
+
+	vb, err := decode(b)
+	if err != nil {
+		return nil, fmt.Errorf("b: %w", err)
+	}
+
+	var changes []Change
+	diff("", va, vb, &changes)
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+func diff(path string, a, b any, changes *[]Change) {
+	switch a := a.(type) {
+	case map[string]any:
+		if b, ok := b.(map[string]any); ok {
+			for k, va := range a {
+				if vb, ok := b[k]; ok {
+					diff(path+"/"+escape(k), va, vb, changes)
+				} else {
+					*changes = append(*changes, Change{Path: path + "/" + escape(k), Old: raw(va)})
+				}
+			}
+			for k, vb := range b {
+				if _, ok := a[k]; !ok {
+					*changes = append(*changes, Change{Path: path + "/" + escape(k), New: raw(vb)})
+				}
+			}
+			return
+		}
+	case []any:
+		if b, ok := b.([]any); ok {
+			for i := 0; i < max(len(a), len(b)); i++ {
+				p := path + "/" + strconv.Itoa(i)
+				switch {
+				case i >= len(b):
+					*changes = append(*changes, Change{Path: p, Old: raw(a[i])})
+				case i >= len(a):
+					*changes = append(*changes, Change{Path: p, New: raw(b[i])})
+				default:
+					diff(p, a[i], b[i], changes)
+				}
+			}
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Path: path, Old: raw(a), New: raw(b)})
+	}
+}
+
+// escape escapes a key for a JSON Pointer
+func escape(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// raw encodes a decoded value, which can't fail
+func raw(v any) json.RawMessage {
+	data, _ := encode(v, "")
+	return data
+}
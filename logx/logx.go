@@ -0,0 +1,95 @@
+// Package logx defines the Logger accepted by the other goal packages.  A
+// *slog.Logger is a Logger, so most programs just pass their own, e.g.
+//
+//	results := str.MapErr(8, urls, fetch, str.Logger(slog.Default()))
+package logx
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Logger records messages with key-value pairs of attributes, like
+// slog.Logger.Log.
+type Logger interface {
+	Log(ctx context.Context, level slog.Level, msg string, args ...any)
+}
+
+// Default returns the default slog.Logger, at the time of the call.
+func Default() Logger {
+	return slog.Default()
+}
+
+// New returns a Logger writing to h.
+func New(h slog.Handler) Logger {
+	return slog.New(h)
+}
+
+// Nop is a Logger discarding everything.
+var Nop Logger = nop{}
+
+type nop struct{}
+
+func (nop) Log(context.Context, slog.Level, string, ...any) {}
+
+// Entry is a message recorded by a TestLogger.
+type Entry struct {
+	Level slog.Level
+	Msg   string
+	Attrs map[string]any
+}
+
+// TestLogger records messages, for tests to check what was logged.  It is
+// safe for concurrent use.
+type TestLogger struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// Log records a message.  Arguments are paired into attributes, like slog
+// does.
+func (t *TestLogger) Log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	r := slog.NewRecord(time.Time{}, level, msg, 0)
+	r.Add(args...)
+
+	attrs := make(map[string]any, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.Resolve().Any()
+		return true
+	})
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entries = append(t.entries, Entry{level, msg, attrs})
+}
+
+// Entries returns the messages recorded so far.
+func (t *TestLogger) Entries() []Entry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return slices.Clone(t.entries)
+}
+
+// Find returns the messages recorded with msg.
+func (t *TestLogger) Find(msg string) []Entry {
+	var found []Entry
+	for _, e := range t.Entries() {
+		if e.Msg == msg {
+			found = append(found, e)
+		}
+	}
+
+	return found
+}
+
+// Reset forgets the messages recorded so far.
+func (t *TestLogger) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.entries = nil
+}
@@ -0,0 +1,46 @@
+package logx_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/sspencer/goal/logx"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	l := logx.New(slog.NewTextHandler(&buf, nil))
+	l.Log(context.Background(), slog.LevelInfo, "hello", "n", 1)
+
+	if !strings.Contains(buf.String(), "msg=hello n=1") {
+		t.Errorf("Expected the message to be written, received %q", buf.String())
+	}
+
+	// a *slog.Logger is a Logger
+	var _ logx.Logger = slog.Default()
+	logx.Nop.Log(context.Background(), slog.LevelError, "discarded")
+}
+
+func TestTestLogger(t *testing.T) {
+	var l logx.TestLogger
+	l.Log(context.Background(), slog.LevelWarn, "failed", "item", 3, slog.String("error", "boom"))
+	l.Log(context.Background(), slog.LevelDebug, "done", "item", 4)
+
+	entries := l.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, received %d", len(entries))
+	}
+
+	failed := l.Find("failed")
+	if len(failed) != 1 || failed[0].Level != slog.LevelWarn || failed[0].Attrs["item"] != int64(3) || failed[0].Attrs["error"] != "boom" {
+		t.Errorf("Unexpected entry %+v", failed)
+	}
+
+	l.Reset()
+	if len(l.Entries()) != 0 {
+		t.Error("Expected no entries after Reset")
+	}
+}
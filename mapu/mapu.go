@@ -0,0 +1,85 @@
+// Package mapu collates results held in maps, complementing sliceu.
+package mapu
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+)
+
+// Keys returns the keys of m, in no particular order.
+func Keys[K comparable, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// Values returns the values of m, in no particular order.
+func Values[K comparable, V any](m map[K]V) []V {
+	values := make([]V, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+
+	return values
+}
+
+// SortedKeys returns the keys of m in ascending order.
+//
+// e.g. SortedKeys(map[string]int{"b": 1, "a": 2}) -> [a b]
+func SortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	keys := Keys(m)
+	slices.Sort(keys)
+	return keys
+}
+
+// Sorted iterates over m in ascending order of keys, e.g.
+//
+//	for k, v := range mapu.Sorted(counts) {
+//		fmt.Println(k, v)
+//	}
+func Sorted[K cmp.Ordered, V any](m map[K]V) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, k := range SortedKeys(m) {
+			if !yield(k, m[k]) {
+				return
+			}
+		}
+	}
+}
+
+// Merge returns a new map with the entries of every map, the later maps
+// winning for keys in several.
+//
+// e.g. Merge(map[string]int{"a": 1, "b": 2}, map[string]int{"b": 3}) -> map[a:1 b:3]
+func Merge[K comparable, V any](maps ...map[K]V) map[K]V {
+	n := 0
+	for _, m := range maps {
+		n += len(m)
+	}
+
+	out := make(map[K]V, n)
+	for _, m := range maps {
+		for k, v := range m {
+			out[k] = v
+		}
+	}
+
+	return out
+}
+
+// Invert returns a map from the values of m to their keys.  When several
+// keys have the same value, any one of them is kept.
+//
+// e.g. Invert(map[string]int{"a": 1, "b": 2}) -> map[1:a 2:b]
+func Invert[K, V comparable](m map[K]V) map[V]K {
+	out := make(map[V]K, len(m))
+	for k, v := range m {
+		out[v] = k
+	}
+
+	return out
+}
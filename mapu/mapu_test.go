@@ -0,0 +1,89 @@
+package mapu_test
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/sspencer/goal/mapu"
+)
+
+func TestKeysValues(t *testing.T) {
+	m := map[string]int{"b": 2, "a": 1, "c": 3}
+
+	keys := mapu.Keys(m)
+	sort.Strings(keys)
+	values := mapu.Values(m)
+	sort.Ints(values)
+
+	if !reflect.DeepEqual(keys, []string{"a", "b", "c"}) || !reflect.DeepEqual(values, []int{1, 2, 3}) {
+		t.Errorf("Expected [a b c] [1 2 3], received %v %v", keys, values)
+	}
+
+	if got := mapu.SortedKeys(m); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("Expected [a b c], received %v", got)
+	}
+
+	var order []string
+	for k, v := range mapu.Sorted(m) {
+		order = append(order, k)
+		if m[k] != v {
+			t.Errorf("Expected %d for %s, received %d", m[k], k, v)
+		}
+		if k == "b" {
+			break
+		}
+	}
+	if !reflect.DeepEqual(order, []string{"a", "b"}) {
+		t.Errorf("Expected [a b], received %v", order)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	got := mapu.Merge(map[string]int{"a": 1, "b": 2}, nil, map[string]int{"b": 3, "c": 4})
+	want := map[string]int{"a": 1, "b": 3, "c": 4}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, received %v", want, got)
+	}
+}
+
+func TestInvert(t *testing.T) {
+	got := mapu.Invert(map[string]int{"a": 1, "b": 2})
+	want := map[int]string{1: "a", 2: "b"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, received %v", want, got)
+	}
+}
+
+func TestOrderedMap(t *testing.T) {
+	var m mapu.OrderedMap[string, int]
+	m.Set("c", 1)
+	m.Set("a", 2)
+	m.Set("b", 3)
+	m.Set("a", 4)
+	m.Delete("c")
+	m.Delete("missing")
+
+	var keys []string
+	var values []int
+	for k, v := range m.All() {
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+
+	if !reflect.DeepEqual(keys, []string{"a", "b"}) || !reflect.DeepEqual(values, []int{4, 3}) || m.Len() != 2 {
+		t.Errorf("Expected [a b] [4 3], received %v %v", keys, values)
+	}
+
+	if v, ok := m.Get("a"); !ok || v != 4 {
+		t.Errorf("Expected 4, received %d %v", v, ok)
+	}
+	if _, ok := m.Get("c"); ok {
+		t.Error("Expected c to be deleted")
+	}
+	if !reflect.DeepEqual(m.Keys(), []string{"a", "b"}) {
+		t.Errorf("Expected [a b], received %v", m.Keys())
+	}
+}
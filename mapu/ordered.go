@@ -0,0 +1,65 @@
+package mapu
+
+import "iter"
+
+// OrderedMap is a map iterating in the order keys were first set, e.g. to
+// keep the order of inputs in a report.  The zero value is empty and ready
+// to use.  It is not safe for concurrent use.
+type OrderedMap[K comparable, V any] struct {
+	keys   []K
+	values map[K]V
+}
+
+// Set sets the value of key, keeping its position if it is already set.
+func (m *OrderedMap[K, V]) Set(key K, value V) {
+	if m.values == nil {
+		m.values = make(map[K]V)
+	}
+
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Get returns the value of key, if it is set.
+func (m *OrderedMap[K, V]) Get(key K) (V, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// Delete removes key.
+func (m *OrderedMap[K, V]) Delete(key K) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Len returns the number of keys.
+func (m *OrderedMap[K, V]) Len() int {
+	return len(m.keys)
+}
+
+// Keys returns the keys in order.
+func (m *OrderedMap[K, V]) Keys() []K {
+	return append([]K(nil), m.keys...)
+}
+
+// All iterates over the entries in order.
+func (m *OrderedMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, k := range m.keys {
+			if !yield(k, m.values[k]) {
+				return
+			}
+		}
+	}
+}
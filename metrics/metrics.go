@@ -0,0 +1,313 @@
+// Package metrics holds counters, gauges and timers in a Registry, served
+// in the Prometheus text format and published with expvar, e.g.
+//
+//	reg := metrics.NewRegistry()
+//	reg.Counter("jobs_total", "Jobs run.", "queue", "email").Inc()
+//	stop := reg.Timer("job_seconds", "Time jobs took.").Start()
+//	...
+//	stop()
+//	http.Handle("/metrics", reg)
+//
+// Metrics are identified by name and labels, given as key, value pairs:
+// asking for the same ones again returns the same metric.
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds of timer buckets, in seconds, from
+// 5ms to 10s.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Default is a registry shared by the packages of a program, e.g. served
+// on /metrics.
+var Default = NewRegistry()
+
+// Counter is a count that only goes up.
+type Counter struct {
+	v atomic.Int64
+}
+
+// Inc adds 1.
+func (c *Counter) Inc() {
+	c.v.Add(1)
+}
+
+// Add adds n, which must not be negative.
+func (c *Counter) Add(n int64) {
+	if n > 0 {
+		c.v.Add(n)
+	}
+}
+
+// Value returns the count.
+func (c *Counter) Value() int64 {
+	return c.v.Load()
+}
+
+// Gauge is a value that goes up and down.
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+// Set sets the value.
+func (g *Gauge) Set(v float64) {
+	g.bits.Store(math.Float64bits(v))
+}
+
+// Add adds d, which may be negative.
+func (g *Gauge) Add(d float64) {
+	for {
+		old := g.bits.Load()
+		if g.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+d)) {
+			return
+		}
+	}
+}
+
+// Value returns the value.
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+// Timer is a histogram of durations.
+type Timer struct {
+	mu      sync.Mutex
+	buckets []float64 // upper bounds, in seconds
+	counts  []int64   // per bucket, not cumulative, plus +Inf
+	sum     time.Duration
+	count   int64
+}
+
+// Observe records a duration.
+func (t *Timer) Observe(d time.Duration) {
+	i := sort.SearchFloat64s(t.buckets, d.Seconds())
+
+	t.mu.Lock()
+	t.counts[i]++
+	t.sum += d
+	t.count++
+	t.mu.Unlock()
+}
+
+// Start returns a function recording the time since Start when called.
+func (t *Timer) Start() func() {
+	start := time.Now()
+	return func() {
+		t.Observe(time.Since(start))
+	}
+}
+
+// Count returns the number of durations recorded, and their sum.
+func (t *Timer) Count() (int64, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.count, t.sum
+}
+
+// Registry holds metrics by name and labels.  It is safe for concurrent
+// use.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// family is the metrics with the same name, by labels
+type family struct {
+	name, help, kind string
+	series           map[string]any // *Counter, *Gauge or *Timer by formatted labels
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Counter returns the counter called name with labels, creating it if
+// needed.  It panics if name is a metric of another kind.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return r.metric(name, help, "counter", labels, func() any { return &Counter{} }).(*Counter)
+}
+
+// Gauge returns the gauge called name with labels, creating it if needed.
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	return r.metric(name, help, "gauge", labels, func() any { return &Gauge{} }).(*Gauge)
+}
+
+// Timer returns the timer called name with labels, with DefaultBuckets,
+// creating it if needed.
+func (r *Registry) Timer(name, help string, labels ...string) *Timer {
+	return r.TimerBuckets(name, help, DefaultBuckets, labels...)
+}
+
+// TimerBuckets is like Timer, with the upper bounds of buckets in seconds,
+// sorted.  They are only used when the timer is created.
+func (r *Registry) TimerBuckets(name, help string, buckets []float64, labels ...string) *Timer {
+	return r.metric(name, help, "histogram", labels, func() any {
+		return &Timer{buckets: buckets, counts: make([]int64, len(buckets)+1)}
+	}).(*Timer)
+}
+
+// metric returns the metric of the family name with labels, created by
+// create when missing
+func (r *Registry) metric(name, help, kind string, labels []string, create func() any) any {
+	key := formatLabels(labels)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{name: name, help: help, kind: kind, series: make(map[string]any)}
+		r.families[name] = f
+	}
+	if f.kind != kind {
+		panic(fmt.Sprintf("metrics: %s is a %s, not a %s", name, f.kind, kind))
+	}
+
+	m, ok := f.series[key]
+	if !ok {
+		m = create()
+		f.series[key] = m
+	}
+
+	return m
+}
+
+// formatLabels formats key, value pairs as Prometheus labels, e.g.
+// {code="200",method="GET"}, sorted by key
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	if len(labels)%2 != 0 {
+		panic("metrics: labels must be key, value pairs")
+	}
+
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i < len(labels); i += 2 {
+		pairs = append(pairs, labels[i]+"="+strconv.Quote(labels[i+1]))
+	}
+	sort.Strings(pairs)
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// withLabel adds a label to formatted labels, e.g. le for buckets
+func withLabel(labels, key, value string) string {
+	pair := key + "=" + strconv.Quote(value)
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+
+	return labels[:len(labels)-1] + "," + pair + "}"
+}
+
+// series is a metric of a family, with its formatted labels
+type series struct {
+	labels string
+	metric any
+}
+
+// snapshot returns the families sorted by name, with their series sorted
+// by labels
+func (r *Registry) snapshot() ([]*family, map[*family][]series) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	families := make([]*family, 0, len(r.families))
+	all := make(map[*family][]series, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+		for labels, m := range f.series {
+			all[f] = append(all[f], series{labels, m})
+		}
+		sort.Slice(all[f], func(i, j int) bool { return all[f][i].labels < all[f][j].labels })
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	return families, all
+}
+
+// WriteTo writes every metric to w, in the Prometheus text format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	families, all := r.snapshot()
+
+	var buf bytes.Buffer
+	for _, f := range families {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+
+		for _, s := range all[f] {
+			switch m := s.metric.(type) {
+			case *Counter:
+				fmt.Fprintf(&buf, "%s%s %d\n", f.name, s.labels, m.Value())
+			case *Gauge:
+				fmt.Fprintf(&buf, "%s%s %g\n", f.name, s.labels, m.Value())
+			case *Timer:
+				m.mu.Lock()
+				var cumulative int64
+				for b, le := range m.buckets {
+					cumulative += m.counts[b]
+					fmt.Fprintf(&buf, "%s_bucket%s %d\n", f.name, withLabel(s.labels, "le", strconv.FormatFloat(le, 'g', -1, 64)), cumulative)
+				}
+				fmt.Fprintf(&buf, "%s_bucket%s %d\n", f.name, withLabel(s.labels, "le", "+Inf"), m.count)
+				fmt.Fprintf(&buf, "%s_sum%s %g\n%s_count%s %d\n", f.name, s.labels, m.sum.Seconds(), f.name, s.labels, m.count)
+				m.mu.Unlock()
+			}
+		}
+	}
+
+	return buf.WriteTo(w)
+}
+
+// ServeHTTP serves every metric, for Prometheus to scrape.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	r.WriteTo(w)
+}
+
+// String returns the metrics as JSON, by name then labels, so a Registry is
+// an expvar.Var.  Timers have their count and sum in seconds.
+func (r *Registry) String() string {
+	families, all := r.snapshot()
+
+	out := make(map[string]map[string]any, len(families))
+	for _, f := range families {
+		values := make(map[string]any, len(all[f]))
+		for _, s := range all[f] {
+			switch m := s.metric.(type) {
+			case *Counter:
+				values[s.labels] = m.Value()
+			case *Gauge:
+				values[s.labels] = m.Value()
+			case *Timer:
+				count, sum := m.Count()
+				values[s.labels] = map[string]any{"count": count, "sum": sum.Seconds()}
+			}
+		}
+		out[f.name] = values
+	}
+
+	data, _ := json.Marshal(out)
+	return string(data)
+}
+
+// Publish publishes the registry with expvar as name, served on
+// /debug/vars along with the memory statistics of the runtime.
+func (r *Registry) Publish(name string) {
+	expvar.Publish(name, r)
+}
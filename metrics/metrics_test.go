@@ -0,0 +1,140 @@
+package metrics_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/metrics"
+	"github.com/sspencer/goal/req"
+	"github.com/sspencer/goal/str"
+)
+
+func TestRegistry(t *testing.T) {
+	reg := metrics.NewRegistry()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reg.Counter("jobs_total", "Jobs run.", "queue", "email").Inc()
+			reg.Gauge("depth", "Queue depth.").Add(0.5)
+		}()
+	}
+	wg.Wait()
+
+	reg.Counter("jobs_total", "Jobs run.", "queue", "sms").Add(3)
+	timer := reg.TimerBuckets("job_seconds", "Job time.", []float64{0.1, 1})
+	timer.Observe(50 * time.Millisecond)
+	timer.Observe(500 * time.Millisecond)
+	timer.Observe(2 * time.Second)
+
+	var buf bytes.Buffer
+	reg.WriteTo(&buf)
+
+	expected := `# HELP depth Queue depth.
+# TYPE depth gauge
+depth 5
+# HELP job_seconds Job time.
+# TYPE job_seconds histogram
+job_seconds_bucket{le="0.1"} 1
+job_seconds_bucket{le="1"} 2
+job_seconds_bucket{le="+Inf"} 3
+job_seconds_sum 2.55
+job_seconds_count 3
+# HELP jobs_total Jobs run.
+# TYPE jobs_total counter
+jobs_total{queue="email"} 10
+jobs_total{queue="sms"} 3
+`
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\nReceived:\n%s", expected, buf.String())
+	}
+
+	var vars map[string]map[string]any
+	if err := json.Unmarshal([]byte(reg.String()), &vars); err != nil {
+		t.Fatal(err)
+	}
+	if vars["jobs_total"][`{queue="sms"}`] != 3.0 {
+		t.Errorf("Expected the expvar JSON to hold the counters, received %v", vars)
+	}
+}
+
+func TestRegistryKindMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic")
+		}
+	}()
+
+	reg := metrics.NewRegistry()
+	reg.Counter("x", "")
+	reg.Gauge("x", "")
+}
+
+func TestRequestMetrics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	reg := metrics.NewRegistry()
+	r := req.New().Metrics(reg)
+	r.Get(ts.URL)
+	r.Get(ts.URL)
+	r.Get(ts.URL + "/missing")
+
+	host := strings.TrimPrefix(ts.URL, "http://")
+	tests := []struct {
+		code  string
+		count int64
+	}{
+		{"200", 2},
+		{"404", 1},
+	}
+
+	for _, tt := range tests {
+		if n := reg.Counter("req_requests_total", "", "method", "GET", "host", host, "code", tt.code).Value(); n != tt.count {
+			t.Errorf("%s: expected %d requests, received %d", tt.code, tt.count, n)
+		}
+	}
+
+	if n, _ := reg.Timer("req_request_seconds", "", "method", "GET", "host", host).Count(); n != 3 {
+		t.Errorf("Expected 3 requests timed, received %d", n)
+	}
+}
+
+func TestPool(t *testing.T) {
+	reg := metrics.NewRegistry()
+
+	str.MapErr(2, []int{1, 2, 3}, func(n int) (int, error) {
+		if n == 2 {
+			return 0, errors.New("fail")
+		}
+		return n, nil
+	}, str.ReportMetrics(reg.Pool("nums")))
+
+	tests := []struct {
+		name  string
+		count int64
+	}{
+		{"str_items_queued_total", 3},
+		{"str_items_completed_total", 3},
+		{"str_items_failed_total", 1},
+	}
+
+	for _, tt := range tests {
+		if n := reg.Counter(tt.name, "", "pool", "nums").Value(); n != tt.count {
+			t.Errorf("%s: expected %d, received %d", tt.name, tt.count, n)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import "time"
+
+// Pool counts the events of a str batch or Pool, as a str.Metrics, labeled
+// pool="name", e.g.
+//
+//	p := str.NewPool(8, upload, str.ReportMetrics(reg.Pool("uploads")))
+type Pool struct {
+	queued, started, completed, failed *Counter
+	wait, work                         *Timer
+}
+
+// Pool returns the metrics of the pool called name.
+func (r *Registry) Pool(name string) *Pool {
+	return &Pool{
+		queued:    r.Counter("str_items_queued_total", "Items queued.", "pool", name),
+		started:   r.Counter("str_items_started_total", "Items started.", "pool", name),
+		completed: r.Counter("str_items_completed_total", "Items completed, including failures.", "pool", name),
+		failed:    r.Counter("str_items_failed_total", "Items that failed.", "pool", name),
+		wait:      r.Timer("str_item_queue_seconds", "Time items waited in the queue.", "pool", name),
+		work:      r.Timer("str_item_work_seconds", "Time items took to process.", "pool", name),
+	}
+}
+
+// Queued counts n items queued.
+func (p *Pool) Queued(n int) {
+	p.queued.Add(int64(n))
+}
+
+// Started counts an item started after waiting in the queue.
+func (p *Pool) Started(wait time.Duration) {
+	p.started.Inc()
+	p.wait.Observe(wait)
+}
+
+// Done counts an item completed after running for d.
+func (p *Pool) Done(d time.Duration, err error) {
+	p.completed.Inc()
+	p.work.Observe(d)
+	if err != nil {
+		p.failed.Inc()
+	}
+}
@@ -0,0 +1,114 @@
+package mw
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures the CORS middleware.  The zero value allows simple
+// requests from any origin.
+type CORSOptions struct {
+	// Origins allowed, e.g. "https://example.com", or "*" for any.  Empty
+	// allows any origin.
+	Origins []string
+
+	// Methods allowed, GET, HEAD, POST, PUT, PATCH and DELETE when empty
+	Methods []string
+
+	// Headers allowed in requests, Content-Type, Authorization and
+	// X-Request-ID when empty
+	Headers []string
+
+	// Headers exposed to the browser in responses
+	Expose []string
+
+	// MaxAge is how long browsers may cache preflight responses, no caching
+	// when 0
+	MaxAge time.Duration
+
+	// Credentials allows cookies and authorization headers.  The origin of
+	// the request is then echoed back, rather than "*".
+	Credentials bool
+}
+
+var (
+	defaultCORSMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"}
+	defaultCORSHeaders = []string{"Content-Type", "Authorization", RequestIDHeader}
+)
+
+// CORS answers preflight requests and adds the CORS headers to responses,
+// so browsers on the allowed origins can call the API, e.g.
+//
+//	mw.CORS(mw.CORSOptions{Origins: []string{"https://example.com"}, MaxAge: time.Hour})
+//
+// Requests from origins not allowed get no CORS headers, and preflight
+// requests are answered without calling the handler.
+func CORS(opts CORSOptions) Middleware {
+	anyOrigin := len(opts.Origins) == 0 || slices.Contains(opts.Origins, "*")
+
+	methods := opts.Methods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+
+	headers := opts.Headers
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(headers, ", ")
+	expose := strings.Join(opts.Expose, ", ")
+
+	allowed := func(origin string) bool {
+		return anyOrigin || slices.Contains(opts.Origins, origin)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			h := w.Header()
+			h.Add("Vary", "Origin")
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			if origin == "" || !allowed(origin) {
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if anyOrigin && !opts.Credentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if opts.Credentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if expose != "" {
+					h.Set("Access-Control-Expose-Headers", expose)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", allowMethods)
+			h.Set("Access-Control-Allow-Headers", allowHeaders)
+			if opts.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
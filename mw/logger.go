@@ -0,0 +1,59 @@
+package mw
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/sspencer/goal/req"
+	"github.com/sspencer/goal/secrets"
+)
+
+// maxLoggedBody is the most of a request body Logger includes
+const maxLoggedBody = 64 * 1024
+
+// Logger logs every request with the standard logger: a curl command
+// repeating it (like the req client logs its requests), followed by the
+// status, size and duration of the response.  Credentials in headers, such
+// as Authorization and Cookie, are masked.
+func Logger() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body []byte
+			if r.Body != nil {
+				body, _ = io.ReadAll(io.LimitReader(r.Body, maxLoggedBody))
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+			}
+
+			start := time.Now()
+			rec := &recorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+
+			log.Printf("\n%s\n\n%d %s (%d bytes) in %v\n", req.CurlCommand(absolute(r), body),
+				rec.status, http.StatusText(rec.status), rec.size, time.Since(start).Round(time.Microsecond))
+		})
+	}
+}
+
+// absolute returns a copy of the server request r with an absolute URL,
+// and its credential headers masked
+func absolute(r *http.Request) *http.Request {
+	r2 := r.Clone(r.Context())
+	r2.Header = secrets.RedactHeader(r.Header)
+	r2.URL.Host = r.Host
+	r2.URL.Scheme = "http"
+	if r.TLS != nil {
+		r2.URL.Scheme = "https"
+	}
+
+	return r2
+}
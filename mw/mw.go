@@ -0,0 +1,51 @@
+// Package mw is a kit of HTTP server middlewares: request logging, panic
+// recovery, request IDs and timeouts.
+package mw
+
+import (
+	"net/http"
+)
+
+// Middleware wraps a handler with extra behavior.
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h with every middleware, the first one being the outermost,
+// so it sees requests first, e.g.
+//
+//	mw.Chain(mux, mw.RequestID(), mw.Logger(), mw.Recover())
+func Chain(h http.Handler, mws ...Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+
+	return h
+}
+
+// recorder records the status and size of a response
+type recorder struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	n, err := r.ResponseWriter.Write(b)
+	r.size += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the original writer
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,257 @@
+package mw_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/mw"
+)
+
+func TestChain(t *testing.T) {
+	var order []string
+	tag := func(name string) mw.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	h := mw.Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}), tag("a"), tag("b"))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if got := strings.Join(order, ","); got != "a,b,handler" {
+		t.Errorf("Expected a,b,handler, received %s", got)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(io.Discard)
+
+	h := mw.Logger()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "http://example.com/users", strings.NewReader(`{"name":"ann"}`)))
+
+	if w.Body.String() != `{"name":"ann"}` {
+		t.Errorf("Expected the handler to read the body, received %q", w.Body.String())
+	}
+
+	out := buf.String()
+	for _, want := range []string{"curl", "-XPOST", "http://example.com/users", `{"name":"ann"}`, "201 Created", "(14 bytes)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected log to contain %q, received %q", want, out)
+		}
+	}
+}
+
+func TestLoggerRedacts(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(io.Discard)
+
+	h := mw.Logger()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer abcdef123456" {
+			t.Errorf("Expected the handler to see the token, received %q", r.Header.Get("Authorization"))
+		}
+	}))
+
+	r := httptest.NewRequest("GET", "http://example.com/me", nil)
+	r.Header.Set("Authorization", "Bearer abcdef123456")
+	r.Header.Set("Cookie", "session=0123456789")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	out := buf.String()
+	if strings.Contains(out, "abcdef123456") || strings.Contains(out, "0123456789") {
+		t.Errorf("Expected credentials to be masked, received %q", out)
+	}
+	if !strings.Contains(out, "Authorization: Bearer ****") {
+		t.Errorf("Expected the masked Authorization header, received %q", out)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(io.Discard)
+
+	h := mw.Recover()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500, received %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"error"`) {
+		t.Errorf("Expected a JSON error, received %q", w.Body.String())
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := mw.RequestID()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = mw.GetRequestID(r.Context())
+	}))
+
+	tests := []struct {
+		header string
+		reused bool
+	}{
+		{"", false},
+		{"abc-123", true},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		if tt.header != "" {
+			r.Header.Set(mw.RequestIDHeader, tt.header)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		id := w.Header().Get(mw.RequestIDHeader)
+		if id == "" || id != seen {
+			t.Errorf("Expected the same ID in the response and context, received %q and %q", id, seen)
+		}
+		if tt.reused && id != tt.header {
+			t.Errorf("Expected %q to be reused, received %q", tt.header, id)
+		}
+	}
+
+	if id := mw.GetRequestID(context.Background()); id != "" {
+		t.Errorf("Expected no ID outside of RequestID, received %q", id)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	h := mw.Timeout(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+	}{
+		{"/fast", http.StatusOK, ""},
+		{"/slow", http.StatusServiceUnavailable, "application/json"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected %d, received %d", tt.path, tt.status, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+			t.Errorf("%s: expected %s, received %s", tt.path, tt.contentType, ct)
+		}
+	}
+}
+
+func TestCORS(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	tests := []struct {
+		name    string
+		opts    mw.CORSOptions
+		method  string
+		origin  string
+		status  int
+		headers map[string]string
+	}{
+		{"any origin", mw.CORSOptions{}, "GET", "https://a.com", 200,
+			map[string]string{"Access-Control-Allow-Origin": "*"}},
+		{"allowed origin", mw.CORSOptions{Origins: []string{"https://a.com"}}, "GET", "https://a.com", 200,
+			map[string]string{"Access-Control-Allow-Origin": "https://a.com"}},
+		{"other origin", mw.CORSOptions{Origins: []string{"https://a.com"}}, "GET", "https://b.com", 200,
+			map[string]string{"Access-Control-Allow-Origin": ""}},
+		{"credentials", mw.CORSOptions{Credentials: true}, "GET", "https://a.com", 200,
+			map[string]string{"Access-Control-Allow-Origin": "https://a.com", "Access-Control-Allow-Credentials": "true"}},
+		{"preflight", mw.CORSOptions{MaxAge: time.Hour}, "OPTIONS", "https://a.com", 204,
+			map[string]string{"Access-Control-Allow-Methods": "GET, HEAD, POST, PUT, PATCH, DELETE", "Access-Control-Max-Age": "3600"}},
+		{"preflight methods", mw.CORSOptions{Methods: []string{"GET"}}, "OPTIONS", "https://a.com", 204,
+			map[string]string{"Access-Control-Allow-Methods": "GET", "Access-Control-Max-Age": ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			r.Header.Set("Origin", tt.origin)
+			if tt.method == "OPTIONS" {
+				r.Header.Set("Access-Control-Request-Method", "POST")
+			}
+
+			w := httptest.NewRecorder()
+			mw.CORS(tt.opts)(ok).ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected %d, received %d", tt.status, w.Code)
+			}
+			for k, v := range tt.headers {
+				if got := w.Header().Get(k); got != v {
+					t.Errorf("Expected %s %q, received %q", k, v, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	h := mw.RateLimit(1, 2)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	tests := []struct {
+		remoteAddr string
+		status     int
+		retryAfter string
+	}{
+		{"10.0.0.1:1234", 200, ""},
+		{"10.0.0.1:1235", 200, ""},
+		{"10.0.0.1:1236", 429, "1"},
+		{"10.0.0.2:1234", 200, ""},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tt.remoteAddr
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected %d, received %d", tt.remoteAddr, tt.status, w.Code)
+		}
+		if got := w.Header().Get("Retry-After"); got != tt.retryAfter {
+			t.Errorf("%s: expected Retry-After %q, received %q", tt.remoteAddr, tt.retryAfter, got)
+		}
+	}
+}
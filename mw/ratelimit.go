@@ -0,0 +1,64 @@
+package mw
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sspencer/goal/ratelimit"
+	"github.com/sspencer/goal/resp"
+)
+
+// RateLimit throttles requests per client IP to 'rate' per second, with
+// bursts of up to 'burst', e.g.
+//
+//	mw.RateLimit(10, 20)
+//
+// Requests over the limit get a 429 response with a Retry-After header,
+// without calling the handler.
+func RateLimit(rate float64, burst int) Middleware {
+	limits := ratelimit.NewKeyed[string](func() ratelimit.Limiter {
+		return ratelimit.NewTokenBucket(rate, burst)
+	}, time.Hour)
+
+	return RateLimitBy(limits, ClientIP)
+}
+
+// RateLimitBy throttles requests with the limiter of their key, e.g. an API
+// key or user ID.
+func RateLimitBy(limits *ratelimit.Keyed[string], key func(*http.Request) string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l := limits.Get(key(r))
+
+			// limiters that can tell when there will be room again set
+			// Retry-After precisely, other clients retry after a second
+			retry := time.Second
+			if rl, ok := l.(interface{ Reserve() time.Duration }); ok {
+				retry = rl.Reserve()
+			} else if l.Allow() {
+				retry = 0
+			}
+
+			if retry > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int((retry+time.Second-1)/time.Second)))
+				resp.Error(w, http.StatusTooManyRequests, http.StatusText(http.StatusTooManyRequests))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP returns the IP address of the client of r, without the port.
+// Proxy headers are not trusted.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
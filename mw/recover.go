@@ -0,0 +1,33 @@
+package mw
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/sspencer/goal/resp"
+)
+
+// Recover turns panics in handlers into 500 responses, logging the panic
+// and its stack, so one bad request doesn't crash the server.  Panics with
+// http.ErrAbortHandler are left alone, as they abort on purpose.
+func Recover() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+
+				log.Printf("panic serving %s %s: %v\n%s", r.Method, r.URL, v, debug.Stack())
+				resp.Error(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
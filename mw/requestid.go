@@ -0,0 +1,36 @@
+package mw
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sspencer/goal/ctxutil"
+	"github.com/sspencer/goal/id"
+)
+
+// RequestIDHeader is the header carrying request IDs.
+const RequestIDHeader = ctxutil.RequestIDHeader
+
+// RequestID gives every request an ID, taken from the X-Request-ID header
+// of the request when present, or generated as a ULID, which sorts by time
+// in logs.  The ID is sent back in the X-Request-ID header of the response,
+// and handlers get it with GetRequestID (or ctxutil.RequestID).
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rid := r.Header.Get(RequestIDHeader)
+			if rid == "" || len(rid) > 128 {
+				rid = id.NewULID().String()
+			}
+
+			w.Header().Set(RequestIDHeader, rid)
+			next.ServeHTTP(w, r.WithContext(ctxutil.WithRequestID(r.Context(), rid)))
+		})
+	}
+}
+
+// GetRequestID returns the ID of the request with context ctx, or "" if the
+// RequestID middleware is not used.
+func GetRequestID(ctx context.Context) string {
+	return ctxutil.RequestID(ctx)
+}
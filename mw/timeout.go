@@ -0,0 +1,41 @@
+package mw
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sspencer/goal/resp"
+)
+
+// timeoutBody is the body of responses to requests that timed out
+const timeoutBody = `{"error":"request timed out"}`
+
+// Timeout cancels the context of requests running longer than d, and
+// responds with 503 Service Unavailable and a JSON error.  Handlers should
+// watch their context to stop early.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		h := http.TimeoutHandler(next, d, timeoutBody)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h.ServeHTTP(&timeoutWriter{ResponseWriter: w}, r)
+		})
+	}
+}
+
+// timeoutWriter labels the body of http.TimeoutHandler as JSON
+type timeoutWriter struct {
+	http.ResponseWriter
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	if status == http.StatusServiceUnavailable && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", resp.JSONContentType)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the original writer
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
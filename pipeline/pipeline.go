@@ -0,0 +1,253 @@
+// Package pipeline combines channels, for flows that don't fit the batch
+// model of str, e.g. merging event streams or batching writes.  Every
+// combinator stops and closes its output channels when ctx is done, or
+// once its inputs are closed and drained.
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FromSlice sends the items of input on the returned channel.
+func FromSlice[T any](ctx context.Context, input []T) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+
+		for _, v := range input {
+			if !send(ctx, out, v) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Collect receives the items of in until it is closed or ctx is done.
+func Collect[T any](ctx context.Context, in <-chan T) []T {
+	var out []T
+	for {
+		select {
+		case v, ok := <-in:
+			if !ok {
+				return out
+			}
+			out = append(out, v)
+		case <-ctx.Done():
+			return out
+		}
+	}
+}
+
+// FanIn merges the items of every input channel, in the order they arrive.
+func FanIn[T any](ctx context.Context, inputs ...<-chan T) <-chan T {
+	out := make(chan T)
+
+	var wg sync.WaitGroup
+	for _, in := range inputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			forward(ctx, in, out)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// FanOut spreads the items of in over n channels, every item going to one
+// of the channels ready to receive it, so slow consumers get fewer items.
+func FanOut[T any](ctx context.Context, in <-chan T, n int) []<-chan T {
+	outs := make([]<-chan T, max(n, 1))
+	for i := range outs {
+		out := make(chan T)
+		outs[i] = out
+
+		go func() {
+			defer close(out)
+			forward(ctx, in, out)
+		}()
+	}
+
+	return outs
+}
+
+// Tee sends every item of in to both returned channels.  Both must be read,
+// as the slower one holds back the other.
+func Tee[T any](ctx context.Context, in <-chan T) (<-chan T, <-chan T) {
+	out1, out2 := make(chan T), make(chan T)
+
+	go func() {
+		defer close(out1)
+		defer close(out2)
+
+		for {
+			v, ok := receive(ctx, in)
+			if !ok {
+				return
+			}
+
+			// send to whichever is ready first, then to the other
+			a, b := out1, out2
+			for i := 0; i < 2; i++ {
+				select {
+				case a <- v:
+					a = nil
+				case b <- v:
+					b = nil
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out1, out2
+}
+
+// Buffer holds up to size items of in, so a bursty producer isn't held back
+// by a consumer that catches up later.
+func Buffer[T any](ctx context.Context, in <-chan T, size int) <-chan T {
+	out := make(chan T, max(size, 0))
+
+	go func() {
+		defer close(out)
+		forward(ctx, in, out)
+	}()
+
+	return out
+}
+
+// Throttle passes on the items of in at most one every interval, e.g.
+// time.Second/10 for 10 items a second.
+func Throttle[T any](ctx context.Context, in <-chan T, interval time.Duration) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+
+		var next time.Time
+		for {
+			v, ok := receive(ctx, in)
+			if !ok {
+				return
+			}
+
+			if wait := time.Until(next); wait > 0 {
+				t := time.NewTimer(wait)
+				select {
+				case <-t.C:
+				case <-ctx.Done():
+					t.Stop()
+					return
+				}
+			}
+			next = time.Now().Add(interval)
+
+			if !send(ctx, out, v) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Batch groups the items of in into slices of up to size items.  A partial
+// batch is sent once maxWait went by since its first item (never when 0),
+// so items don't wait forever when in is slow, and when in is closed.
+func Batch[T any](ctx context.Context, in <-chan T, size int, maxWait time.Duration) <-chan []T {
+	size = max(size, 1)
+	out := make(chan []T)
+
+	go func() {
+		defer close(out)
+
+		var batch []T
+		var timer *time.Timer
+		var expired <-chan time.Time
+
+		flush := func() bool {
+			if timer != nil {
+				timer.Stop()
+				timer, expired = nil, nil
+			}
+			if len(batch) == 0 {
+				return true
+			}
+
+			b := batch
+			batch = nil
+			return send(ctx, out, b)
+		}
+
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+
+				batch = append(batch, v)
+				if len(batch) == 1 && maxWait > 0 {
+					timer = time.NewTimer(maxWait)
+					expired = timer.C
+				}
+				if len(batch) >= size && !flush() {
+					return
+				}
+			case <-expired:
+				timer, expired = nil, nil
+				if !flush() {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// forward sends the items of in to out, until in is closed or ctx is done
+func forward[T any](ctx context.Context, in <-chan T, out chan<- T) {
+	for {
+		v, ok := receive(ctx, in)
+		if !ok || !send(ctx, out, v) {
+			return
+		}
+	}
+}
+
+// receive returns the next item of in, or FALSE when in is closed or ctx
+// is done
+func receive[T any](ctx context.Context, in <-chan T) (T, bool) {
+	select {
+	case v, ok := <-in:
+		return v, ok
+	case <-ctx.Done():
+		var zero T
+		return zero, false
+	}
+}
+
+// send sends v on out, returning FALSE if ctx is done first
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,146 @@
+package pipeline_test
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/pipeline"
+)
+
+func numbers(n int) []int {
+	input := make([]int, n)
+	for i := range input {
+		input[i] = i
+	}
+	return input
+}
+
+func TestFanInOut(t *testing.T) {
+	ctx := context.Background()
+
+	outs := pipeline.FanOut(ctx, pipeline.FromSlice(ctx, numbers(100)), 4)
+	if len(outs) != 4 {
+		t.Fatalf("Expected 4 channels, received %d", len(outs))
+	}
+
+	got := pipeline.Collect(ctx, pipeline.FanIn(ctx, outs...))
+	sort.Ints(got)
+
+	if !reflect.DeepEqual(got, numbers(100)) {
+		t.Errorf("Expected every number once, received %v", got)
+	}
+}
+
+func TestTee(t *testing.T) {
+	ctx := context.Background()
+	a, b := pipeline.Tee(ctx, pipeline.FromSlice(ctx, numbers(10)))
+
+	var wg sync.WaitGroup
+	var gotA, gotB []int
+	wg.Add(2)
+	go func() { defer wg.Done(); gotA = pipeline.Collect(ctx, a) }()
+	go func() { defer wg.Done(); gotB = pipeline.Collect(ctx, b) }()
+	wg.Wait()
+
+	if !reflect.DeepEqual(gotA, numbers(10)) || !reflect.DeepEqual(gotB, numbers(10)) {
+		t.Errorf("Expected both to receive every number, received %v and %v", gotA, gotB)
+	}
+}
+
+func TestBuffer(t *testing.T) {
+	ctx := context.Background()
+	in := make(chan int)
+	out := pipeline.Buffer(ctx, in, 5)
+
+	// the producer doesn't wait for the consumer
+	for i := 0; i < 5; i++ {
+		select {
+		case in <- i:
+		case <-time.After(time.Second):
+			t.Fatal("Expected the buffer to hold 5 items")
+		}
+	}
+	close(in)
+
+	if got := pipeline.Collect(ctx, out); !reflect.DeepEqual(got, numbers(5)) {
+		t.Errorf("Expected %v, received %v", numbers(5), got)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	ctx := context.Background()
+
+	start := time.Now()
+	got := pipeline.Collect(ctx, pipeline.Throttle(ctx, pipeline.FromSlice(ctx, numbers(5)), 10*time.Millisecond))
+
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("Expected at least 40ms for 5 items, received %v", elapsed)
+	}
+	if !reflect.DeepEqual(got, numbers(5)) {
+		t.Errorf("Expected %v, received %v", numbers(5), got)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	ctx := context.Background()
+
+	got := pipeline.Collect(ctx, pipeline.Batch(ctx, pipeline.FromSlice(ctx, numbers(7)), 3, 0))
+	want := [][]int{{0, 1, 2}, {3, 4, 5}, {6}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, received %v", want, got)
+	}
+
+	// a partial batch is sent after maxWait
+	in := make(chan int)
+	batches := pipeline.Batch(ctx, in, 10, 10*time.Millisecond)
+	in <- 1
+	in <- 2
+
+	select {
+	case b := <-batches:
+		if !reflect.DeepEqual(b, []int{1, 2}) {
+			t.Errorf("Expected [1 2], received %v", b)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the partial batch after maxWait")
+	}
+	close(in)
+}
+
+func TestCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int) // never closed
+
+	outs := []<-chan int{
+		pipeline.FanIn(ctx, in),
+		pipeline.Buffer(ctx, in, 1),
+		pipeline.Throttle(ctx, in, time.Millisecond),
+	}
+	outs = append(outs, pipeline.FanOut(ctx, in, 2)...)
+	a, b := pipeline.Tee(ctx, in)
+	outs = append(outs, a, b)
+	batches := pipeline.Batch(ctx, in, 2, 0)
+
+	cancel()
+
+	for _, out := range outs {
+		select {
+		case _, ok := <-out:
+			if ok {
+				t.Error("Expected no items")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected the output to be closed")
+		}
+	}
+
+	select {
+	case <-batches:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the batches to be closed")
+	}
+}
@@ -0,0 +1,71 @@
+package pubsub
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Bus holds topics by name, created on first use.  It is safe for
+// concurrent use.
+type Bus[T any] struct {
+	mu     sync.Mutex
+	topics map[string]*Topic[T]
+}
+
+// NewBus returns a Bus without topics.
+func NewBus[T any]() *Bus[T] {
+	return &Bus[T]{topics: make(map[string]*Topic[T])}
+}
+
+// Topic returns the topic called name.
+func (b *Bus[T]) Topic(name string) *Topic[T] {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t, ok := b.topics[name]
+	if !ok {
+		t = NewTopic[T]()
+		b.topics[name] = t
+	}
+
+	return t
+}
+
+// Subscribe subscribes to the topic called name.
+func (b *Bus[T]) Subscribe(name string, opts ...Option) *Subscription[T] {
+	return b.Topic(name).Subscribe(opts...)
+}
+
+// Publish publishes msg to the topic called name.
+func (b *Bus[T]) Publish(ctx context.Context, name string, msg T) error {
+	return b.Topic(name).Publish(ctx, msg)
+}
+
+// Topics returns the names of the topics, sorted.
+func (b *Bus[T]) Topics() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	names := make([]string, 0, len(b.topics))
+	for name := range b.topics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Close unsubscribes the subscriptions of every topic.
+func (b *Bus[T]) Close() {
+	b.mu.Lock()
+	topics := make([]*Topic[T], 0, len(b.topics))
+	for _, t := range b.topics {
+		topics = append(topics, t)
+	}
+	b.mu.Unlock()
+
+	for _, t := range topics {
+		t.Close()
+	}
+}
@@ -0,0 +1,219 @@
+// Package pubsub is an in-memory publish/subscribe bus: messages published
+// to a topic are delivered to every subscription of the topic, each with its
+// own buffer, e.g.
+//
+//	bus := pubsub.NewBus[Order]()
+//	sub := bus.Subscribe("orders", pubsub.Buffer(100))
+//	defer sub.Unsubscribe()
+//
+//	go func() {
+//		for order := range sub.C() {
+//			ship(order)
+//		}
+//	}()
+//
+//	bus.Publish(ctx, "orders", order)
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// Policy decides what happens to messages published to a subscription whose
+// buffer is full.
+type Policy int
+
+const (
+	// Block waits for room in the buffer, or until the ctx of Publish is
+	// done (the default)
+	Block Policy = iota
+
+	// DropNewest drops the message published
+	DropNewest
+
+	// DropOldest drops the oldest message in the buffer, to make room
+	DropOldest
+
+	// Disconnect unsubscribes the subscription, whose channel is closed
+	Disconnect
+)
+
+// options are the settings of a subscription, which don't depend on T
+type options struct {
+	buffer int
+	policy Policy
+}
+
+// Option configures a Subscription.
+type Option func(*options)
+
+// Buffer is the number of messages buffered for the subscriber, 16 by
+// default.
+func Buffer(n int) Option {
+	return func(o *options) {
+		o.buffer = max(n, 0)
+	}
+}
+
+// OnFull sets the policy for messages published while the buffer is full.
+func OnFull(p Policy) Option {
+	return func(o *options) {
+		o.policy = p
+	}
+}
+
+// Topic delivers the messages published to its subscriptions.  It is safe
+// for concurrent use.
+type Topic[T any] struct {
+	mu   sync.RWMutex
+	subs map[*Subscription[T]]struct{}
+}
+
+// NewTopic returns a Topic without subscriptions.
+func NewTopic[T any]() *Topic[T] {
+	return &Topic[T]{subs: make(map[*Subscription[T]]struct{})}
+}
+
+// Subscribe returns a new subscription to the messages published from now
+// on.
+func (t *Topic[T]) Subscribe(opts ...Option) *Subscription[T] {
+	o := options{buffer: 16}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	s := &Subscription[T]{topic: t, o: o, ch: make(chan T, o.buffer), done: make(chan struct{})}
+
+	t.mu.Lock()
+	t.subs[s] = struct{}{}
+	t.mu.Unlock()
+
+	return s
+}
+
+// Publish delivers msg to every subscription, in turn.  With the Block
+// policy, it waits for slow subscribers, returning the error of ctx if it
+// is done first; the subscriptions after are then skipped.
+func (t *Topic[T]) Publish(ctx context.Context, msg T) error {
+	t.mu.RLock()
+	subs := make([]*Subscription[T], 0, len(t.subs))
+	for s := range t.subs {
+		subs = append(subs, s)
+	}
+	t.mu.RUnlock()
+
+	for _, s := range subs {
+		if err := s.deliver(ctx, msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Len returns the number of subscriptions.
+func (t *Topic[T]) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return len(t.subs)
+}
+
+// Close unsubscribes every subscription.
+func (t *Topic[T]) Close() {
+	t.mu.RLock()
+	subs := make([]*Subscription[T], 0, len(t.subs))
+	for s := range t.subs {
+		subs = append(subs, s)
+	}
+	t.mu.RUnlock()
+
+	for _, s := range subs {
+		s.Unsubscribe()
+	}
+}
+
+// Subscription receives the messages of a Topic on its channel.
+type Subscription[T any] struct {
+	topic *Topic[T]
+	o     options
+	ch    chan T
+	done  chan struct{}
+	once  sync.Once
+
+	mu     sync.Mutex // held while delivering
+	closed bool
+}
+
+// C returns the channel of the messages, closed once unsubscribed.
+func (s *Subscription[T]) C() <-chan T {
+	return s.ch
+}
+
+// Done is closed once unsubscribed.
+func (s *Subscription[T]) Done() <-chan struct{} {
+	return s.done
+}
+
+// Unsubscribe stops the delivery of messages, and closes the channel.
+// Messages still buffered can be read.
+func (s *Subscription[T]) Unsubscribe() {
+	s.once.Do(func() {
+		s.topic.mu.Lock()
+		delete(s.topic.subs, s)
+		s.topic.mu.Unlock()
+
+		close(s.done) // wakes blocked deliveries
+
+		s.mu.Lock()
+		s.closed = true
+		close(s.ch)
+		s.mu.Unlock()
+	})
+}
+
+// deliver sends msg according to the policy, returning the error of ctx
+// when blocked until it is done
+func (s *Subscription[T]) deliver(ctx context.Context, msg T) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+
+	select {
+	case s.ch <- msg:
+		s.mu.Unlock()
+		return nil
+	default:
+	}
+
+	switch s.o.policy {
+	case Block:
+		defer s.mu.Unlock()
+		select {
+		case s.ch <- msg:
+		case <-s.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	case DropOldest:
+		defer s.mu.Unlock()
+		select {
+		case <-s.ch:
+		default:
+		}
+		select {
+		case s.ch <- msg:
+		default: // unbuffered, and nobody reading
+		}
+	case DropNewest:
+		s.mu.Unlock()
+	case Disconnect:
+		s.mu.Unlock()
+		s.Unsubscribe()
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package pubsub_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/pubsub"
+)
+
+// drain returns the messages buffered in s
+func drain(s *pubsub.Subscription[int]) []int {
+	var msgs []int
+	for {
+		select {
+		case msg, ok := <-s.C():
+			if !ok {
+				return msgs
+			}
+			msgs = append(msgs, msg)
+		default:
+			return msgs
+		}
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy pubsub.Policy
+		msgs   []int
+		err    error
+		closed bool
+	}{
+		{"block", pubsub.Block, []int{1, 2}, context.DeadlineExceeded, false},
+		{"drop newest", pubsub.DropNewest, []int{1, 2}, nil, false},
+		{"drop oldest", pubsub.DropOldest, []int{3, 4}, nil, false},
+		{"disconnect", pubsub.Disconnect, []int{1, 2}, nil, true},
+	}
+
+	for _, tt := range tests {
+		topic := pubsub.NewTopic[int]()
+		s := topic.Subscribe(pubsub.Buffer(2), pubsub.OnFull(tt.policy))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		var err error
+		for n := 1; n <= 4 && err == nil; n++ {
+			err = topic.Publish(ctx, n)
+		}
+		cancel()
+
+		if err != tt.err {
+			t.Errorf("%s: expected %v, received %v", tt.name, tt.err, err)
+		}
+		if msgs := drain(s); !slices.Equal(msgs, tt.msgs) {
+			t.Errorf("%s: expected %v, received %v", tt.name, tt.msgs, msgs)
+		}
+		if closed := topic.Len() == 0; closed != tt.closed {
+			t.Errorf("%s: expected unsubscribed %v, received %v", tt.name, tt.closed, closed)
+		}
+	}
+}
+
+func TestBus(t *testing.T) {
+	bus := pubsub.NewBus[int]()
+	a1 := bus.Subscribe("a")
+	a2 := bus.Subscribe("a")
+	b := bus.Subscribe("b")
+
+	bus.Publish(context.Background(), "a", 1)
+	bus.Publish(context.Background(), "b", 2)
+
+	tests := []struct {
+		name string
+		sub  *pubsub.Subscription[int]
+		msgs []int
+	}{
+		{"a1", a1, []int{1}},
+		{"a2", a2, []int{1}},
+		{"b", b, []int{2}},
+	}
+
+	for _, tt := range tests {
+		if msgs := drain(tt.sub); !slices.Equal(msgs, tt.msgs) {
+			t.Errorf("%s: expected %v, received %v", tt.name, tt.msgs, msgs)
+		}
+	}
+
+	if topics := bus.Topics(); !slices.Equal(topics, []string{"a", "b"}) {
+		t.Errorf("Expected topics [a b], received %v", topics)
+	}
+
+	bus.Close()
+	select {
+	case <-a1.Done():
+	default:
+		t.Error("Expected subscriptions to be closed")
+	}
+	if _, ok := <-a1.C(); ok {
+		t.Error("Expected the channel to be closed")
+	}
+}
+
+func TestUnsubscribeWhileBlocked(t *testing.T) {
+	topic := pubsub.NewTopic[int]()
+	s := topic.Subscribe(pubsub.Buffer(0))
+
+	published := make(chan error)
+	go func() {
+		published <- topic.Publish(context.Background(), 1)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	s.Unsubscribe()
+
+	select {
+	case err := <-published:
+		if err != nil {
+			t.Errorf("Expected no error, received %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Publish to return once unsubscribed")
+	}
+}
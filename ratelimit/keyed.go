@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Keyed holds a Limiter per key, created on first use, e.g. to limit every
+// client IP on its own.  Limiters unused for a while are dropped, so keys
+// can be unbounded.  It is safe for concurrent use.
+type Keyed[K comparable] struct {
+	mu       sync.Mutex
+	newLimit func() Limiter
+	idle     time.Duration
+	limiters map[K]*keyed
+	swept    time.Time
+}
+
+type keyed struct {
+	Limiter
+	used time.Time
+}
+
+// NewKeyed returns a Keyed creating limiters with newLimit, and dropping
+// those unused for idle (never when 0), e.g.
+//
+//	perIP := ratelimit.NewKeyed[string](func() ratelimit.Limiter {
+//		return ratelimit.NewTokenBucket(10, 20)
+//	}, time.Hour)
+func NewKeyed[K comparable](newLimit func() Limiter, idle time.Duration) *Keyed[K] {
+	return &Keyed[K]{newLimit: newLimit, idle: idle, limiters: make(map[K]*keyed), swept: time.Now()}
+}
+
+// Allow reports whether an event for key may happen now.
+func (k *Keyed[K]) Allow(key K) bool {
+	return k.Get(key).Allow()
+}
+
+// Wait blocks until an event for key may happen, or ctx is done.
+func (k *Keyed[K]) Wait(ctx context.Context, key K) error {
+	return k.Get(key).Wait(ctx)
+}
+
+// Get returns the limiter of key.
+func (k *Keyed[K]) Get(key K) Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	k.sweep(now)
+
+	l, ok := k.limiters[key]
+	if !ok {
+		l = &keyed{Limiter: k.newLimit()}
+		k.limiters[key] = l
+	}
+	l.used = now
+
+	return l.Limiter
+}
+
+// Len returns the number of keys with a limiter.
+func (k *Keyed[K]) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return len(k.limiters)
+}
+
+// sweep drops the limiters idle for too long, at most once per idle period
+func (k *Keyed[K]) sweep(now time.Time) {
+	if k.idle <= 0 || now.Sub(k.swept) < k.idle {
+		return
+	}
+	k.swept = now
+
+	for key, l := range k.limiters {
+		if now.Sub(l.used) >= k.idle {
+			delete(k.limiters, key)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Package ratelimit limits how often something happens: a TokenBucket
+// allows bursts on top of a steady rate, a SlidingWindow allows a number of
+// events per window of time, and Keyed holds a limiter per key, e.g. per
+// client IP or per host.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxReserve bounds the waits of Reserve, e.g. for buckets that never
+// refill, so callers check again rather than wait forever
+const maxReserve = time.Hour
+
+// Limiter decides whether events may happen now.
+type Limiter interface {
+	// Allow reports whether an event may happen now, consuming the
+	// allowance if so
+	Allow() bool
+
+	// Wait blocks until an event may happen, or ctx is done
+	Wait(ctx context.Context) error
+}
+
+// TokenBucket is a Limiter refilled at a steady rate, up to a burst.  It is
+// safe for concurrent use.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket allows 'rate' events per second on average, and up to
+// 'burst' at once after being idle.  A rate of 0 or less never refills the
+// bucket, so only the burst is allowed.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	burst = max(burst, 1)
+	rate = max(rate, 0)
+	return &TokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Allow takes a token if there is one.
+func (b *TokenBucket) Allow() bool {
+	return b.Reserve() == 0
+}
+
+// Wait blocks until it takes a token, or ctx is done.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	return wait(ctx, b.Reserve)
+}
+
+// Reserve takes a token if there is one, otherwise returns how long until
+// there will be, up to an hour.
+func (b *TokenBucket) Reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*b.rate, b.burst)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+
+	// clamped before converting, which would overflow for slow or no refills
+	wait := (1 - b.tokens) / b.rate * float64(time.Second)
+	if b.rate == 0 || wait > float64(maxReserve) {
+		return maxReserve
+	}
+
+	return time.Duration(wait)
+}
+
+// SlidingWindow is a Limiter allowing up to 'limit' events in any window of
+// time, e.g. 100 requests per minute.  It remembers the time of the events
+// in the window, so it suits modest limits.  It is safe for concurrent use.
+type SlidingWindow struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	events []time.Time // oldest first
+}
+
+// NewSlidingWindow allows up to 'limit' events per window.
+func NewSlidingWindow(limit int, window time.Duration) *SlidingWindow {
+	limit = max(limit, 1)
+	return &SlidingWindow{limit: limit, window: window, events: make([]time.Time, 0, limit)}
+}
+
+// Allow records an event if there were fewer than limit in the last window.
+func (w *SlidingWindow) Allow() bool {
+	return w.Reserve() == 0
+}
+
+// Wait blocks until it records an event, or ctx is done.
+func (w *SlidingWindow) Wait(ctx context.Context) error {
+	return wait(ctx, w.Reserve)
+}
+
+// Reserve records an event if there is room in the window, otherwise
+// returns how long until there will be.
+func (w *SlidingWindow) Reserve() time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	expired := 0
+	for expired < len(w.events) && now.Sub(w.events[expired]) >= w.window {
+		expired++
+	}
+	w.events = append(w.events[:0], w.events[expired:]...)
+
+	if len(w.events) < w.limit {
+		w.events = append(w.events, now)
+		return 0
+	}
+
+	return w.window - now.Sub(w.events[0])
+}
+
+// wait blocks until reserve succeeds, or ctx is done
+func wait(ctx context.Context, reserve func() time.Duration) error {
+	for {
+		d := reserve()
+		if d == 0 {
+			return nil
+		}
+
+		t := time.NewTimer(d)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/ratelimit"
+)
+
+func TestLimiters(t *testing.T) {
+	tests := []struct {
+		name    string
+		limiter ratelimit.Limiter
+		allowed int
+	}{
+		{"token bucket", ratelimit.NewTokenBucket(1, 3), 3},
+		{"sliding window", ratelimit.NewSlidingWindow(2, time.Minute), 2},
+		{"no refill", ratelimit.NewTokenBucket(0, 2), 2},
+		{"negative rate", ratelimit.NewTokenBucket(-1, 1), 1},
+	}
+
+	for _, tt := range tests {
+		allowed := 0
+		for i := 0; i < 10; i++ {
+			if tt.limiter.Allow() {
+				allowed++
+			}
+		}
+
+		if allowed != tt.allowed {
+			t.Errorf("%s: expected %d allowed at once, received %d", tt.name, tt.allowed, allowed)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		if err := tt.limiter.Wait(ctx); err != context.DeadlineExceeded {
+			t.Errorf("%s: expected to wait past the deadline, received %v", tt.name, err)
+		}
+		cancel()
+	}
+}
+
+func TestReserve(t *testing.T) {
+	tests := []struct {
+		name   string
+		bucket *ratelimit.TokenBucket
+		min    time.Duration
+		max    time.Duration
+	}{
+		{"refills", ratelimit.NewTokenBucket(10, 1), 50 * time.Millisecond, 100 * time.Millisecond},
+		{"no refill", ratelimit.NewTokenBucket(0, 1), time.Hour, time.Hour},
+		{"slow refill", ratelimit.NewTokenBucket(1e-12, 1), time.Hour, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if d := tt.bucket.Reserve(); d != 0 {
+			t.Errorf("%s: expected the first token at once, received %v", tt.name, d)
+		}
+		if d := tt.bucket.Reserve(); d < tt.min || d > tt.max {
+			t.Errorf("%s: expected to wait between %v and %v, received %v", tt.name, tt.min, tt.max, d)
+		}
+	}
+}
+
+func TestWait(t *testing.T) {
+	tests := []struct {
+		name    string
+		limiter ratelimit.Limiter
+	}{
+		{"token bucket", ratelimit.NewTokenBucket(100, 1)},
+		{"sliding window", ratelimit.NewSlidingWindow(1, 10*time.Millisecond)},
+	}
+
+	for _, tt := range tests {
+		start := time.Now()
+		for i := 0; i < 4; i++ {
+			if err := tt.limiter.Wait(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if elapsed := time.Since(start); elapsed < 30*time.Millisecond || elapsed > time.Second {
+			t.Errorf("%s: expected about 30ms for 4 events, received %v", tt.name, elapsed)
+		}
+	}
+}
+
+func TestKeyed(t *testing.T) {
+	perIP := ratelimit.NewKeyed[string](func() ratelimit.Limiter {
+		return ratelimit.NewTokenBucket(1, 1)
+	}, 20*time.Millisecond)
+
+	if !perIP.Allow("a") || perIP.Allow("a") {
+		t.Error("Expected a single event for a")
+	}
+	if !perIP.Allow("b") {
+		t.Error("Expected b to have its own limit")
+	}
+	if perIP.Len() != 2 {
+		t.Errorf("Expected 2 keys, received %d", perIP.Len())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	perIP.Allow("c")
+	if perIP.Len() != 1 {
+		t.Errorf("Expected idle keys to be dropped, received %d keys", perIP.Len())
+	}
+}
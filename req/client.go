@@ -0,0 +1,36 @@
+package req
+
+import (
+	"net/http"
+)
+
+// HTTPClient sends requests with client, e.g. one with a cookie jar, in
+// place of the one created by New.  Timeout and SkipRedirects, called
+// after, change a copy of it.
+func HTTPClient(client *http.Client) RequestFunc {
+	return func(c *Request) {
+		c.client = client
+	}
+}
+
+// Transport sends requests through rt, e.g. for a proxy, custom TLS
+// settings, tracing or a test double:
+//
+//	t := http.DefaultTransport.(*http.Transport).Clone()
+//	t.Proxy = http.ProxyURL(proxy)
+//	r := req.New(req.Transport(t))
+func Transport(rt http.RoundTripper) RequestFunc {
+	return func(c *Request) {
+		c.configure(func(client *http.Client) {
+			client.Transport = rt
+		})
+	}
+}
+
+// configure changes a copy of the client of c, which other Requests may
+// share, e.g. those made by With
+func (c *Request) configure(fn func(*http.Client)) {
+	client := *c.client
+	fn(&client)
+	c.client = &client
+}
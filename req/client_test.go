@@ -0,0 +1,94 @@
+package req_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/req"
+)
+
+type countingTransport struct {
+	n atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.n.Add(1)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestTransport(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer s.Close()
+
+	var rt countingTransport
+	r := req.New(req.Transport(&rt))
+
+	resp, err := r.Get(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if err := r.Ping(s.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := rt.n.Load(); n != 2 {
+		t.Errorf("Expected 2 requests through the transport, received %d", n)
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("session"); err != nil {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+			return
+		}
+		w.Write([]byte("known"))
+	}))
+	defer s.Close()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rt countingTransport
+	client := &http.Client{Jar: jar, Transport: &rt}
+	r := req.New(req.HTTPClient(client)).Timeout(time.Minute)
+
+	resp, err := r.Get(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	resp, err = r.Get(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != "known" {
+		t.Errorf("Expected the cookie jar of client to be used, received %q", body)
+	}
+
+	if n := rt.n.Load(); n != 2 {
+		t.Errorf("Expected 2 requests through the client, received %d", n)
+	}
+
+	if client.Timeout != 0 {
+		t.Errorf("Expected Timeout to leave client unchanged, received %s", client.Timeout)
+	}
+}
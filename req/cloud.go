@@ -0,0 +1,163 @@
+package req
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	metadataTimeout  = 5 * time.Second
+	identityLifetime = time.Hour
+)
+
+// metadata endpoints, variables for tests
+var (
+	azureTokenURL  = "http://169.254.169.254/metadata/identity/oauth2/token?api-version=2018-02-01"
+	gcpTokenURL    = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+	gcpIdentityURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/identity?format=full"
+)
+
+// metadataClient never goes through a proxy, so credentials stay on the
+// host
+var metadataClient = &http.Client{
+	Timeout:   metadataTimeout,
+	Transport: &http.Transport{Proxy: nil},
+}
+
+// AzureIMDS returns a TokenSource for the managed identity of an Azure VM,
+// scoped to resource, e.g. "https://management.azure.com/".
+func AzureIMDS(resource string) TokenSource {
+	return AzureIMDSClient(resource, "")
+}
+
+// AzureIMDSClient is like AzureIMDS, for the user-assigned identity with clientID.
+func AzureIMDSClient(resource, clientID string) TokenSource {
+	return &cachedToken{fetch: func(ctx context.Context) (string, time.Time, error) {
+		u := azureTokenURL + "&resource=" + url.QueryEscape(resource)
+		if clientID != "" {
+			u += "&client_id=" + url.QueryEscape(clientID)
+		}
+
+		var tr struct {
+			AccessToken string      `json:"access_token"`
+			ExpiresOn   json.Number `json:"expires_on"`
+		}
+
+		if err := metadataJSON(ctx, u, "Metadata", "true", &tr); err != nil {
+			return "", time.Time{}, err
+		}
+
+		secs, err := tr.ExpiresOn.Int64()
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("invalid azure token expiry %q", tr.ExpiresOn)
+		}
+
+		return tr.AccessToken, time.Unix(secs, 0), nil
+	}}
+}
+
+// GCPMetadata returns a TokenSource for OAuth2 access tokens of the default
+// service account of a GCP instance.
+func GCPMetadata() TokenSource {
+	return &cachedToken{fetch: func(ctx context.Context) (string, time.Time, error) {
+		var tr struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int64  `json:"expires_in"`
+		}
+
+		if err := metadataJSON(ctx, gcpTokenURL, "Metadata-Flavor", "Google", &tr); err != nil {
+			return "", time.Time{}, err
+		}
+
+		return tr.AccessToken, time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second), nil
+	}}
+}
+
+// GCPIdentity returns a TokenSource for OIDC identity tokens of the default
+// service account of a GCP instance, e.g. to call Cloud Run services.
+func GCPIdentity(audience string) TokenSource {
+	return &cachedToken{fetch: func(ctx context.Context) (string, time.Time, error) {
+		u := gcpIdentityURL + "&audience=" + url.QueryEscape(audience)
+		b, err := metadataGet(ctx, u, "Metadata-Flavor", "Google")
+		if err != nil {
+			return "", time.Time{}, err
+		}
+
+		token := strings.TrimSpace(string(b))
+		return token, jwtExpiry(token), nil
+	}}
+}
+
+// metadataJSON decodes the JSON response of a metadata endpoint into v
+func metadataJSON(ctx context.Context, url, header, value string, v interface{}) error {
+	b, err := metadataGet(ctx, url, header, value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// metadataGet reads a metadata endpoint.  These require a header to prove
+// the request isn't forwarded from elsewhere.
+func metadataGet(ctx context.Context, url, header, value string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(header, value)
+
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsSuccess(resp.StatusCode) {
+		return nil, fmt.Errorf("metadata token request failed.  HTTP Status %d: %v", resp.StatusCode, string(body))
+	}
+
+	return body, nil
+}
+
+// jwtExpiry reads the "exp" claim of a JWT, without verifying it
+func jwtExpiry(token string) time.Time {
+	fallback := time.Now().Add(identityLifetime)
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fallback
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return fallback
+	}
+
+	var claims struct {
+		Exp json.Number `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return fallback
+	}
+
+	secs, err := strconv.ParseInt(claims.Exp.String(), 10, 64)
+	if err != nil {
+		return fallback
+	}
+
+	return time.Unix(secs, 0)
+}
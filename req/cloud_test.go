@@ -0,0 +1,127 @@
+package req_test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/req"
+)
+
+// payload is the claims of the identity tokens of metadataServer
+var payload = base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, time.Now().Add(time.Hour).Unix())))
+
+// metadataServer fakes the Azure and GCP metadata endpoints, counting the
+// tokens it issues
+func metadataServer(t *testing.T, fetches *atomic.Int32) {
+	t.Helper()
+
+	exp := time.Now().Add(time.Hour).Unix()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case r.URL.Path == "/azure" && r.Header.Get("Metadata") == "true":
+			fetches.Add(1)
+			fmt.Fprintf(w, `{"access_token":"azure %s %s","expires_on":"%d"}`, q.Get("resource"), q.Get("client_id"), exp)
+		case r.URL.Path == "/gcp/token" && r.Header.Get("Metadata-Flavor") == "Google":
+			fetches.Add(1)
+			fmt.Fprint(w, `{"access_token":"gcp","expires_in":3600}`)
+		case r.URL.Path == "/gcp/identity" && r.Header.Get("Metadata-Flavor") == "Google" && q.Get("audience") != "":
+			fetches.Add(1)
+			fmt.Fprintf(w, "header.%s.signature\n", payload)
+		default:
+			http.Error(w, "missing metadata header", http.StatusForbidden)
+		}
+	}))
+	t.Cleanup(s.Close)
+	t.Cleanup(req.SetMetadataURL(s.URL))
+}
+
+func TestMetadataTokens(t *testing.T) {
+	var fetches atomic.Int32
+	metadataServer(t, &fetches)
+
+	tests := []struct {
+		name string
+		ts   req.TokenSource
+		want string
+	}{
+		{"azure", req.AzureIMDS("https://vault.azure.net"), "azure https://vault.azure.net "},
+		{"azure client", req.AzureIMDSClient("https://vault.azure.net", "abc"), "azure https://vault.azure.net abc"},
+		{"gcp", req.GCPMetadata(), "gcp"},
+	}
+
+	for _, tt := range tests {
+		fetches.Store(0)
+		for i := 0; i < 2; i++ {
+			if token, err := tt.ts.Token(context.Background()); token != tt.want || err != nil {
+				t.Errorf("%s: expected %q, received %q, %v", tt.name, tt.want, token, err)
+			}
+		}
+		if fetches.Load() != 1 {
+			t.Errorf("%s: expected the token to be cached, fetched %d times", tt.name, fetches.Load())
+		}
+	}
+
+	token, err := req.GCPIdentity("https://service.run.app").Token(context.Background())
+	if err != nil || token != "header."+payload+".signature" {
+		t.Errorf("Expected an identity token, received %q, %v", token, err)
+	}
+}
+
+func TestMetadataErrors(t *testing.T) {
+	var fetches atomic.Int32
+	metadataServer(t, &fetches)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := req.GCPMetadata().Token(ctx); err == nil {
+		t.Error("Expected a canceled context to stop the request")
+	}
+
+	// errors aren't cached
+	fetches.Store(0)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer s.Close()
+	defer req.SetMetadataURL(s.URL)()
+
+	ts := req.GCPMetadata()
+	for i := 0; i < 2; i++ {
+		if _, err := ts.Token(context.Background()); err == nil {
+			t.Error("Expected an error for a 404")
+		}
+	}
+	if fetches.Load() != 2 {
+		t.Errorf("Expected the token to be fetched again, fetched %d times", fetches.Load())
+	}
+}
+
+func TestBearerSource(t *testing.T) {
+	var fetches atomic.Int32
+	metadataServer(t, &fetches)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer s.Close()
+
+	resp, err := req.New().BearerSource(req.GCPMetadata()).Get(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if got, _ := io.ReadAll(resp.Body); string(got) != "Bearer gcp" {
+		t.Errorf("Expected the metadata token, received %q", got)
+	}
+}
@@ -0,0 +1,73 @@
+package req
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// The Ctx methods are like the methods without the suffix, with a context
+// to cancel the request, or give it a deadline of its own on top of the
+// Timeout of the client, e.g. to tie it to the handler serving a request:
+//   resp, err := r.GetCtx(req.Context(), url)
+// The request ID of ctx (see ctxutil.WithRequestID) is sent in the
+// X-Request-ID header.
+
+// GetCtx performs a HTTP GET
+func (c *Request) GetCtx(ctx context.Context, url string) (*http.Response, error) {
+	return c.request(ctx, http.MethodGet, url, "", nil)
+}
+
+// HeadCtx performs a HTTP HEAD
+func (c *Request) HeadCtx(ctx context.Context, url string) (*http.Response, error) {
+	return c.request(ctx, http.MethodHead, url, "", nil)
+}
+
+// DeleteCtx performs a HTTP DELETE
+func (c *Request) DeleteCtx(ctx context.Context, url string) (*http.Response, error) {
+	return c.request(ctx, http.MethodDelete, url, "", nil)
+}
+
+// PostCtx performs a HTTP POST of url-encoded form values
+func (c *Request) PostCtx(ctx context.Context, url string, values url.Values) (*http.Response, error) {
+	return c.request(ctx, http.MethodPost, url, URLEncodededContentType, strings.NewReader(values.Encode()))
+}
+
+// PutCtx performs a HTTP PUT of url-encoded form values
+func (c *Request) PutCtx(ctx context.Context, url string, values url.Values) (*http.Response, error) {
+	return c.request(ctx, http.MethodPut, url, URLEncodededContentType, strings.NewReader(values.Encode()))
+}
+
+// PatchCtx performs a HTTP PATCH of url-encoded form values
+func (c *Request) PatchCtx(ctx context.Context, url string, values url.Values) (*http.Response, error) {
+	return c.request(ctx, http.MethodPatch, url, URLEncodededContentType, strings.NewReader(values.Encode()))
+}
+
+// PostJSONCtx performs a HTTP POST of v encoded as JSON
+func (c *Request) PostJSONCtx(ctx context.Context, url string, v interface{}) (*http.Response, error) {
+	return c.requestJSON(ctx, http.MethodPost, url, v)
+}
+
+// PutJSONCtx performs a HTTP PUT of v encoded as JSON
+func (c *Request) PutJSONCtx(ctx context.Context, url string, v interface{}) (*http.Response, error) {
+	return c.requestJSON(ctx, http.MethodPut, url, v)
+}
+
+// PatchJSONCtx performs a HTTP PATCH of v encoded as JSON
+func (c *Request) PatchJSONCtx(ctx context.Context, url string, v interface{}) (*http.Response, error) {
+	return c.requestJSON(ctx, http.MethodPatch, url, v)
+}
+
+// PingCtx GETs url, discarding the body, and returns an error unless the
+// status is 2XX
+func (c *Request) PingCtx(ctx context.Context, url string) error {
+	resp, err := c.GetCtx(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
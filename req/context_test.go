@@ -0,0 +1,77 @@
+package req_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/req"
+)
+
+func TestCtxCanceled(t *testing.T) {
+	release := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer s.Close()
+	defer close(release)
+
+	r := req.New()
+
+	tests := []struct {
+		name string
+		send func(ctx context.Context) error
+	}{
+		{"get", func(ctx context.Context) error { _, err := r.GetCtx(ctx, s.URL); return err }},
+		{"head", func(ctx context.Context) error { _, err := r.HeadCtx(ctx, s.URL); return err }},
+		{"delete", func(ctx context.Context) error { _, err := r.DeleteCtx(ctx, s.URL); return err }},
+		{"post", func(ctx context.Context) error { _, err := r.PostCtx(ctx, s.URL, nil); return err }},
+		{"postJSON", func(ctx context.Context) error { _, err := r.PostJSONCtx(ctx, s.URL, 1); return err }},
+		{"ping", func(ctx context.Context) error { return r.PingCtx(ctx, s.URL) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			err := tt.send(ctx)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("Expected context.DeadlineExceeded, received %v", err)
+			}
+
+			if d := time.Since(start); d > 5*time.Second {
+				t.Errorf("Expected the request to stop when ctx was done, took %s", d)
+			}
+		})
+	}
+}
+
+func TestCtxCanceledRetries(t *testing.T) {
+	var calls atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := req.New(req.Retries(3)).GetCtx(ctx, s.URL)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, received %v", err)
+	}
+
+	if n := calls.Load(); n != 0 {
+		t.Errorf("Expected no requests with a canceled ctx, received %d", n)
+	}
+}
@@ -0,0 +1,67 @@
+package req
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sspencer/goal/flight"
+)
+
+// Dedupe shares the exchange of concurrent identical GET and HEAD requests
+// (same URL and headers), e.g. when many goroutines fetch the same page,
+//
+//	r := req.New(req.Dedupe())
+//
+// The response body is read in memory, and every caller gets a response
+// of its own to read.  A caller canceling its ctx stops waiting, without
+// canceling the exchange shared with the others.
+func Dedupe() RequestFunc {
+	return func(c *Request) {
+		c.flights = flight.New[string, *sharedResponse](0)
+	}
+}
+
+// sharedResponse is a response read by Dedupe, copied for every caller
+type sharedResponse struct {
+	resp *http.Response
+	body []byte
+}
+
+// dedupe sends req unless an identical request is in flight, sharing its
+// response otherwise
+func (c *Request) dedupe(req *http.Request, body []byte) (*http.Response, error) {
+	if c.flights == nil || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return c.send(req, body)
+	}
+
+	var key strings.Builder
+	key.WriteString(req.Method + " " + req.URL.String() + "\n")
+	req.Header.Write(&key) // sorted
+
+	shared, err := c.flights.Do(req.Context(), key.String(), func(ctx context.Context) (*sharedResponse, error) {
+		resp, err := c.send(req.WithContext(ctx), body)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		return &sharedResponse{resp, b}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := *shared.resp
+	resp.Header = shared.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(shared.body))
+	resp.Request = req
+	return &resp, nil
+}
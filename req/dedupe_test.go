@@ -0,0 +1,80 @@
+package req_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/req"
+)
+
+func TestDedupe(t *testing.T) {
+	var calls atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond) // for the other requests to join
+		w.Write([]byte("shared " + r.URL.Path))
+	}))
+	defer s.Close()
+
+	tests := []struct {
+		name  string
+		opts  []req.RequestFunc
+		calls int32
+	}{
+		{"shared", []req.RequestFunc{req.Dedupe()}, 1},
+		{"not shared", nil, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			r := req.New(tt.opts...)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					resp, err := r.Get(s.URL + "/page")
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					defer resp.Body.Close()
+
+					if b, _ := io.ReadAll(resp.Body); string(b) != "shared /page" {
+						t.Errorf("Expected the whole body, received %q", b)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if calls.Load() != tt.calls {
+				t.Errorf("Expected %d requests, received %d", tt.calls, calls.Load())
+			}
+		})
+	}
+
+	// other headers, other requests
+	calls.Store(0)
+	r := req.New(req.Dedupe())
+	var wg sync.WaitGroup
+	for _, token := range []string{"a", "b"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if resp, err := r.With(req.BearerToken(token)).Get(s.URL); err == nil {
+				resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	if calls.Load() != 2 {
+		t.Errorf("Expected requests with other headers not to be shared, received %d", calls.Load())
+	}
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"net/url"
 
+	"github.com/sspencer/goal/cli"
 	"github.com/sspencer/goal/req"
 )
 
@@ -22,28 +24,41 @@ type MovieResponse struct {
 }
 
 func main() {
-	url := "https://jsonmock.hackerrank.com/api/movies/search/?Title=world&page=1"
+	app := cli.New("movies", "Search the HackerRank movie API.")
+
+	var title *string
+	var page *int
+	search := app.Command("search", "Search movies by title.", func(ctx context.Context, args []string) error {
+		return searchMovies(*title, *page)
+	})
+	title = search.String("title", "world", "words in the title")
+	page = search.Int("page", 1, "page of results")
+
+	app.Main()
+}
+
+func searchMovies(title string, page int) error {
+	u := fmt.Sprintf("https://jsonmock.hackerrank.com/api/movies/search/?Title=%s&page=%d", url.QueryEscape(title), page)
 
 	r := req.New().CurlHeader()
-	resp, err := r.Get(url)
+	resp, err := r.Get(u)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return err
 	}
 
 	if !req.IsSuccess(resp.StatusCode) {
-		fmt.Printf("movie search failed with HTTP:%d\n", resp.StatusCode)
-		os.Exit(2)
+		return fmt.Errorf("movie search failed with HTTP:%d", resp.StatusCode)
 	}
 
 	mr := MovieResponse{}
 	if err := req.Unmarshal(resp.Body, &mr); err != nil {
-		fmt.Println("Could not decode movie response:", err)
-		os.Exit(3)
+		return fmt.Errorf("could not decode movie response: %w", err)
 	}
 
 	fmt.Printf("Movie page: %d, per_page: %d, total: %d, total_pages: %d\n", mr.Page, mr.PerPage, mr.Total, mr.TotalPages)
 	for _, m := range mr.Data {
 		fmt.Printf("  %d: %q\n", m.Year, m.Title)
 	}
+
+	return nil
 }
@@ -0,0 +1,25 @@
+package req
+
+// SetMetadataURL points the metadata TokenSources at the server at base,
+// returning a function restoring them.
+func SetMetadataURL(base string) func() {
+	azure, gcp, identity := azureTokenURL, gcpTokenURL, gcpIdentityURL
+	azureTokenURL = base + "/azure?api-version=2018-02-01"
+	gcpTokenURL = base + "/gcp/token"
+	gcpIdentityURL = base + "/gcp/identity?format=full"
+
+	return func() {
+		azureTokenURL, gcpTokenURL, gcpIdentityURL = azure, gcp, identity
+	}
+}
+
+// SetMaxSitemapSize changes the size limit of sitemaps, returning a
+// function restoring it.
+func SetMaxSitemapSize(n int64) func() {
+	size := maxSitemapSize
+	maxSitemapSize = n
+
+	return func() {
+		maxSitemapSize = size
+	}
+}
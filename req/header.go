@@ -0,0 +1,67 @@
+package req
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+)
+
+// Header sets the header key to value, on every request when given to New,
+// e.g.
+//
+//	r := req.New(req.Header("Accept", "application/vnd.github+json"))
+//
+// or on a single request when given to With or Do.  Explicit headers take
+// precedence over the content types of Post and the like.
+func Header(key, value string) RequestFunc {
+	return func(c *Request) {
+		c.header = cloneHeader(c.header)
+		c.header.Set(key, value)
+	}
+}
+
+// Headers sets all the values of the headers in h, replacing any value
+// set before.
+func Headers(h http.Header) RequestFunc {
+	return func(c *Request) {
+		c.header = cloneHeader(c.header)
+		for key, values := range h {
+			c.header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+		}
+	}
+}
+
+// BearerToken authenticates requests with an "Authorization: Bearer" header.
+// See BearerSource for tokens that expire.
+func BearerToken(token string) RequestFunc {
+	return Header("Authorization", "Bearer "+token)
+}
+
+// BasicAuth authenticates requests with HTTP basic authentication.
+func BasicAuth(user, password string) RequestFunc {
+	auth := base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+	return Header("Authorization", "Basic "+auth)
+}
+
+// With returns a copy of c configured by opts, for requests needing
+// options of their own, e.g.
+//
+//	r.With(req.BearerToken(token)).PostJSON(url, v)
+func (c *Request) With(opts ...RequestFunc) *Request {
+	r := *c
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	return &r
+}
+
+// GetWith performs a GET request with the additional headers h.
+func (c *Request) GetWith(url string, h http.Header) (*http.Response, error) {
+	return c.GetWithCtx(context.Background(), url, h)
+}
+
+// GetWithCtx is like GetWith, with a context as in GetCtx.
+func (c *Request) GetWithCtx(ctx context.Context, url string, h http.Header) (*http.Response, error) {
+	return c.With(Headers(h)).request(ctx, http.MethodGet, url, "", nil)
+}
@@ -0,0 +1,81 @@
+package req_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sspencer/goal/logx"
+	"github.com/sspencer/goal/req"
+)
+
+func TestHeaders(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Echo", r.Header.Get("X-Tenant")+" "+r.Header.Get("Authorization"))
+	}))
+	defer s.Close()
+
+	r := req.New(req.BearerToken("s3cr3t"))
+	resp, err := r.GetWithCtx(context.Background(), s.URL, http.Header{"X-Tenant": {"acme"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Echo"); got != "acme Bearer s3cr3t" {
+		t.Errorf("Expected the headers of the request and the call, received %q", got)
+	}
+
+	// the per-call headers don't stick
+	resp, err = r.Get(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Echo"); got != "Bearer s3cr3t" {
+		t.Errorf("Expected the headers of the request only, received %q", got)
+	}
+}
+
+func TestCurlRedacts(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer s.Close()
+
+	var l logx.TestLogger
+	resp, err := req.New(req.BearerToken("s3cr3t")).Logger(&l).CurlHeader().Get(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	entries := l.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected the exchange to be logged, received %d entries", len(entries))
+	}
+	out := entries[0].Msg
+	if strings.Contains(out, "s3cr3t") || !strings.Contains(out, "Authorization: Bearer ") {
+		t.Errorf("Expected the token to be masked, received %s", out)
+	}
+}
+
+func TestColorLogger(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"gopher"}`))
+	}))
+	defer s.Close()
+
+	var l logx.TestLogger
+	resp, err := req.New().Logger(&l).Curl().Color().Get(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	for _, e := range l.Entries() {
+		if strings.Contains(e.Msg, "\x1b[") {
+			t.Errorf("Expected no colors in the output of a Logger, received %q", e.Msg)
+		}
+	}
+}
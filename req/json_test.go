@@ -0,0 +1,59 @@
+package req_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sspencer/goal/req"
+)
+
+func TestJSONMethods(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.NewEncoder(w).Encode(map[string]string{
+			"method":      r.Method,
+			"contentType": r.Header.Get("Content-Type"),
+			"body":        string(body),
+		})
+	}))
+	defer s.Close()
+
+	r := req.New()
+	payload := map[string]int{"n": 1}
+
+	tests := []struct {
+		method string
+		send   func(url string, v interface{}) (*http.Response, error)
+		v      interface{}
+		body   string
+	}{
+		{http.MethodPost, r.PostJSON, payload, `{"n":1}`},
+		{http.MethodPut, r.PutJSON, payload, `{"n":1}`},
+		{http.MethodPatch, r.PatchJSON, payload, `{"n":1}`},
+		{http.MethodPost, r.PostJSON, []byte(`{"raw": true}`), `{"raw": true}`}, // sent as is
+		{http.MethodPut, r.PutJSON, json.RawMessage(`[1, 2]`), `[1, 2]`},
+	}
+
+	for _, tt := range tests {
+		resp, err := tt.send(s.URL, tt.v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got map[string]string
+		if err := req.Unmarshal(resp.Body, &got); err != nil {
+			t.Fatal(err)
+		}
+
+		if got["method"] != tt.method || got["contentType"] != req.JSONContentType || got["body"] != tt.body {
+			t.Errorf("%s %s: expected %s %q, received %v", tt.method, tt.body, req.JSONContentType, tt.body, got)
+		}
+	}
+
+	if _, err := r.PostJSON(s.URL, func() {}); err == nil {
+		t.Error("Expected an error for a value that can't be encoded")
+	}
+}
@@ -0,0 +1,146 @@
+package req
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"strings"
+
+	"github.com/sspencer/goal/fsu"
+)
+
+// ANSI colors for curl logging of JSON bodies
+const (
+	colorKey    = "\x1b[34;1m"
+	colorString = "\x1b[32m"
+	colorNumber = "\x1b[36m"
+	colorBool   = "\x1b[33m"
+	colorNull   = "\x1b[90m"
+	colorReset  = "\x1b[0m"
+)
+
+// JSONIndent changes the indent width of JSON bodies in curl logging (3 spaces)
+func (c *Request) JSONIndent(n int) *Request {
+	if n < 0 {
+		n = 0
+	}
+	c.jsonIndent = n
+	return c
+}
+
+// SortKeys sorts object keys of JSON bodies in curl logging
+func (c *Request) SortKeys() *Request {
+	c.sortKeys = true
+	return c
+}
+
+// Color colorizes JSON bodies in curl logging, when it goes to the standard
+// logger and its writer is a terminal.  The output of a Logger is unknown,
+// so it is never colorized.
+func (c *Request) Color() *Request {
+	c.color = true
+	return c
+}
+
+// formatJSON pretty prints a JSON body according to the logging options
+func (c *Request) formatJSON(b []byte) ([]byte, error) {
+	if c.sortKeys {
+		var err error
+		if b, err = sortJSON(b); err != nil {
+			return nil, err
+		}
+	}
+
+	out, err := indentJSON(b, strings.Repeat(" ", c.jsonIndent))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.color && c.log == nil && fsu.IsTerminal(log.Writer()) {
+		out = colorJSON(out)
+	}
+
+	return out, nil
+}
+
+// sortJSON re-encodes b, which sorts the keys of all objects.  Numbers are
+// kept as written and HTML characters are not escaped.
+func sortJSON(b []byte) ([]byte, error) {
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	e := json.NewEncoder(&out)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimRight(out.Bytes(), "\n"), nil
+}
+
+// colorJSON wraps the keys and values of valid JSON in ANSI colors
+func colorJSON(b []byte) []byte {
+	var out bytes.Buffer
+	for i := 0; i < len(b); {
+		ch := b[i]
+		switch {
+		case ch == '"':
+			j := i + 1
+			for j < len(b) && b[j] != '"' {
+				if b[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < len(b) {
+				j++
+			}
+
+			color := colorString
+			if k := skipSpace(b, j); k < len(b) && b[k] == ':' {
+				color = colorKey
+			}
+			writeColor(&out, color, b[i:j])
+			i = j
+		case ch == '-' || (ch >= '0' && ch <= '9'):
+			j := i + 1
+			for j < len(b) && strings.IndexByte("0123456789.eE+-", b[j]) >= 0 {
+				j++
+			}
+			writeColor(&out, colorNumber, b[i:j])
+			i = j
+		case bytes.HasPrefix(b[i:], []byte("true")):
+			writeColor(&out, colorBool, b[i:i+4])
+			i += 4
+		case bytes.HasPrefix(b[i:], []byte("false")):
+			writeColor(&out, colorBool, b[i:i+5])
+			i += 5
+		case bytes.HasPrefix(b[i:], []byte("null")):
+			writeColor(&out, colorNull, b[i:i+4])
+			i += 4
+		default:
+			out.WriteByte(ch)
+			i++
+		}
+	}
+
+	return out.Bytes()
+}
+
+func writeColor(out *bytes.Buffer, color string, b []byte) {
+	out.WriteString(color)
+	out.Write(b)
+	out.WriteString(colorReset)
+}
+
+func skipSpace(b []byte, i int) int {
+	for i < len(b) && (b[i] == ' ' || b[i] == '\t' || b[i] == '\n' || b[i] == '\r') {
+		i++
+	}
+	return i
+}
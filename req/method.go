@@ -0,0 +1,106 @@
+package req
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidMethod is returned by Do when the method is not a valid HTTP token.
+var ErrInvalidMethod = errors.New("invalid HTTP method")
+
+// Method is an HTTP request method.  Besides the standard verbs, any valid
+// token may be used, e.g. the WebDAV and CDN extensions below.
+type Method string
+
+// Standard HTTP methods
+const (
+	MethodGet     Method = http.MethodGet
+	MethodHead    Method = http.MethodHead
+	MethodPost    Method = http.MethodPost
+	MethodPut     Method = http.MethodPut
+	MethodPatch   Method = http.MethodPatch
+	MethodDelete  Method = http.MethodDelete
+	MethodOptions Method = http.MethodOptions
+	MethodTrace   Method = http.MethodTrace
+)
+
+// WebDAV (RFC 4918, RFC 3253) and CDN cache methods
+const (
+	MethodPropfind  Method = "PROPFIND"
+	MethodProppatch Method = "PROPPATCH"
+	MethodMkcol     Method = "MKCOL"
+	MethodCopy      Method = "COPY"
+	MethodMove      Method = "MOVE"
+	MethodLock      Method = "LOCK"
+	MethodUnlock    Method = "UNLOCK"
+	MethodReport    Method = "REPORT"
+	MethodPurge     Method = "PURGE"
+)
+
+// tchar from RFC 7230, minus ALPHA and DIGIT
+const methodSymbols = "!#$%&'*+-.^_`|~"
+
+// Valid returns TRUE if the method is a non-empty RFC 7230 token
+func (m Method) Valid() bool {
+	if m == "" {
+		return false
+	}
+
+	for _, r := range m {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune(methodSymbols, r):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// String implements the Stringer interface
+func (m Method) String() string {
+	return string(m)
+}
+
+// ContentType sets the Content-Type of request bodies sent with Do.
+func ContentType(contentType string) RequestFunc {
+	return func(c *Request) {
+		c.contentType = contentType
+	}
+}
+
+// Range requests the body from offset on, e.g. to resume a download.
+// Servers supporting ranges answer 206 Partial Content.
+func Range(offset int64) RequestFunc {
+	return func(c *Request) {
+		c.header = cloneHeader(c.header)
+		c.header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+}
+
+// Do performs a request with any method, for verbs without a helper of their
+// own.  Options only apply to this call, e.g.
+//
+//	r.Do(req.MethodPropfind, url, body, req.ContentType("application/xml"))
+func (c *Request) Do(method Method, url string, body io.Reader, opts ...RequestFunc) (*http.Response, error) {
+	return c.DoCtx(context.Background(), method, url, body, opts...)
+}
+
+// DoCtx is like Do, with a context to cancel the request.
+func (c *Request) DoCtx(ctx context.Context, method Method, url string, body io.Reader, opts ...RequestFunc) (*http.Response, error) {
+	if !method.Valid() {
+		return nil, ErrInvalidMethod
+	}
+
+	r := *c
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	return r.request(ctx, method.String(), url, "", body)
+}
@@ -0,0 +1,97 @@
+package req_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/req"
+)
+
+func TestDo(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Content-Type", r.Header.Get("Content-Type"))
+		w.Write([]byte(r.Method + " " + string(body)))
+	}))
+	defer s.Close()
+
+	r := req.New()
+	resp, err := r.Do(req.MethodPropfind, s.URL, strings.NewReader("<propfind/>"), req.ContentType("application/xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if b, _ := io.ReadAll(resp.Body); string(b) != "PROPFIND <propfind/>" {
+		t.Errorf("Expected the method and body, received %q", b)
+	}
+	if ct := resp.Header.Get("X-Content-Type"); ct != "application/xml" {
+		t.Errorf("Expected the content type, received %q", ct)
+	}
+
+	// options only apply to the call
+	resp, err = r.Do(req.MethodPost, s.URL, strings.NewReader("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if ct := resp.Header.Get("X-Content-Type"); ct != "" {
+		t.Errorf("Expected no content type, received %q", ct)
+	}
+
+	for _, m := range []req.Method{"", "GET /", "BAD\n"} {
+		if _, err := r.Do(m, s.URL, nil); !errors.Is(err, req.ErrInvalidMethod) {
+			t.Errorf("%q: expected ErrInvalidMethod, received %v", m, err)
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	content := []byte("0123456789")
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(content))
+	}))
+	defer s.Close()
+
+	tests := []struct {
+		offset int64
+		status int
+		body   string
+		err    int // status of an HTTPError
+	}{
+		{0, http.StatusPartialContent, "0123456789", 0},
+		{4, http.StatusPartialContent, "456789", 0},
+		{10, 0, "", http.StatusRequestedRangeNotSatisfiable},
+	}
+
+	for _, tt := range tests {
+		resp, err := req.New().Do(req.MethodGet, s.URL, nil, req.Range(tt.offset))
+
+		var httpErr req.HTTPError
+		if tt.err != 0 {
+			if !errors.As(err, &httpErr) || httpErr.StatusCode != tt.err || httpErr.Header.Get("Content-Range") != "bytes */10" {
+				t.Errorf("%d: expected a %d with the size, received %v", tt.offset, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status || string(b) != tt.body {
+			t.Errorf("%d: expected %d %q, received %d %q", tt.offset, tt.status, tt.body, resp.StatusCode, b)
+		}
+		if want := fmt.Sprintf("bytes %d-9/10", tt.offset); resp.Header.Get("Content-Range") != want {
+			t.Errorf("%d: expected Content-Range %q, received %q", tt.offset, want, resp.Header.Get("Content-Range"))
+		}
+	}
+}
@@ -2,17 +2,29 @@ package req
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"log/slog"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/sspencer/goal/backoff"
+	"github.com/sspencer/goal/bufpool"
+	"github.com/sspencer/goal/ctxutil"
+	"github.com/sspencer/goal/errorsx"
+	"github.com/sspencer/goal/flight"
+	"github.com/sspencer/goal/logx"
+	"github.com/sspencer/goal/metrics"
+	"github.com/sspencer/goal/secrets"
 )
 
 const (
@@ -24,9 +36,10 @@ const (
 )
 
 // From a TJ Holowaychuk tweet:
-//     TIL Go json syntax errors give you the offset, so you
-//     can provide more context if you want, the single char
-//     gets a little confusing
+//
+//	TIL Go json syntax errors give you the offset, so you
+//	can provide more context if you want, the single char
+//	gets a little confusing
 //
 // see SyntaxError and Unmarshall below
 type SyntaxError struct {
@@ -34,32 +47,69 @@ type SyntaxError struct {
 	input []byte
 }
 
+// EmptyBody is returned by Unmarshal when there is nothing to decode,
+// e.g. the body of a 204 No Content response.
+type EmptyBody struct{}
+
+// HTTPError is returned when a request completes with a non 2XX status.
+type HTTPError struct {
+	StatusCode int
+	Body       []byte
+	Header     http.Header // of the response, e.g. Content-Range for a 416
+}
+
 // RequestFunc allows variable numbers of args in New to configure requests.
 // For example:
-//   r0 := req.New()
-//   r1 := req.New().Curl()        // enable curl logging
-//   r2 := req.New().CurlHeader()  // enable curl + headers logging
+//
+//	r0 := req.New()
+//	r1 := req.New().Curl()        // enable curl logging
+//	r2 := req.New().CurlHeader()  // enable curl + headers logging
 type RequestFunc func(*Request)
 
 // Request is used to set some configuration options on the HTTP request.
 type Request struct {
 	curl          bool
 	curlHeader    bool
-	timeout       time.Duration
+	client        *http.Client
 	skipRedirects bool
+	robots        *robots
+	contentType   string
+	tee           io.Writer
+	jsonIndent    int
+	sortKeys      bool
+	color         bool
+	tokens        TokenSource
+	header        http.Header
+	log           logx.Logger
+	metrics       *metrics.Registry
+	retries       int
+	backoff       backoff.Strategy
+	shouldRetry   func(*http.Response, error) bool
+	flights       *flight.Group[string, *sharedResponse]
 }
 
-// New creates a new Request struct.  Defaults are:
-//   curl (body): false
-//   curl header (and body): false
-//   timeout: 30 seconds
-//   skip redirects: false
-func New() *Request {
+// New creates a new Request struct, configured by opts.  Defaults are:
+//
+//	curl (body): false
+//	curl header (and body): false
+//	timeout: 30 seconds
+//	skip redirects: false
+//	JSON indent: 3 spaces
+//	retries: none
+//
+// Requests share the http.Client created here, and its connections, with
+// the copies made by With and Do.
+func New(opts ...RequestFunc) *Request {
 	r := &Request{}
 	r.curl = false
 	r.curlHeader = false
-	r.timeout = 30 * time.Second
+	r.client = &http.Client{Timeout: 30 * time.Second}
 	r.skipRedirects = false
+	r.jsonIndent = 3
+
+	for _, opt := range opts {
+		opt(r)
+	}
 
 	return r
 }
@@ -77,15 +127,37 @@ func (c *Request) CurlHeader() *Request {
 	return c
 }
 
+// Logger sends curl logging to l at info level, rather than the standard
+// logger
+func (c *Request) Logger(l logx.Logger) *Request {
+	c.log = l
+	return c
+}
+
+// Metrics counts requests in reg, as req_requests_total by method, host and
+// status code ("error" when there is no response), and times them as
+// req_request_seconds by method and host
+func (c *Request) Metrics(reg *metrics.Registry) *Request {
+	c.metrics = reg
+	return c
+}
+
 // Timeout changes the default request timeout (30 seconds)
 func (c *Request) Timeout(d time.Duration) *Request {
-	c.timeout = d
+	c.configure(func(client *http.Client) {
+		client.Timeout = d
+	})
 	return c
 }
 
 // SkipRedirects enables the flag to skip redirects
 func (c *Request) SkipRedirects() *Request {
 	c.skipRedirects = true
+	c.configure(func(client *http.Client) {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return errors.New("Skip redirects")
+		}
+	})
 	return c
 }
 
@@ -99,18 +171,58 @@ func (e SyntaxError) Error() string {
 	return fmt.Sprintf("syntax error near: `%s`", string(e.input[e.Offset-1:]))
 }
 
-// Unmarshal unmarshals a successful http response (and closes it)
+// Error implements the Error method for HTTPErrors
+func (e HTTPError) Error() string {
+	return fmt.Sprintf("Error making HTTP request.  HTTP Status %d: %v", e.StatusCode, string(e.Body))
+}
+
+// Code returns the errorsx code of the status of e.
+func (e HTTPError) Code() errorsx.Code {
+	return errorsx.FromStatus(e.StatusCode)
+}
+
+// Is matches the errorsx code of e, e.g. errors.Is(err, errorsx.NotFound).
+func (e HTTPError) Is(target error) bool {
+	code, ok := target.(errorsx.Code)
+	return ok && code == e.Code()
+}
+
+// Error implements the Error method for EmptyBody
+func (e EmptyBody) Error() string {
+	return "empty response body"
+}
+
+// Unmarshal unmarshals a successful http response (and closes it).  An
+// empty body returns EmptyBody.
 func Unmarshal(body io.ReadCloser, v interface{}) error {
 	defer body.Close()
-	data, err := ioutil.ReadAll(body)
-	if err != nil {
+	buf := bufpool.Get()
+	defer bufpool.Put(buf)
+
+	if _, err := buf.ReadFrom(body); err != nil {
 		return err
 	}
 
-	err = json.Unmarshal(data, &v)
+	data := buf.Bytes()
+	if len(bytes.TrimSpace(data)) == 0 {
+		return EmptyBody{}
+	}
+
+	err := json.Unmarshal(data, &v)
 
 	if e, ok := err.(*json.SyntaxError); ok {
-		return SyntaxError{e, data}
+		return SyntaxError{e, bytes.Clone(data)} // data goes back to the pool
+	}
+
+	return err
+}
+
+// UnmarshalAllowEmpty is like Unmarshal, but an empty body is not an
+// error and leaves v untouched.
+func UnmarshalAllowEmpty(body io.ReadCloser, v interface{}) error {
+	err := Unmarshal(body, v)
+	if errors.As(err, &EmptyBody{}) {
+		return nil
 	}
 
 	return err
@@ -118,134 +230,235 @@ func Unmarshal(body io.ReadCloser, v interface{}) error {
 
 // Get performs a HTTP GET
 func (c *Request) Get(url string) (*http.Response, error) {
-	return c.request(http.MethodGet, url, "", nil)
+	return c.GetCtx(context.Background(), url)
 }
 
 // Get performs a HTTP HEAD
 func (c *Request) Head(url string) (*http.Response, error) {
-	return c.request(http.MethodHead, url, "", nil)
+	return c.HeadCtx(context.Background(), url)
+}
+
+// Ping GETs url, discarding the body, and returns an error unless the
+// status is 2XX, e.g. to check an upstream service is healthy
+func (c *Request) Ping(url string) error {
+	return c.PingCtx(context.Background(), url)
 }
 
 // Get performs a HTTP DELETE
 func (c *Request) Delete(url string) (*http.Response, error) {
-	return c.request(http.MethodDelete, url, "", nil)
+	return c.DeleteCtx(context.Background(), url)
 }
 
-// Get performs a HTTP POST
+// Post performs a HTTP POST of url-encoded form values
 func (c *Request) Post(url string, values url.Values) (*http.Response, error) {
-	return c.request(http.MethodPost, url, URLEncodededContentType, strings.NewReader(values.Encode()))
+	return c.PostCtx(context.Background(), url, values)
 }
 
-// Get performs a HTTP PUT
+// Put performs a HTTP PUT of url-encoded form values
 func (c *Request) Put(url string, values url.Values) (*http.Response, error) {
-	return c.request(http.MethodPost, url, URLEncodededContentType, strings.NewReader(values.Encode()))
+	return c.PutCtx(context.Background(), url, values)
 }
 
-// request does all the work of the above HTTP method functions
-func (c *Request) request(method, url, contentType string, data io.Reader) (*http.Response, error) {
+// Patch performs a HTTP PATCH of url-encoded form values
+func (c *Request) Patch(url string, values url.Values) (*http.Response, error) {
+	return c.PatchCtx(context.Background(), url, values)
+}
 
-	var buf bytes.Buffer
-	var err error
-	var req *http.Request
+// PostJSON performs a HTTP POST of v encoded as JSON ([]byte and
+// json.RawMessage are sent as is)
+func (c *Request) PostJSON(url string, v interface{}) (*http.Response, error) {
+	return c.PostJSONCtx(context.Background(), url, v)
+}
 
-	if data != nil {
-		tee := io.TeeReader(data, &buf) // TeeRequest for curl output
-		req, err = http.NewRequest(method, url, tee)
-	} else {
-		req, err = http.NewRequest(method, url, nil)
-	}
+// PutJSON performs a HTTP PUT of v encoded as JSON
+func (c *Request) PutJSON(url string, v interface{}) (*http.Response, error) {
+	return c.PutJSONCtx(context.Background(), url, v)
+}
+
+// PatchJSON performs a HTTP PATCH of v encoded as JSON
+func (c *Request) PatchJSON(url string, v interface{}) (*http.Response, error) {
+	return c.PatchJSONCtx(context.Background(), url, v)
+}
 
+// requestJSON sends v as a JSON body
+func (c *Request) requestJSON(ctx context.Context, method, url string, v interface{}) (*http.Response, error) {
+	body, err := encodeJSON(v)
 	if err != nil {
 		return nil, err
 	}
 
-	if contentType != "" {
-		req.Header.Set("Content-Type", contentType)
+	return c.request(ctx, method, url, JSONContentType, bytes.NewReader(body))
+}
+
+// encodeJSON marshals v, unless it is JSON already
+func encodeJSON(v interface{}) ([]byte, error) {
+	switch b := v.(type) {
+	case []byte:
+		return b, nil
+	case json.RawMessage:
+		return b, nil
+	default:
+		return json.Marshal(v)
 	}
+}
 
-	client := &http.Client{Timeout: c.timeout}
-	if c.skipRedirects {
-		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			return errors.New("Skip redirects")
+// request does all the work of the above HTTP method functions.  The
+// client Timeout applies along with the deadline of ctx, if any.
+func (c *Request) request(ctx context.Context, method, url, contentType string, data io.Reader) (*http.Response, error) {
+
+	// the body is read once, to be sent again by retries and redirects,
+	// and logged
+	var body []byte
+	if data != nil {
+		var err error
+		if body, err = ioutil.ReadAll(data); err != nil {
+			return nil, err
 		}
 	}
 
-	resp, err := client.Do(req)
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	// TeeBuffer is empty until request is sent. Data is copied to writer as it is read.
-	if c.curl || c.curlHeader {
-		c.logger(req, resp, &buf)
+	if c.robots != nil {
+		if err := c.robots.check(req, c.client, c.log); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, values := range c.header {
+		req.Header[name] = values
+	}
+
+	// robots.txt groups are selected by the agent, so servers must see it
+	if c.robots != nil && c.robots.agent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.robots.agent)
+	}
+
+	// forward the ID of the request being served, if any
+	if rid := ctxutil.RequestID(ctx); rid != "" && req.Header.Get(ctxutil.RequestIDHeader) == "" {
+		req.Header.Set(ctxutil.RequestIDHeader, rid)
+	}
+
+	if c.tokens != nil {
+		token, err := c.tokens.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	if contentType == "" {
+		contentType = c.contentType
+	}
+
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.dedupe(req, body)
+	if err != nil {
+		return nil, err
 	}
 
 	if resp.StatusCode >= http.StatusOK && resp.StatusCode <= http.StatusIMUsed {
+		if c.tee != nil {
+			TeeBody(resp, c.tee)
+		}
 		return resp, nil
 	}
 
 	// NOT OK - return error body
 	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
-	return nil, fmt.Errorf("Error making HTTP request.  HTTP Status %d: %v", resp.StatusCode, string(body))
+	return nil, HTTPError{StatusCode: resp.StatusCode, Body: respBody, Header: resp.Header}
 }
 
-func (c *Request) logger(r *http.Request, resp *http.Response, data io.Reader) {
-	if !c.curl && !c.curlHeader {
+// attempt sends a copy of req with body once
+func (c *Request) attempt(req *http.Request, body []byte) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	if body != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		r.ContentLength = int64(len(body))
+	}
+
+	start := time.Now()
+	resp, err := c.client.Do(r)
+	c.observe(r, resp, time.Since(start))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.curl || c.curlHeader {
+		c.logger(r, resp, body)
+	}
+
+	return resp, nil
+}
+
+// observe records a request in the Metrics registry, if any
+func (c *Request) observe(r *http.Request, resp *http.Response, d time.Duration) {
+	if c.metrics == nil {
 		return
 	}
 
-	curlIndent := strings.Repeat(" ", 4)
-	jsonIndent := strings.Repeat(" ", 3)
-	
-	// -s silences output (progress meter and errors)
-	// -S "unsilences" errors
-	buf := bytes.NewBufferString("\ncurl -sS")
+	code := "error"
+	if resp != nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
 
-	if c.skipRedirects {
-		buf.WriteString(" -L")
+	c.metrics.Counter("req_requests_total", "HTTP requests sent.", "method", r.Method, "host", r.URL.Host, "code", code).Inc()
+	c.metrics.Timer("req_request_seconds", "Time until the response headers.", "method", r.Method, "host", r.URL.Host).Observe(d)
+}
+
+func (c *Request) logger(r *http.Request, resp *http.Response, body []byte) {
+	if !c.curl && !c.curlHeader {
+		return
 	}
 
-	buf.WriteString(" -X")
-	buf.WriteString(r.Method) // GET, POST, PUT, etc.
-	buf.WriteString(" \\\n")
+	out := "\n" + c.Format(r, body, resp)
 
-	for n, v := range r.Header {
-		buf.WriteString(curlIndent)
-		buf.WriteString("-H'")
-		buf.WriteString(n)
-		buf.WriteString(": ")
-		buf.WriteString(v[0])
-		buf.WriteString("' \\\n")
+	// are we just logging this ?
+	if c.log != nil {
+		c.log.Log(r.Context(), slog.LevelInfo, out)
+		return
 	}
+	log.Println(out)
+}
 
-	if data != nil {
-		b, err := ioutil.ReadAll(data)
-		if err == nil {
-			str := string(b)
-			if str != "" {
-				buf.WriteString(curlIndent)
-				buf.WriteString("-d'")
-				buf.WriteString(strings.TrimSpace(str))
-				buf.WriteString("' \\\n")
-			}
-		}
+// Format returns the curl logging of an exchange: a curl command repeating
+// r with body, then resp, with its JSON body formatted by the JSONIndent,
+// SortKeys and Color options.  Response headers are included with
+// CurlHeader.  Credentials in headers are masked.  The body of resp is
+// read, and replaced so it can be read again.
+func (c *Request) Format(r *http.Request, body []byte, resp *http.Response) string {
+	flags := ""
+	if c.skipRedirects {
+		flags = " -L"
 	}
 
-	// curl -XGET ...
-	//     "<THE URL>"   <--
-	buf.WriteString(curlIndent)
-	buf.WriteString("\"")
-	buf.WriteString(r.URL.String())
-	buf.WriteString("\"")
+	// credentials are masked, so logs can be shared
+	redacted := *r
+	redacted.Header = secrets.RedactHeader(r.Header)
+	buf := bufpool.Get()
+	defer bufpool.Put(buf)
+	buf.WriteString(curlCommand(&redacted, body, flags))
 
 	// that's it for the actual curl command,
 	// now log the response
-	if dump, err := httputil.DumpResponse(resp, true); err == nil {
+	dumped := *resp
+	dumped.Header = secrets.RedactHeader(resp.Header)
+	dump, err := httputil.DumpResponse(&dumped, true)
+	resp.Body = dumped.Body
+	if err == nil {
 		// split header from body
 		parts := bytes.SplitN(dump, []byte("\r\n\r\n"), 2)
 
@@ -264,7 +477,7 @@ func (c *Request) logger(r *http.Request, resp *http.Response, data io.Reader) {
 				buf.WriteString("\n")
 			}
 
-			if json, err := indentJSON(body, jsonIndent); err != nil {
+			if json, err := c.formatJSON(body); err != nil {
 				buf.WriteString(string(body))
 			} else {
 				buf.WriteString(string(json))
@@ -273,8 +486,53 @@ func (c *Request) logger(r *http.Request, resp *http.Response, data io.Reader) {
 		}
 	}
 
-	// are we just logging this ?
-	log.Println(buf.String())
+	return buf.String()
+}
+
+// CurlCommand returns a curl command line repeating the request r, with
+// the given body, e.g. to log the requests received by a server.  Only the
+// first value of every header is included.
+func CurlCommand(r *http.Request, body []byte) string {
+	return curlCommand(r, body, "")
+}
+
+// curlCommand formats the curl command line of r, with extra curl flags
+func curlCommand(r *http.Request, body []byte, flags string) string {
+	curlIndent := strings.Repeat(" ", 4)
+
+	// -s silences output (progress meter and errors)
+	// -S "unsilences" errors
+	buf := bytes.NewBufferString("curl -sS")
+	buf.WriteString(flags)
+
+	buf.WriteString(" -X")
+	buf.WriteString(r.Method) // GET, POST, PUT, etc.
+	buf.WriteString(" \\\n")
+
+	for n, v := range r.Header {
+		buf.WriteString(curlIndent)
+		buf.WriteString("-H'")
+		buf.WriteString(n)
+		buf.WriteString(": ")
+		buf.WriteString(v[0])
+		buf.WriteString("' \\\n")
+	}
+
+	if str := string(body); str != "" {
+		buf.WriteString(curlIndent)
+		buf.WriteString("-d'")
+		buf.WriteString(strings.TrimSpace(str))
+		buf.WriteString("' \\\n")
+	}
+
+	// curl -XGET ...
+	//     "<THE URL>"   <--
+	buf.WriteString(curlIndent)
+	buf.WriteString("\"")
+	buf.WriteString(r.URL.String())
+	buf.WriteString("\"")
+
+	return buf.String()
 }
 
 func indentJSON(b []byte, jsonIndent string) ([]byte, error) {
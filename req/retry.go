@@ -0,0 +1,156 @@
+package req
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sspencer/goal/backoff"
+	"github.com/sspencer/goal/retry"
+)
+
+const (
+	// retryBase and retryLimit bound the default backoff between retries
+	retryBase  = 100 * time.Millisecond
+	retryLimit = 10 * time.Second
+
+	// maxRetryAfter caps the waits asked for by Retry-After headers
+	maxRetryAfter = time.Minute
+)
+
+// Retries retries failed requests up to n times.  By default, only
+// idempotent requests (GET, HEAD, OPTIONS, TRACE, PUT and DELETE) are
+// retried, after connection errors, 429 and 5XX responses, e.g.
+//
+//	r := req.New(req.Retries(3))
+//
+// Waits follow the Backoff option, unless a Retry-After header asks for
+// longer (up to a minute).  Bodies are sent again as they were.
+func Retries(n int) RequestFunc {
+	return func(c *Request) {
+		c.retries = n
+	}
+}
+
+// Backoff sets the waits between retries, exponential with jitter between
+// 100ms and 10s by default (see backoff.DecorrelatedJitter)
+func Backoff(s backoff.Strategy) RequestFunc {
+	return func(c *Request) {
+		c.backoff = s
+	}
+}
+
+// ShouldRetry replaces the default decision to retry a request, given its
+// response, or the error when there is none.  It applies to every method.
+func ShouldRetry(fn func(resp *http.Response, err error) bool) RequestFunc {
+	return func(c *Request) {
+		c.shouldRetry = fn
+	}
+}
+
+// retryStatus is the error of responses to retry
+type retryStatus struct {
+	code int
+}
+
+func (e *retryStatus) Error() string {
+	return fmt.Sprintf("HTTP status %d", e.code)
+}
+
+// send sends req, retrying according to the Retries option.  The response
+// to the last attempt is returned, even with a status worth retrying.
+func (c *Request) send(req *http.Request, body []byte) (*http.Response, error) {
+	if c.retries <= 0 {
+		return c.attempt(req, body)
+	}
+
+	strategy := c.backoff
+	if strategy == nil {
+		strategy = backoff.DecorrelatedJitter(retryBase, retryLimit)
+	}
+
+	var last *http.Response
+	policy := retry.Policy{
+		MaxAttempts: c.retries + 1,
+		Backoff:     strategy,
+		OnRetry: func(int, error, time.Duration) {
+			if last != nil {
+				io.Copy(io.Discard, last.Body)
+				last.Body.Close()
+				last = nil
+			}
+		},
+	}
+
+	resp, err := retry.DoValue(req.Context(), policy, func() (*http.Response, error) {
+		resp, err := c.attempt(req, body)
+		last = resp
+
+		if !c.retryable(req, resp, err) {
+			return resp, retry.Permanent(err)
+		}
+		if err == nil {
+			err = &retryStatus{resp.StatusCode}
+		}
+		return resp, retry.After(err, retryAfter(resp))
+	})
+
+	if err != nil && req.Context().Err() != nil {
+		return nil, err // canceled while waiting to retry
+	}
+
+	var rs *retryStatus
+	if errors.As(err, &rs) {
+		return resp, nil
+	}
+
+	return resp, err
+}
+
+// retryable decides whether to retry after the response or error
+func (c *Request) retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	if c.shouldRetry != nil {
+		return c.shouldRetry(resp, err)
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// retryAfter returns the wait asked for by the Retry-After header of resp,
+// in seconds or as a date, 0 when there is none
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+
+	var d time.Duration
+	if secs, err := strconv.Atoi(v); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(v); err == nil {
+		d = time.Until(t)
+	}
+
+	return max(min(d, maxRetryAfter), 0)
+}
@@ -0,0 +1,134 @@
+package req_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/backoff"
+	"github.com/sspencer/goal/req"
+)
+
+// failing serves the status and headers of fail to the first request, and
+// 200 to the next
+func failing(fail int, header http.Header, calls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(fail)
+		}
+	}))
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		header  func() http.Header
+		minWait time.Duration
+	}{
+		{"unavailable", http.StatusServiceUnavailable, nil, 0},
+		{"too many requests", http.StatusTooManyRequests, nil, 0},
+		{"retry after seconds", http.StatusServiceUnavailable, func() http.Header {
+			return http.Header{"Retry-After": {"1"}}
+		}, time.Second},
+		// dates are to the second: 2s after the current second is over a second away
+		{"retry after date", http.StatusServiceUnavailable, func() http.Header {
+			date := time.Now().Truncate(time.Second).Add(2 * time.Second)
+			return http.Header{"Retry-After": {date.UTC().Format(http.TimeFormat)}}
+		}, 900 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header http.Header
+			if tt.header != nil {
+				header = tt.header()
+			}
+
+			var calls atomic.Int32
+			start := time.Now()
+			s := failing(tt.status, header, &calls)
+			defer s.Close()
+
+			r := req.New(req.Retries(2), req.Backoff(backoff.Constant(time.Millisecond)))
+			resp, err := r.Get(s.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK || calls.Load() != 2 {
+				t.Errorf("Expected 200 on the second attempt, received %d after %d", resp.StatusCode, calls.Load())
+			}
+			if elapsed := time.Since(start); elapsed < tt.minWait {
+				t.Errorf("Expected to wait at least %s, waited %s", tt.minWait, elapsed)
+			}
+		})
+	}
+}
+
+func TestRetriesGiveUp(t *testing.T) {
+	var calls atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer s.Close()
+
+	r := req.New(req.Retries(2), req.Backoff(backoff.Constant(time.Millisecond)))
+	_, err := r.Get(s.URL)
+	var httpErr req.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadGateway || calls.Load() != 3 {
+		t.Errorf("Expected the last 502 after 3 attempts, received %v after %d", err, calls.Load())
+	}
+
+	// POST isn't idempotent
+	calls.Store(0)
+	if _, err := r.Post(s.URL, nil); err == nil || calls.Load() != 1 {
+		t.Errorf("Expected a single failed POST, received %v after %d", err, calls.Load())
+	}
+}
+
+func TestRetriesBody(t *testing.T) {
+	var calls atomic.Int32
+	var bodies [2]string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		if n <= 2 {
+			bodies[n-1] = string(body)
+		}
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer s.Close()
+
+	r := req.New(
+		req.Retries(1),
+		req.Backoff(backoff.Constant(time.Millisecond)),
+		req.ShouldRetry(func(resp *http.Response, err error) bool {
+			return err != nil || resp.StatusCode >= http.StatusInternalServerError
+		}),
+	)
+	resp, err := r.PostJSON(s.URL, map[string]string{"name": "gopher"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || calls.Load() != 2 {
+		t.Fatalf("Expected 200 on the second attempt, received %d after %d", resp.StatusCode, calls.Load())
+	}
+	if !strings.Contains(bodies[0], "gopher") || bodies[1] != bodies[0] {
+		t.Errorf("Expected the body to be sent again, received %q then %q", bodies[0], bodies[1])
+	}
+}
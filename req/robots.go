@@ -0,0 +1,345 @@
+package req
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sspencer/goal/logx"
+)
+
+// ErrRobotsDisallowed is returned when robots.txt disallows the requested path.
+var ErrRobotsDisallowed = errors.New("disallowed by robots.txt")
+
+const (
+	// robots.txt files larger than this are truncated (RFC 9309 asks for at least 500 KiB)
+	maxRobotsSize = 500 << 10
+
+	// robots.txt is fetched again after robotsTTL (RFC 9309 asks not to cache
+	// it longer than a day), or after robotsRetry when it couldn't be fetched
+	robotsTTL   = 24 * time.Hour
+	robotsRetry = time.Minute
+
+	// maxCrawlDelay caps the Crawl-delay of robots.txt files
+	maxCrawlDelay = 10 * time.Second
+)
+
+// robots caches the parsed robots.txt of every host requested
+type robots struct {
+	agent string
+	warn  bool
+
+	mu    sync.Mutex
+	hosts map[string]*robotsHost
+}
+
+// robotsHost holds the rules that apply to our user agent for one host,
+// plus the time of the next request, to honor Crawl-delay.
+type robotsHost struct {
+	mu      sync.Mutex // held while loading robots.txt
+	rules   []robotsRule
+	delay   time.Duration
+	expires time.Time // zero until loaded
+	next    time.Time
+}
+
+type robotsRule struct {
+	allow bool
+	path  string
+}
+
+// robotsGroup is a set of user-agent lines followed by their rules
+type robotsGroup struct {
+	agents []string
+	rules  []robotsRule
+	delay  time.Duration
+}
+
+// Robots enables robots.txt awareness.  The robots.txt of every host is
+// fetched once a day and cached; requests to paths disallowed for the user agent
+// (or "*" when agent is empty) fail with ErrRobotsDisallowed, and requests to
+// the same host are spaced by its Crawl-delay (up to 10s).  The agent is
+// sent as the User-Agent of requests that don't set one.
+func (c *Request) Robots(agent string) *Request {
+	c.robots = &robots{agent: agent, hosts: make(map[string]*robotsHost)}
+	return c
+}
+
+// RobotsWarn is like Robots, but disallowed paths are only logged, not refused.
+func (c *Request) RobotsWarn(agent string) *Request {
+	c.Robots(agent)
+	c.robots.warn = true
+	return c
+}
+
+// check returns an error if the URL of req is disallowed, otherwise waits
+// out the crawl delay, or until req is canceled.  Disallowed URLs are
+// logged to l (or the standard logger) with RobotsWarn.
+func (r *robots) check(req *http.Request, client *http.Client, l logx.Logger) error {
+	u := req.URL
+	key := u.Scheme + "://" + u.Host
+
+	r.mu.Lock()
+	h, ok := r.hosts[key]
+	if !ok {
+		h = &robotsHost{}
+		r.hosts[key] = h
+	}
+	r.mu.Unlock()
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	h.mu.Lock()
+	if !time.Now().Before(h.expires) {
+		if err := h.load(req.Context(), key+"/robots.txt", r.agent, client); err != nil {
+			h.mu.Unlock()
+			return err
+		}
+	}
+	allowed := h.allowed(path)
+	if !allowed && !r.warn {
+		h.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrRobotsDisallowed, u)
+	}
+	at := h.reserve()
+	h.mu.Unlock()
+
+	if !allowed {
+		if l != nil {
+			l.Log(req.Context(), slog.LevelWarn, "robots.txt disallows", "url", u.String())
+		} else {
+			log.Printf("robots.txt disallows %s", u)
+		}
+	}
+
+	return sleepUntil(req.Context(), at)
+}
+
+// load fetches and parses robots.txt.  Missing files (4XX) allow everything,
+// while server errors and unreachable files disallow everything, as
+// recommended by RFC 9309, until they are fetched again a minute later.
+// Only the cancellation of ctx is returned, leaving the host to load again.
+func (h *robotsHost) load(ctx context.Context, robotsURL, agent string, client *http.Client) error {
+	h.rules, h.delay = []robotsRule{{allow: false, path: "/"}}, 0
+	h.expires = time.Now().Add(robotsRetry)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return nil
+	}
+	if agent != "" {
+		req.Header.Set("User-Agent", agent)
+	}
+
+	// robots.txt is fetched following redirects, as RFC 9309 requires
+	follow := *client
+	follow.CheckRedirect = nil
+
+	resp, err := follow.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			h.expires = time.Time{}
+			return context.Cause(ctx)
+		}
+		return nil
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case IsSuccess(resp.StatusCode):
+		h.rules, h.delay = selectRobotsGroup(parseRobots(io.LimitReader(resp.Body, maxRobotsSize)), agent)
+		h.delay = min(h.delay, maxCrawlDelay)
+	case resp.StatusCode < http.StatusInternalServerError:
+		h.rules = nil
+	default:
+		return nil
+	}
+
+	h.expires = time.Now().Add(robotsTTL)
+	return nil
+}
+
+// allowed applies the longest matching rule, with Allow winning ties
+func (h *robotsHost) allowed(path string) bool {
+	best, allow := -1, true
+	for _, r := range h.rules {
+		n := len(r.path)
+		if n < best || (n == best && !r.allow) {
+			continue
+		}
+
+		if robotsMatch(r.path, path) {
+			best, allow = n, r.allow
+		}
+	}
+
+	return allow
+}
+
+// reserve returns the time of the next request to the host, a crawl delay
+// after the one before
+func (h *robotsHost) reserve() time.Time {
+	at := time.Now()
+	if h.next.After(at) {
+		at = h.next
+	}
+	h.next = at.Add(h.delay)
+
+	return at
+}
+
+// sleepUntil sleeps until at, or until ctx is done
+func sleepUntil(ctx context.Context, at time.Time) error {
+	d := time.Until(at)
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// parseRobots splits a robots.txt file into its user agent groups
+func parseRobots(r io.Reader) []*robotsGroup {
+	var groups []*robotsGroup
+	var g *robotsGroup
+	inRules := false
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(line[:i]))
+		val := strings.TrimSpace(line[i+1:])
+
+		if key == "user-agent" {
+			if g == nil || inRules {
+				g = &robotsGroup{}
+				groups = append(groups, g)
+				inRules = false
+			}
+			g.agents = append(g.agents, strings.ToLower(val))
+			continue
+		}
+
+		if g == nil {
+			continue
+		}
+
+		switch key {
+		case "allow", "disallow":
+			inRules = true
+			if val != "" { // an empty Disallow allows everything
+				g.rules = append(g.rules, robotsRule{allow: key == "allow", path: val})
+			}
+		case "crawl-delay":
+			inRules = true
+			if secs, err := strconv.ParseFloat(val, 64); err == nil && secs > 0 {
+				g.delay = time.Duration(secs * float64(time.Second))
+			}
+		}
+	}
+
+	return groups
+}
+
+// selectRobotsGroup merges the groups naming our agent, falling back to "*"
+func selectRobotsGroup(groups []*robotsGroup, agent string) ([]robotsRule, time.Duration) {
+	agent = strings.ToLower(agent)
+
+	var named, star []*robotsGroup
+	for _, g := range groups {
+		for _, a := range g.agents {
+			if a == "*" {
+				star = append(star, g)
+				break
+			}
+			if agent != "" && strings.Contains(agent, a) {
+				named = append(named, g)
+				break
+			}
+		}
+	}
+
+	if len(named) == 0 {
+		named = star
+	}
+
+	var rules []robotsRule
+	var delay time.Duration
+	for _, g := range named {
+		rules = append(rules, g.rules...)
+		if g.delay > delay {
+			delay = g.delay
+		}
+	}
+
+	return rules, delay
+}
+
+// robotsMatch matches a path against a rule, which may contain '*'
+// wildcards and be anchored to the end of the path with '$'.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	}
+
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		if anchored {
+			return path == pattern
+		}
+		return strings.HasPrefix(path, pattern)
+	}
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+
+	pos := len(parts[0])
+	for _, p := range parts[1 : len(parts)-1] {
+		i := strings.Index(path[pos:], p)
+		if i < 0 {
+			return false
+		}
+		pos += i + len(p)
+	}
+
+	last := parts[len(parts)-1]
+	if anchored {
+		return strings.HasSuffix(path, last) && len(path)-len(last) >= pos
+	}
+
+	return strings.Contains(path[pos:], last)
+}
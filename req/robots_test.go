@@ -0,0 +1,173 @@
+package req_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/logx"
+	"github.com/sspencer/goal/req"
+)
+
+const robotsTxt = `# everyone else is kept out
+User-agent: *
+Disallow: /
+
+User-agent: goalbot
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+Disallow: /tmp*/cache
+Allow: /docs/*.html$
+Disallow: /docs/
+`
+
+// robotsServer serves robots.txt with status, recording the User-Agent of
+// every request
+func robotsServer(t *testing.T, status int, body string) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	var agents []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.UserAgent())
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}
+	}))
+	t.Cleanup(s.Close)
+
+	return s, &agents
+}
+
+func TestRobots(t *testing.T) {
+	s, agents := robotsServer(t, http.StatusOK, robotsTxt)
+
+	tests := []struct {
+		agent   string
+		path    string
+		allowed bool
+	}{
+		{"goalbot/1.0", "/", true},
+		{"goalbot/1.0", "/private", false},
+		{"goalbot/1.0", "/private/keys", false},
+		{"goalbot/1.0", "/private/public/readme", true}, // longest rule wins
+		{"goalbot/1.0", "/report.pdf", false},
+		{"goalbot/1.0", "/report.pdf?page=2", true}, // $ anchors to the end
+		{"goalbot/1.0", "/tmp1/a/cache", false},
+		{"goalbot/1.0", "/tmp/cached", false},
+		{"goalbot/1.0", "/tmp1/a/other", true},
+		{"goalbot/1.0", "/docs/index.html", true},
+		{"goalbot/1.0", "/docs/index.htm", false},
+		{"GoalBot", "/private", false}, // agents match case insensitively
+		{"otherbot", "/", false},       // falls back to *
+		{"", "/anything", false},
+	}
+
+	for _, tt := range tests {
+		_, err := req.New().Robots(tt.agent).Get(s.URL + tt.path)
+		if allowed := !errors.Is(err, req.ErrRobotsDisallowed); allowed != tt.allowed {
+			t.Errorf("%s %s: expected allowed %t, received %v", tt.agent, tt.path, tt.allowed, err)
+		}
+	}
+
+	// robots.txt, then the page, of the first test
+	if (*agents)[0] != "goalbot/1.0" || (*agents)[1] != "goalbot/1.0" {
+		t.Errorf("Expected the agent on robots.txt and requests, received %v", (*agents)[:2])
+	}
+}
+
+func TestRobotsUnavailable(t *testing.T) {
+	missing, _ := robotsServer(t, http.StatusNotFound, "")
+	failing, _ := robotsServer(t, http.StatusInternalServerError, "")
+	unreachable, _ := robotsServer(t, http.StatusOK, "")
+	unreachable.Close()
+
+	tests := []struct {
+		name    string
+		url     string
+		allowed bool
+	}{
+		{"missing", missing.URL, true},
+		{"server error", failing.URL, false},
+		{"unreachable", unreachable.URL, false},
+	}
+
+	for _, tt := range tests {
+		_, err := req.New().Robots("goalbot").Get(tt.url + "/page")
+		if allowed := !errors.Is(err, req.ErrRobotsDisallowed); allowed != tt.allowed {
+			t.Errorf("%s: expected allowed %t, received %v", tt.name, tt.allowed, err)
+		}
+	}
+}
+
+func TestRobotsWarn(t *testing.T) {
+	s, _ := robotsServer(t, http.StatusOK, robotsTxt)
+
+	var l logx.TestLogger
+	if _, err := req.New().Logger(&l).RobotsWarn("goalbot").Get(s.URL + "/private"); err != nil {
+		t.Fatalf("Expected the request to be sent, received %v", err)
+	}
+
+	if found := l.Find("robots.txt disallows"); len(found) != 1 || found[0].Attrs["url"] != s.URL+"/private" {
+		t.Errorf("Expected a warning on the Logger, received %v", l.Entries())
+	}
+}
+
+func TestRobotsCrawlDelay(t *testing.T) {
+	s, _ := robotsServer(t, http.StatusOK, "User-agent: *\nCrawl-delay: 0.1\n")
+
+	r := req.New().Robots("goalbot")
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := r.Get(s.URL + "/page"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("Expected requests spaced by 100ms, took %v", elapsed)
+	}
+
+	// an hour is capped, and waits end with the request
+	s, _ = robotsServer(t, http.StatusOK, "User-agent: *\nCrawl-delay: 3600\n")
+	r = req.New().Robots("goalbot")
+	if _, err := r.Get(s.URL + "/page"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if _, err := r.GetCtx(ctx, s.URL+"/page"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the wait to be canceled, received %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the wait to end with the request, took %v", elapsed)
+	}
+}
+
+func TestRobotsCanceled(t *testing.T) {
+	var calls atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" && calls.Add(1) == 1 {
+			<-r.Context().Done() // until the client gives up
+		}
+	}))
+	defer s.Close()
+
+	r := req.New().Robots("goalbot")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := r.GetCtx(ctx, s.URL+"/page"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the robots.txt fetch to be canceled, received %v", err)
+	}
+
+	// a canceled fetch doesn't disallow the host
+	if _, err := r.Get(s.URL + "/page"); err != nil || calls.Load() != 2 {
+		t.Errorf("Expected robots.txt to be fetched again, received %v after %d fetches", err, calls.Load())
+	}
+}
@@ -0,0 +1,103 @@
+package req
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// sitemap indexes may point to other indexes, but not forever
+const maxSitemapDepth = 3
+
+// maxSitemapSize is the limit of uncompressed sitemaps in the sitemaps.org
+// protocol, so gzip bombs can't exhaust memory (a variable for tests)
+var maxSitemapSize int64 = 50 << 20
+
+// SitemapURL is a single <url> entry of a sitemap.
+type SitemapURL struct {
+	Loc        string  `xml:"loc"`
+	LastMod    string  `xml:"lastmod"`
+	ChangeFreq string  `xml:"changefreq"`
+	Priority   float64 `xml:"priority"`
+}
+
+// sitemapDoc decodes both <urlset> sitemaps and <sitemapindex> files
+type sitemapDoc struct {
+	XMLName  xml.Name
+	URLs     []SitemapURL `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+// Sitemap downloads and parses a sitemap with a default Request.
+func Sitemap(url string) ([]SitemapURL, error) {
+	return New().Sitemap(url)
+}
+
+// Sitemap downloads and parses sitemap.xml into its URL entries.  Sitemap
+// index files are followed and gzipped sitemaps (sitemap.xml.gz) are
+// decompressed.  Surrounding whitespace is trimmed from locations.
+func (c *Request) Sitemap(url string) ([]SitemapURL, error) {
+	return c.sitemap(url, 0)
+}
+
+func (c *Request) sitemap(url string, depth int) ([]SitemapURL, error) {
+	if depth > maxSitemapDepth {
+		return nil, fmt.Errorf("sitemap index nested too deeply at %s", url)
+	}
+
+	resp, err := c.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := sitemapReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc sitemapDoc
+	if err := xml.NewDecoder(body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("could not parse sitemap %s: %v", url, err)
+	}
+
+	if doc.XMLName.Local != "sitemapindex" {
+		for i := range doc.URLs {
+			doc.URLs[i].Loc = strings.TrimSpace(doc.URLs[i].Loc)
+		}
+		return doc.URLs, nil
+	}
+
+	var urls []SitemapURL
+	for _, s := range doc.Sitemaps {
+		u, err := c.sitemap(strings.TrimSpace(s.Loc), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, u...)
+	}
+
+	return urls, nil
+}
+
+// sitemapReader transparently decompresses gzipped content, up to
+// maxSitemapSize.  The gzip magic number is checked instead of the URL or
+// headers, since servers are rarely consistent about either.
+func sitemapReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return io.LimitReader(gz, maxSitemapSize), nil
+	}
+
+	return io.LimitReader(br, maxSitemapSize), nil
+}
@@ -0,0 +1,113 @@
+package req_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sspencer/goal/req"
+)
+
+const urlset = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>
+      %[1]s/%[2]s/a
+    </loc>
+    <lastmod>2024-01-02</lastmod>
+    <priority>0.8</priority>
+  </url>
+  <url><loc>%[1]s/%[2]s/b</loc></url>
+</urlset>`
+
+const sitemapIndex = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc> %[1]s/plain.xml </loc></sitemap>
+  <sitemap><loc>%[1]s/gzipped.xml.gz</loc></sitemap>
+</sitemapindex>`
+
+func sitemapServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var s *httptest.Server
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/plain.xml":
+			fmt.Fprintf(w, urlset, s.URL, "plain")
+		case "/gzipped.xml.gz":
+			gz := gzip.NewWriter(w)
+			fmt.Fprintf(gz, urlset, s.URL, "gzipped")
+			gz.Close()
+		case "/index.xml":
+			fmt.Fprintf(w, sitemapIndex, s.URL)
+		case "/loop.xml":
+			fmt.Fprintf(w, `<sitemapindex><sitemap><loc>%s/loop.xml</loc></sitemap></sitemapindex>`, s.URL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func TestSitemap(t *testing.T) {
+	s := sitemapServer(t)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/plain.xml", []string{"/plain/a", "/plain/b"}},
+		{"/gzipped.xml.gz", []string{"/gzipped/a", "/gzipped/b"}},
+		{"/index.xml", []string{"/plain/a", "/plain/b", "/gzipped/a", "/gzipped/b"}},
+	}
+
+	for _, tt := range tests {
+		urls, err := req.Sitemap(s.URL + tt.path)
+		if err != nil {
+			t.Errorf("%s: %v", tt.path, err)
+			continue
+		}
+
+		var locs []string
+		for _, u := range urls {
+			locs = append(locs, strings.TrimPrefix(u.Loc, s.URL))
+		}
+		if fmt.Sprint(locs) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: expected %v, received %v", tt.path, tt.want, locs)
+		}
+		if urls[0].LastMod != "2024-01-02" || urls[0].Priority != 0.8 {
+			t.Errorf("%s: unexpected first entry %+v", tt.path, urls[0])
+		}
+	}
+
+	if _, err := req.Sitemap(s.URL + "/loop.xml"); err == nil {
+		t.Error("Expected an error for an index pointing to itself")
+	}
+}
+
+func TestSitemapSize(t *testing.T) {
+	defer req.SetMaxSitemapSize(1 << 10)()
+
+	// whitespace compresses to next to nothing
+	var bomb bytes.Buffer
+	gz := gzip.NewWriter(&bomb)
+	gz.Write([]byte("<urlset>"))
+	gz.Write(bytes.Repeat([]byte(" "), 2<<10))
+	gz.Write([]byte("</urlset>"))
+	gz.Close()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bomb.Bytes())
+	}))
+	defer s.Close()
+
+	if _, err := req.Sitemap(s.URL); err == nil {
+		t.Error("Expected sitemaps over the limit to be cut short")
+	}
+}
@@ -0,0 +1,28 @@
+package req
+
+import (
+	"io"
+	"net/http"
+)
+
+// teeBody reads through a TeeReader but closes the original body
+type teeBody struct {
+	io.Reader
+	io.Closer
+}
+
+// TeeBody replaces the response body with one that copies everything read
+// from it to w.  Payloads can be archived (to a file, buffer, etc.) while
+// they are being decoded, e.g.
+//
+//	req.Unmarshal(req.TeeBody(resp, f).Body, &v)
+func TeeBody(resp *http.Response, w io.Writer) *http.Response {
+	resp.Body = teeBody{io.TeeReader(resp.Body, w), resp.Body}
+	return resp
+}
+
+// Tee copies the body of every successful response to w as it is read
+func (c *Request) Tee(w io.Writer) *Request {
+	c.tee = w
+	return c
+}
@@ -0,0 +1,52 @@
+package req_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sspencer/goal/req"
+)
+
+func TestTeeBody(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"gopher"}`))
+	}))
+	defer s.Close()
+
+	resp, err := req.New().Get(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	var v struct{ Name string }
+	if err := req.Unmarshal(req.TeeBody(resp, &archive).Body, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Name != "gopher" || archive.String() != `{"name":"gopher"}` {
+		t.Errorf("Expected the body decoded and copied, received %+v and %q", v, archive.String())
+	}
+}
+
+func TestTee(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("payload"))
+	}))
+	defer s.Close()
+
+	var archive bytes.Buffer
+	resp, err := req.New().Tee(&archive).Get(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(b) != "payload" || archive.String() != "payload" {
+		t.Errorf("Expected the body read and copied, received %q and %q", b, archive.String())
+	}
+}
@@ -0,0 +1,51 @@
+package req
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokens are refreshed this long before they expire
+const tokenExpiryMargin = time.Minute
+
+// TokenSource supplies bearer tokens, e.g. from a cloud metadata endpoint.
+// ctx is that of the request needing the token.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// BearerSource sets an "Authorization: Bearer" header on every request,
+// with a token from ts.
+func (c *Request) BearerSource(ts TokenSource) *Request {
+	c.tokens = ts
+	return c
+}
+
+// cachedToken is a TokenSource that fetches a new token only when the
+// current one is about to expire
+type cachedToken struct {
+	fetch func(context.Context) (string, time.Time, error)
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// Token implements TokenSource
+func (t *cachedToken) Token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && time.Now().Add(tokenExpiryMargin).Before(t.expiry) {
+		return t.token, nil
+	}
+
+	token, expiry, err := t.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	t.token, t.expiry = token, expiry
+	return token, nil
+}
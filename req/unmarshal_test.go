@@ -0,0 +1,71 @@
+package req_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sspencer/goal/req"
+)
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		empty  bool // an EmptyBody error
+		want   string
+	}{
+		{"no content", http.StatusNoContent, "", true, "default"},
+		{"empty", http.StatusOK, "", true, "default"},
+		{"whitespace", http.StatusOK, " \n", true, "default"},
+		{"object", http.StatusOK, `{"name":"gopher"}`, false, "gopher"},
+	}
+
+	for _, tt := range tests {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+
+		get := func() *http.Response {
+			resp, err := req.New().Get(s.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return resp
+		}
+
+		v := struct{ Name string }{"default"}
+		err := req.Unmarshal(get().Body, &v)
+		if empty := errors.As(err, &req.EmptyBody{}); empty != tt.empty || (!tt.empty && err != nil) {
+			t.Errorf("%s: Unmarshal expected empty %t, received %v", tt.name, tt.empty, err)
+		}
+
+		v.Name = "default"
+		if err := req.UnmarshalAllowEmpty(get().Body, &v); err != nil || v.Name != tt.want {
+			t.Errorf("%s: UnmarshalAllowEmpty expected %q, received %q, %v", tt.name, tt.want, v.Name, err)
+		}
+
+		s.Close()
+	}
+}
+
+func TestUnmarshalSyntaxError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":}`))
+	}))
+	defer s.Close()
+
+	resp, err := req.New().Get(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v struct{ Name string }
+	var syntax req.SyntaxError
+	if err := req.UnmarshalAllowEmpty(resp.Body, &v); !errors.As(err, &syntax) {
+		t.Errorf("Expected a SyntaxError, received %v", err)
+	}
+}
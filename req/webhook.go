@@ -0,0 +1,107 @@
+package req
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/sspencer/goal/backoff"
+	"github.com/sspencer/goal/id"
+	"github.com/sspencer/goal/retry"
+)
+
+const (
+	// WebhookSignatureHeader holds "sha256=" and the hex HMAC-SHA256 of the
+	// timestamp and body, see Sign
+	WebhookSignatureHeader = "X-Webhook-Signature-256"
+
+	// WebhookTimestampHeader holds the unix time the delivery was first attempted
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+
+	// WebhookDeliveryHeader holds a unique ID, identical across retries
+	WebhookDeliveryHeader = "X-Webhook-Delivery"
+
+	webhookAttempts = 4
+	webhookBackoff  = time.Second
+)
+
+// Webhook delivers a webhook with a default Request.
+func Webhook(url string, payload interface{}, secret string) (*http.Response, error) {
+	return New().Webhook(url, payload, secret)
+}
+
+// Webhook POSTs payload as JSON ([]byte payloads are sent as is), signed with
+// the HMAC-SHA256 of secret.  Connection errors, 429 and 5XX responses are
+// retried with exponential backoff (1s, 2s, 4s).
+func (c *Request) Webhook(url string, payload interface{}, secret string) (*http.Response, error) {
+	return c.WebhookCtx(context.Background(), url, payload, secret)
+}
+
+// WebhookCtx is like Webhook, with a context to cancel the delivery,
+// including the waits between attempts.
+func (c *Request) WebhookCtx(ctx context.Context, url string, payload interface{}, secret string) (*http.Response, error) {
+	body, err := encodeJSON(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	r := *c
+	r.header = cloneHeader(c.header)
+	r.header.Set(WebhookSignatureHeader, "sha256="+Sign(timestamp, body, secret))
+	r.header.Set(WebhookTimestampHeader, timestamp)
+	r.header.Set(WebhookDeliveryHeader, id.NewV4().String())
+	r.retries = 0 // deliveries have their own policy
+
+	policy := retry.Policy{
+		MaxAttempts: webhookAttempts,
+		Backoff:     backoff.Exponential(webhookBackoff, 2),
+		Retryable:   retryWebhook,
+	}
+
+	return retry.DoValue(ctx, policy, func() (*http.Response, error) {
+		return r.request(ctx, http.MethodPost, url, JSONContentType, bytes.NewReader(body))
+	})
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the timestamp header, a dot
+// and body, for verifying webhooks.  Signing the timestamp keeps deliveries
+// from being replayed with a fresh one, so receivers should also reject
+// stale timestamps.
+func Sign(timestamp string, body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryWebhook retries transport errors, 429 and 5XX responses, but not
+// errors that another attempt wouldn't fix, such as invalid URLs
+func retryWebhook(err error) bool {
+	var he HTTPError
+	if errors.As(err, &he) {
+		return he.StatusCode == http.StatusTooManyRequests || he.StatusCode >= http.StatusInternalServerError
+	}
+
+	// client.Do wraps its errors with the method, url.Parse with "parse"
+	var ue *url.Error
+	return errors.As(err, &ue) && ue.Op != "parse"
+}
+
+func cloneHeader(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, v := range h {
+		c[k] = append([]string(nil), v...)
+	}
+
+	return c
+}
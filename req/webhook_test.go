@@ -0,0 +1,41 @@
+package req_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sspencer/goal/req"
+)
+
+func TestWebhook(t *testing.T) {
+	var calls atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		timestamp := r.Header.Get(req.WebhookTimestampHeader)
+		if r.Header.Get(req.WebhookSignatureHeader) != "sha256="+req.Sign(timestamp, body, "secret") {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer s.Close()
+
+	if _, err := req.Webhook(s.URL, map[string]string{"event": "ping"}, "secret"); err != nil {
+		t.Errorf("Expected a valid signature, received %v", err)
+	}
+
+	if req.Sign("1700000000", []byte("{}"), "secret") == req.Sign("1700000001", []byte("{}"), "secret") {
+		t.Error("Expected the signature to cover the timestamp")
+	}
+
+	// neither is fixed by retrying
+	calls.Store(0)
+	if _, err := req.Webhook(s.URL, nil, "wrong"); err == nil || calls.Load() != 1 {
+		t.Errorf("Expected a single rejected attempt, received %d and %v", calls.Load(), err)
+	}
+	if _, err := req.Webhook("http://[::1", nil, "secret"); err == nil {
+		t.Error("Expected an invalid URL error")
+	}
+}
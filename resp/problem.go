@@ -0,0 +1,83 @@
+package resp
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Problem is an RFC 7807 problem detail, the standard body of HTTP API
+// errors.  Extensions are members of their own, next to the standard ones.
+type Problem struct {
+	Type       string                 // URI of the problem type, "about:blank" when empty
+	Title      string                 // summary of the problem type
+	Status     int                    // HTTP status
+	Detail     string                 // explanation of this occurrence
+	Instance   string                 // URI of this occurrence
+	Extensions map[string]interface{} // e.g. "errors" for validation failures
+}
+
+// NewProblem returns the problem of a status, titled with its status text.
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{Status: status, Title: http.StatusText(status), Detail: detail}
+}
+
+// With adds an extension member to p.
+func (p *Problem) With(name string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+
+	p.Extensions[name] = value
+	return p
+}
+
+// Error implements the Error method for Problems, so handlers can return them
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Title + ": " + p.Detail
+	}
+
+	return p.Title
+}
+
+// MarshalJSON flattens the extensions into the standard members
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for name, value := range p.Extensions {
+		m[name] = value
+	}
+
+	m["type"] = p.Type
+	if p.Type == "" {
+		m["type"] = "about:blank"
+	}
+	if p.Title != "" {
+		m["title"] = p.Title
+	}
+	if p.Status != 0 {
+		m["status"] = p.Status
+	}
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+
+	return json.Marshal(m)
+}
+
+// WriteProblem writes p as an application/problem+json response, with its
+// status (set to 500 when missing).
+func WriteProblem(w http.ResponseWriter, p *Problem) error {
+	if p.Status == 0 {
+		p.Status = http.StatusInternalServerError
+	}
+
+	return write(w, p.Status, ProblemContentType, p)
+}
+
+// ProblemStatus writes the problem of a status with the given detail.
+func ProblemStatus(w http.ResponseWriter, status int, detail string) error {
+	return WriteProblem(w, NewProblem(status, detail))
+}
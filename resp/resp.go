@@ -0,0 +1,56 @@
+// Package resp writes the JSON responses of HTTP handlers, the server side
+// counterpart of the req client.
+package resp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	// JSONContentType is the http content type for json
+	JSONContentType = "application/json"
+
+	// ProblemContentType is the http content type for RFC 7807 problems
+	ProblemContentType = "application/problem+json"
+)
+
+// ErrorBody is the body written by Error.
+type ErrorBody struct {
+	Error string `json:"error"`
+}
+
+// JSON writes v as the JSON body of a response with the given status.  v is
+// encoded before anything is written, so an encoding error still results in
+// a 500 response.
+func JSON(w http.ResponseWriter, status int, v interface{}) error {
+	return write(w, status, JSONContentType, v)
+}
+
+// Error writes {"error": msg} with the given status.
+func Error(w http.ResponseWriter, status int, msg string) error {
+	return JSON(w, status, ErrorBody{msg})
+}
+
+// NoContent writes an empty 204 response.
+func NoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// write encodes v, then writes the header and the body
+func write(w http.ResponseWriter, status int, contentType string, v interface{}) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(v); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return err
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
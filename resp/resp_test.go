@@ -0,0 +1,53 @@
+package resp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sspencer/goal/resp"
+)
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		write  func(w http.ResponseWriter) error
+		status int
+		ct     string
+		body   string
+	}{
+		{func(w http.ResponseWriter) error { return resp.JSON(w, http.StatusCreated, map[string]int{"id": 1}) },
+			http.StatusCreated, resp.JSONContentType, `{"id":1}`},
+		{func(w http.ResponseWriter) error { return resp.Error(w, http.StatusBadRequest, "bad <input>") },
+			http.StatusBadRequest, resp.JSONContentType, `{"error":"bad <input>"}`},
+		{func(w http.ResponseWriter) error { return resp.ProblemStatus(w, http.StatusNotFound, "no such user") },
+			http.StatusNotFound, resp.ProblemContentType, `{"detail":"no such user","status":404,"title":"Not Found","type":"about:blank"}`},
+		{func(w http.ResponseWriter) error {
+			p := &resp.Problem{Type: "https://example.com/out-of-credit", Title: "Out of credit"}
+			return resp.WriteProblem(w, p.With("balance", 30))
+		}, http.StatusInternalServerError, resp.ProblemContentType,
+			`{"balance":30,"status":500,"title":"Out of credit","type":"https://example.com/out-of-credit"}`},
+	}
+
+	for i, tc := range tests {
+		rec := httptest.NewRecorder()
+		tc.write(rec)
+
+		if rec.Code != tc.status || rec.Header().Get("Content-Type") != tc.ct {
+			t.Errorf("Test %d: expected %d %s, received %d %s", i, tc.status, tc.ct, rec.Code, rec.Header().Get("Content-Type"))
+		}
+		if tc.body != "" && rec.Body.String() != tc.body+"\n" {
+			t.Errorf("Test %d: expected body %s, received %s", i, tc.body, rec.Body.String())
+		}
+	}
+}
+
+func TestJSONEncodingError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := resp.JSON(rec, http.StatusOK, make(chan int)); err == nil {
+		t.Error("Expected an encoding error")
+	}
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500, received %d", rec.Code)
+	}
+}
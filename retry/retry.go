@@ -0,0 +1,162 @@
+// Package retry runs a function until it succeeds, waiting between
+// attempts according to a Policy, e.g.
+//
+//	err := retry.Do(ctx, retry.Policy{MaxAttempts: 5, Backoff: backoff.Exponential(time.Second, 2)}, func() error {
+//		return upload(ctx, file)
+//	})
+package retry
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sspencer/goal/backoff"
+)
+
+const (
+	defaultAttempts = 3
+	defaultBackoff  = 100 * time.Millisecond
+)
+
+// Policy configures Do.  The zero value makes 3 attempts, 100ms and 200ms
+// apart, retrying every error.
+type Policy struct {
+	// MaxAttempts in total, including the first, 3 when 0
+	MaxAttempts int
+
+	// Backoff between attempts, backoff.Exponential(100ms, 2) when nil
+	Backoff backoff.Strategy
+
+	// Retryable reports whether to retry after err, every error but
+	// Permanent ones when nil
+	Retryable func(err error) bool
+
+	// OnRetry is called before waiting delay to retry after a failed attempt
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// Do calls fn until it succeeds, the policy gives up or ctx is done,
+// returning the last error of fn.  When ctx is done while waiting, the
+// error also matches the cause of ctx.
+func Do(ctx context.Context, p Policy, fn func() error) error {
+	_, err := DoValue(ctx, p, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+
+	return err
+}
+
+// DoValue is like Do for functions returning a value.  The value of the
+// last attempt is returned, even if it failed.
+func DoValue[T any](ctx context.Context, p Policy, fn func() (T, error)) (T, error) {
+	attempts := p.MaxAttempts
+	if attempts <= 0 {
+		attempts = defaultAttempts
+	}
+
+	strategy := p.Backoff
+	if strategy == nil {
+		strategy = backoff.Exponential(defaultBackoff, 2)
+	}
+
+	for attempt := 1; ; attempt++ {
+		v, err := fn()
+		if err == nil || attempt >= attempts || !p.retryable(err) {
+			return v, unwrap(err)
+		}
+
+		delay := strategy.Delay(attempt)
+		if d, ok := afterDelay(err); ok && d > delay {
+			delay = d
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, delay)
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return v, errors.Join(unwrap(err), context.Cause(ctx))
+		}
+	}
+}
+
+func (p Policy) retryable(err error) bool {
+	var perm *permanent
+	if errors.As(err, &perm) {
+		return false
+	}
+
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// Permanent marks err as not worth retrying, whatever the policy.  Do
+// returns err itself.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanent{err}
+}
+
+type permanent struct {
+	err error
+}
+
+func (p *permanent) Error() string {
+	return p.err.Error()
+}
+
+func (p *permanent) Unwrap() error {
+	return p.err
+}
+
+// After marks err as worth retrying no sooner than d, e.g. as asked by
+// the Retry-After header of an HTTP response.  Shorter backoff delays are
+// extended to d.  Do returns err itself.
+func After(err error, d time.Duration) error {
+	if err == nil || d <= 0 {
+		return err
+	}
+
+	return &after{err, d}
+}
+
+type after struct {
+	err error
+	d   time.Duration
+}
+
+func (a *after) Error() string {
+	return a.err.Error()
+}
+
+func (a *after) Unwrap() error {
+	return a.err
+}
+
+// afterDelay returns the delay of an error marked by After
+func afterDelay(err error) (time.Duration, bool) {
+	var a *after
+	if errors.As(err, &a) {
+		return a.d, true
+	}
+
+	return 0, false
+}
+
+// unwrap returns the error marked by Permanent or After
+func unwrap(err error) error {
+	switch e := err.(type) {
+	case *permanent:
+		return e.err
+	case *after:
+		return e.err
+	}
+
+	return err
+}
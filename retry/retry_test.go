@@ -0,0 +1,113 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/backoff"
+	"github.com/sspencer/goal/retry"
+)
+
+var errFlaky = errors.New("flaky")
+
+func TestDo(t *testing.T) {
+	permanent := errors.New("permanent")
+
+	tests := []struct {
+		name     string
+		policy   retry.Policy
+		errs     []error // returned by successive attempts, nil after
+		attempts int
+		err      error
+	}{
+		{"success", retry.Policy{}, nil, 1, nil},
+		{"recovers", retry.Policy{}, []error{errFlaky, errFlaky}, 3, nil},
+		{"gives up", retry.Policy{MaxAttempts: 2}, []error{errFlaky, errFlaky, errFlaky}, 2, errFlaky},
+		{"permanent", retry.Policy{}, []error{retry.Permanent(permanent)}, 1, permanent},
+		{"not retryable", retry.Policy{Retryable: func(err error) bool { return err != permanent }}, []error{permanent}, 1, permanent},
+	}
+
+	for _, tt := range tests {
+		tt.policy.Backoff = backoff.Constant(time.Millisecond)
+
+		attempts := 0
+		err := retry.Do(context.Background(), tt.policy, func() error {
+			attempts++
+			if attempts <= len(tt.errs) {
+				return tt.errs[attempts-1]
+			}
+			return nil
+		})
+
+		if attempts != tt.attempts {
+			t.Errorf("%s: expected %d attempts, received %d", tt.name, tt.attempts, attempts)
+		}
+		if err != tt.err {
+			t.Errorf("%s: expected error %v, received %v", tt.name, tt.err, err)
+		}
+	}
+}
+
+func TestOnRetry(t *testing.T) {
+	var delays []time.Duration
+	policy := retry.Policy{
+		MaxAttempts: 3,
+		Backoff:     backoff.Exponential(time.Millisecond, 2),
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			delays = append(delays, delay)
+		},
+	}
+
+	n, err := retry.DoValue(context.Background(), policy, func() (int, error) {
+		return 7, errFlaky
+	})
+
+	if n != 7 || err != errFlaky {
+		t.Errorf("Expected 7 and %v, received %d and %v", errFlaky, n, err)
+	}
+	if len(delays) != 2 || delays[0] != time.Millisecond || delays[1] != 2*time.Millisecond {
+		t.Errorf("Expected [1ms 2ms], received %v", delays)
+	}
+}
+
+func TestDoCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := retry.Do(ctx, retry.Policy{MaxAttempts: 10, Backoff: backoff.Constant(time.Hour)}, func() error {
+		return errFlaky
+	})
+
+	if !errors.Is(err, errFlaky) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected both %v and the deadline, received %v", errFlaky, err)
+	}
+}
+
+func TestAfter(t *testing.T) {
+	var delays []time.Duration
+	policy := retry.Policy{
+		MaxAttempts: 3,
+		Backoff:     backoff.Constant(time.Millisecond),
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			delays = append(delays, delay)
+		},
+	}
+
+	attempts := 0
+	err := retry.Do(context.Background(), policy, func() error {
+		attempts++
+		if attempts == 1 {
+			return retry.After(errFlaky, 5*time.Millisecond)
+		}
+		return retry.After(errFlaky, time.Microsecond)
+	})
+
+	if err != errFlaky {
+		t.Errorf("Expected %v, received %v", errFlaky, err)
+	}
+	if len(delays) != 2 || delays[0] != 5*time.Millisecond || delays[1] != time.Millisecond {
+		t.Errorf("Expected [5ms 1ms], received %v", delays)
+	}
+}
@@ -0,0 +1,231 @@
+package sched
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrSyntax is returned by ParseCron for invalid expressions.
+var ErrSyntax = errors.New("sched: invalid cron expression")
+
+// Schedule decides when a job runs.
+type Schedule interface {
+	// Next returns the first time after t to run, or the zero time for
+	// never
+	Next(t time.Time) time.Time
+}
+
+// Every runs every d, e.g. Every(5*time.Minute).
+func Every(d time.Duration) Schedule {
+	return every(max(d, time.Millisecond))
+}
+
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// cron is a parsed cron expression, with a bit set per allowed value
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	anyDom, anyDow                bool
+	loc                           *time.Location
+}
+
+// field is the range of a field of cron expressions
+type field struct {
+	name     string
+	min, max int
+	names    []string // of the values from min, if any
+}
+
+var fields = []field{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{"day of week", 0, 6, []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+var shortcuts = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a standard 5-field cron expression (minute, hour, day of
+// month, month, day of week) in the local time zone, e.g.
+//
+//	*/15 * * * *     every 15 minutes
+//	0 9 * * mon-fri  at 9:00 on weekdays
+//	0 0 1,15 * *     at midnight on the 1st and 15th
+//
+// Shortcuts @hourly, @daily, @weekly, @monthly and @yearly are supported,
+// as is "@every 90s" (see Every).  Like cron, when both the day of month
+// and the day of week are restricted, either one matching is enough.
+func ParseCron(expr string) (Schedule, error) {
+	return ParseCronIn(expr, time.Local)
+}
+
+// ParseCronIn is like ParseCron, in the time zone loc.
+func ParseCronIn(expr string, loc *time.Location) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if d, ok := strings.CutPrefix(expr, "@every "); ok {
+		dur, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil || dur <= 0 {
+			return nil, fmt.Errorf("%w: %q", ErrSyntax, expr)
+		}
+		return Every(dur), nil
+	}
+	if s, ok := shortcuts[strings.ToLower(expr)]; ok {
+		expr = s
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("%w: %q has %d fields, expected 5", ErrSyntax, expr, len(parts))
+	}
+
+	var bits [5]uint64
+	for i, f := range fields {
+		b, err := f.parse(parts[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrSyntax, expr, err)
+		}
+		bits[i] = b
+	}
+
+	// 7 is also Sunday
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+
+	return &cron{
+		minute: bits[0], hour: bits[1], dom: bits[2], month: bits[3], dow: bits[4],
+		anyDom: parts[2] == "*" || parts[2] == "?",
+		anyDow: parts[4] == "*" || parts[4] == "?",
+		loc:    loc,
+	}, nil
+}
+
+// parse returns the bits of the values of a comma-separated list of
+// values, ranges and steps
+func (f field) parse(s string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		expr, stepStr, hasStep := strings.Cut(part, "/")
+
+		lo, hi := f.min, f.max
+		if f.name == "day of week" {
+			hi = 7
+		}
+		if expr != "*" && expr != "?" {
+			loStr, hiStr, isRange := strings.Cut(expr, "-")
+			var err error
+			if lo, err = f.value(loStr); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(hiStr); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max // e.g. 5/15 means 5-59/15
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("%s range %s is backwards", f.name, expr)
+			}
+		}
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid %s step %q", f.name, stepStr)
+			}
+			step = n
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+
+	return bits, nil
+}
+
+// value parses a number or name in the range of the field
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+
+	n, err := strconv.Atoi(s)
+	hi := f.max
+	if f.name == "day of week" {
+		hi = 7
+	}
+	if err != nil || n < f.min || n > hi {
+		return 0, fmt.Errorf("invalid %s %q", f.name, s)
+	}
+
+	return n, nil
+}
+
+// Next returns the first minute after t matching the expression, searching
+// up to 5 years ahead (e.g. for February 29th)
+func (c *cron) Next(t time.Time) time.Time {
+	t = t.In(c.loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<int(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.loc)
+			continue
+		}
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.loc)
+			continue
+		}
+		if c.hour&(1<<t.Hour()) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, c.loc)
+			continue
+		}
+		if c.minute&(1<<t.Minute()) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// matchDay reports whether the day of t matches, either the day of month or
+// the day of week when both are restricted
+func (c *cron) matchDay(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<int(t.Weekday())) != 0
+
+	switch {
+	case c.anyDom && c.anyDow:
+		return true
+	case c.anyDom:
+		return dow
+	case c.anyDow:
+		return dom
+	default:
+		return dom || dow
+	}
+}
@@ -0,0 +1,298 @@
+// Package sched runs jobs periodically, on cron expressions or at fixed
+// intervals, e.g.
+//
+//	s := sched.New()
+//	s.Cron("report", "0 9 * * mon-fri", sendReport)
+//	s.Every("refresh", 5*time.Minute, refresh, sched.Jitter(30*time.Second))
+//	s.Run(ctx)
+//
+// A job doesn't start while its previous run is still going, unless it
+// allows overlap, and panics are recovered and logged as errors.
+package sched
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sspencer/goal/logx"
+)
+
+// ErrDuplicate is returned when adding a job with the name of another job.
+var ErrDuplicate = errors.New("sched: duplicate job")
+
+// Func is the work of a job.  Its ctx is canceled when the scheduler stops,
+// or after the Timeout of the job.
+type Func func(ctx context.Context) error
+
+// Option configures a Scheduler.
+type Option func(*Scheduler)
+
+// Logger logs the runs of jobs to l: failures as errors, runs skipped
+// because of overlap as warnings, and successful runs at debug level.
+func Logger(l logx.Logger) Option {
+	return func(s *Scheduler) {
+		s.log = l
+	}
+}
+
+// JobOption configures a job.
+type JobOption func(*job)
+
+// Jitter delays every run by a random duration of up to d, so jobs of many
+// processes don't all start at once.
+func Jitter(d time.Duration) JobOption {
+	return func(j *job) {
+		j.jitter = d
+	}
+}
+
+// AllowOverlap lets a run start while the previous run of the job is still
+// going.  By default, the run is skipped.
+func AllowOverlap() JobOption {
+	return func(j *job) {
+		j.overlap = true
+	}
+}
+
+// Timeout cancels the ctx of runs taking longer than d.
+func Timeout(d time.Duration) JobOption {
+	return func(j *job) {
+		j.timeout = d
+	}
+}
+
+// job is a function run on a schedule
+type job struct {
+	name     string
+	schedule Schedule
+	fn       Func
+	jitter   time.Duration
+	overlap  bool
+	timeout  time.Duration
+
+	mu      sync.Mutex
+	running int
+	next    time.Time
+	last    Run
+}
+
+// Run is the outcome of the run of a job.
+type Run struct {
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// Status describes a job of a Scheduler.
+type Status struct {
+	Name    string
+	Next    time.Time // zero when never, or not scheduled yet
+	Running int
+	Last    Run // zero when it hasn't run
+}
+
+// Scheduler runs jobs on their schedules.  It is safe for concurrent use.
+type Scheduler struct {
+	log logx.Logger
+
+	mu   sync.Mutex
+	jobs map[string]*job
+	wake chan struct{} // signals jobs added while running
+}
+
+// New returns a Scheduler without jobs.
+func New(opts ...Option) *Scheduler {
+	s := &Scheduler{log: logx.Default(), jobs: make(map[string]*job), wake: make(chan struct{}, 1)}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Add schedules fn as the job called name.
+func (s *Scheduler) Add(name string, schedule Schedule, fn Func, opts ...JobOption) error {
+	j := &job{name: name, schedule: schedule, fn: fn}
+	for _, opt := range opts {
+		opt(j)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicate, name)
+	}
+	s.jobs[name] = j
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Cron schedules fn on the cron expression expr (see ParseCron).
+func (s *Scheduler) Cron(name, expr string, fn Func, opts ...JobOption) error {
+	schedule, err := ParseCron(expr)
+	if err != nil {
+		return err
+	}
+
+	return s.Add(name, schedule, fn, opts...)
+}
+
+// Every schedules fn every d, starting d from when the scheduler runs.
+func (s *Scheduler) Every(name string, d time.Duration, fn Func, opts ...JobOption) error {
+	return s.Add(name, Every(d), fn, opts...)
+}
+
+// Remove unschedules the job called name.  A run in progress goes on.
+func (s *Scheduler) Remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.jobs, name)
+}
+
+// Jobs returns the status of every job, sorted by name.
+func (s *Scheduler) Jobs() []Status {
+	s.mu.Lock()
+	jobs := make([]*job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	s.mu.Unlock()
+
+	statuses := make([]Status, 0, len(jobs))
+	for _, j := range jobs {
+		j.mu.Lock()
+		statuses = append(statuses, Status{Name: j.name, Next: j.next, Running: j.running, Last: j.last})
+		j.mu.Unlock()
+	}
+	sort.Slice(statuses, func(a, b int) bool { return statuses[a].Name < statuses[b].Name })
+
+	return statuses
+}
+
+// Run runs the jobs on their schedules until ctx is done, then cancels the
+// runs in progress and waits for them to return.
+func (s *Scheduler) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		now := time.Now()
+		next := s.start(ctx, now, &wg)
+
+		wait := time.Hour
+		if !next.IsZero() {
+			wait = next.Sub(now)
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-timer.C:
+		case <-s.wake:
+			timer.Stop()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// start starts the jobs due at now, and returns when the next job is due
+func (s *Scheduler) start(ctx context.Context, now time.Time, wg *sync.WaitGroup) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	for _, j := range s.jobs {
+		j.mu.Lock()
+		if j.next.IsZero() {
+			j.next = j.schedule.Next(now)
+		} else if !j.next.After(now) {
+			due := j.next
+			j.next = j.schedule.Next(now)
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.run(ctx, j, due)
+			}()
+		}
+
+		if !j.next.IsZero() && (next.IsZero() || j.next.Before(next)) {
+			next = j.next
+		}
+		j.mu.Unlock()
+	}
+
+	return next
+}
+
+// run runs the job due at the given time, after its jitter, unless the
+// previous run is still going
+func (s *Scheduler) run(ctx context.Context, j *job, due time.Time) {
+	if j.jitter > 0 {
+		t := time.NewTimer(rand.N(j.jitter))
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return
+		}
+	}
+
+	j.mu.Lock()
+	if j.running > 0 && !j.overlap {
+		j.mu.Unlock()
+		s.log.Log(ctx, slog.LevelWarn, "job skipped, previous run still going", "job", j.name, "due", due)
+		return
+	}
+	j.running++
+	j.mu.Unlock()
+
+	if j.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := call(ctx, j.fn)
+	r := Run{Start: start, Duration: time.Since(start), Err: err}
+
+	j.mu.Lock()
+	j.running--
+	j.last = r
+	j.mu.Unlock()
+
+	if err != nil {
+		s.log.Log(ctx, slog.LevelError, "job failed", "job", j.name, "duration", r.Duration, "error", err)
+	} else {
+		s.log.Log(ctx, slog.LevelDebug, "job done", "job", j.name, "duration", r.Duration)
+	}
+}
+
+// call calls fn, turning a panic into an error
+func call(ctx context.Context, fn Func) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("panic: %v\n%s", v, debug.Stack())
+		}
+	}()
+
+	return fn(ctx)
+}
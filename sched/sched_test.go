@@ -0,0 +1,102 @@
+package sched_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/logx"
+	"github.com/sspencer/goal/sched"
+)
+
+func TestParseCron(t *testing.T) {
+	// a Wednesday
+	from := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		next string
+	}{
+		{"* * * * *", "2025-01-15 10:31"},
+		{"*/15 * * * *", "2025-01-15 10:45"},
+		{"0 9 * * mon-fri", "2025-01-16 09:00"},
+		{"0 0 1,15 * *", "2025-02-01 00:00"},
+		{"30 10 * * 3", "2025-01-22 10:30"},
+		{"0 0 * * 7", "2025-01-19 00:00"},
+		{"0 0 1 * 1", "2025-01-20 00:00"},
+		{"5/20 * * * *", "2025-01-15 10:45"},
+		{"0 0 29 feb *", "2028-02-29 00:00"},
+		{"@daily", "2025-01-16 00:00"},
+		{"@every 90s", "2025-01-15 10:31"},
+	}
+
+	for _, tt := range tests {
+		s, err := sched.ParseCronIn(tt.expr, time.UTC)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+
+		if next := s.Next(from).Format("2006-01-02 15:04"); next != tt.next {
+			t.Errorf("%s: expected %s, received %s", tt.expr, tt.next, next)
+		}
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "5-1 * * * *", "*/0 * * * *", "* * * foo *", "@every soon"} {
+		if _, err := sched.ParseCron(expr); !errors.Is(err, sched.ErrSyntax) {
+			t.Errorf("%q: expected ErrSyntax, received %v", expr, err)
+		}
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	log := &logx.TestLogger{}
+	s := sched.New(sched.Logger(log))
+
+	var ticks, slow atomic.Int32
+	s.Every("tick", 10*time.Millisecond, func(ctx context.Context) error {
+		ticks.Add(1)
+		return nil
+	})
+	s.Every("slow", 10*time.Millisecond, func(ctx context.Context) error {
+		slow.Add(1)
+		<-ctx.Done()
+		return nil
+	})
+	s.Every("panic", 10*time.Millisecond, func(ctx context.Context) error {
+		panic("boom")
+	})
+
+	if err := s.Every("tick", time.Second, nil); !errors.Is(err, sched.ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate, received %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 75*time.Millisecond)
+	defer cancel()
+	if err := s.Run(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected %v, received %v", context.DeadlineExceeded, err)
+	}
+
+	if n := ticks.Load(); n < 3 {
+		t.Errorf("Expected tick to run about 7 times, received %d", n)
+	}
+	if n := slow.Load(); n != 1 {
+		t.Errorf("Expected slow runs not to overlap, received %d", n)
+	}
+	if len(log.Find("job skipped, previous run still going")) == 0 {
+		t.Error("Expected skipped runs to be logged")
+	}
+	if len(log.Find("job failed")) == 0 {
+		t.Error("Expected panics to be logged as failures")
+	}
+
+	for _, st := range s.Jobs() {
+		if st.Name == "panic" && st.Last.Err == nil {
+			t.Error("Expected the panic to be the error of the last run")
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package sema
+
+import (
+	"context"
+	"sync"
+)
+
+// LockByKey is a set of mutexes by key, e.g. to serialize the requests to
+// each host, or the writes to each file, while different keys proceed in
+// parallel.  Mutexes are created on demand and dropped once unlocked, so
+// keys can be unbounded.  The zero value is ready to use.
+type LockByKey[K comparable] struct {
+	mu    sync.Mutex
+	locks map[K]*keyLock
+}
+
+// keyLock is the mutex of a key, a channel so waiting can be canceled
+type keyLock struct {
+	ch   chan struct{}
+	refs int // holder and waiters
+}
+
+// Lock locks key, returning the function unlocking it, e.g.
+//
+//	unlock := locks.Lock(host)
+//	defer unlock()
+func (l *LockByKey[K]) Lock(key K) (unlock func()) {
+	unlock, _ = l.LockContext(context.Background(), key)
+	return unlock
+}
+
+// LockContext is like Lock, but gives up when ctx is done.
+func (l *LockByKey[K]) LockContext(ctx context.Context, key K) (unlock func(), err error) {
+	k := l.ref(key)
+
+	select {
+	case k.ch <- struct{}{}:
+		return sync.OnceFunc(func() {
+			<-k.ch
+			l.unref(key, k)
+		}), nil
+	case <-ctx.Done():
+		l.unref(key, k)
+		return nil, ctx.Err()
+	}
+}
+
+// TryLock locks key if it is not locked, returning the unlock function and
+// TRUE if it did.
+func (l *LockByKey[K]) TryLock(key K) (unlock func(), ok bool) {
+	k := l.ref(key)
+
+	select {
+	case k.ch <- struct{}{}:
+		return sync.OnceFunc(func() {
+			<-k.ch
+			l.unref(key, k)
+		}), true
+	default:
+		l.unref(key, k)
+		return nil, false
+	}
+}
+
+// Len returns the number of keys locked or waited on.
+func (l *LockByKey[K]) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.locks)
+}
+
+func (l *LockByKey[K]) ref(key K) *keyLock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.locks == nil {
+		l.locks = make(map[K]*keyLock)
+	}
+
+	k, ok := l.locks[key]
+	if !ok {
+		k = &keyLock{ch: make(chan struct{}, 1)}
+		l.locks[key] = k
+	}
+	k.refs++
+
+	return k
+}
+
+func (l *LockByKey[K]) unref(key K, k *keyLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if k.refs--; k.refs == 0 {
+		delete(l.locks, key)
+	}
+}
@@ -0,0 +1,143 @@
+package sema_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/sema"
+)
+
+func TestWeighted(t *testing.T) {
+	s := sema.NewWeighted(10)
+	ctx := context.Background()
+
+	if n, err := s.Acquire(ctx, 6); n != 6 || err != nil {
+		t.Fatalf("Expected 6, received %d (%v)", n, err)
+	}
+	if _, ok := s.TryAcquire(5); ok {
+		t.Error("Expected no room for 5")
+	}
+	if n, ok := s.TryAcquire(4); !ok || n != 4 {
+		t.Error("Expected room for 4")
+	}
+
+	// oversized amounts wait for the semaphore to be empty
+	acquired := make(chan int64)
+	go func() {
+		n, _ := s.Acquire(ctx, 100)
+		acquired <- n
+	}()
+
+	s.Release(6)
+	select {
+	case <-acquired:
+		t.Fatal("Expected to wait for the semaphore to be empty")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	s.Release(4)
+	if n := <-acquired; n != 10 {
+		t.Errorf("Expected 10 to be acquired, received %d", n)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.Acquire(timeout, 1); err != context.DeadlineExceeded {
+		t.Errorf("Expected a timeout, received %v", err)
+	}
+
+	s.Release(10)
+	if _, ok := s.TryAcquire(10); !ok {
+		t.Error("Expected the canceled waiter to be gone")
+	}
+}
+
+func TestWeightedFIFO(t *testing.T) {
+	s := sema.NewWeighted(2)
+	ctx := context.Background()
+	s.Acquire(ctx, 2)
+
+	var order []int64
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, n := range []int64{2, 1} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Acquire(ctx, n)
+			mu.Lock()
+			order = append(order, n)
+			mu.Unlock()
+			s.Release(n)
+		}()
+		time.Sleep(5 * time.Millisecond) // queue 2 first
+	}
+
+	s.Release(2)
+	wg.Wait()
+
+	if len(order) != 2 || order[0] != 2 {
+		t.Errorf("Expected 2 to be served first, received %v", order)
+	}
+}
+
+func TestLockByKey(t *testing.T) {
+	var locks sema.LockByKey[string]
+	var inside [2]atomic.Int32
+	var overlap atomic.Bool
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := []string{"a", "b"}[i%2]
+
+			unlock := locks.Lock(key)
+			defer unlock()
+
+			if inside[i%2].Add(1) > 1 {
+				overlap.Store(true)
+			}
+			time.Sleep(time.Millisecond)
+			inside[i%2].Add(-1)
+		}()
+	}
+	wg.Wait()
+
+	if overlap.Load() {
+		t.Error("Expected a single holder per key")
+	}
+	if locks.Len() != 0 {
+		t.Errorf("Expected no keys left, received %d", locks.Len())
+	}
+}
+
+func TestLockByKeyContext(t *testing.T) {
+	var locks sema.LockByKey[int]
+	unlock := locks.Lock(1)
+
+	if _, ok := locks.TryLock(1); ok {
+		t.Error("Expected 1 to be locked")
+	}
+	if u, ok := locks.TryLock(2); !ok {
+		t.Error("Expected 2 to be free")
+	} else {
+		u()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := locks.LockContext(ctx, 1); err != context.DeadlineExceeded {
+		t.Errorf("Expected a timeout, received %v", err)
+	}
+
+	unlock()
+	unlock() // no-op
+	if locks.Len() != 0 {
+		t.Errorf("Expected no keys left, received %d", locks.Len())
+	}
+}
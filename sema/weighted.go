@@ -0,0 +1,119 @@
+// Package sema has concurrency primitives beyond the sync package: a
+// weighted semaphore, and mutexes by key.
+package sema
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// Weighted is a semaphore of a given size, acquired in amounts (bytes,
+// rows...) rather than one at a time.  Room is handed out first come, first
+// served, so large amounts aren't starved by small ones.
+type Weighted struct {
+	size int64
+
+	mu      sync.Mutex
+	cur     int64
+	waiters list.List // of *waiter
+}
+
+type waiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+// NewWeighted returns a semaphore of the given size.
+func NewWeighted(size int64) *Weighted {
+	return &Weighted{size: size}
+}
+
+// Acquire blocks until there is room for n, or ctx is done.  Amounts larger
+// than the semaphore wait for it to be empty, so they run alone rather than
+// never.  It returns the amount acquired, n bounded by the size, which is
+// the amount to Release.
+func (s *Weighted) Acquire(ctx context.Context, n int64) (int64, error) {
+	n = s.bound(n)
+
+	s.mu.Lock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return n, nil
+	}
+
+	w := &waiter{n, make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return n, nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		select {
+		case <-w.ready:
+			// acquired meanwhile, give it back
+			s.cur -= n
+		default:
+			s.waiters.Remove(elem)
+		}
+		s.notify()
+
+		return 0, ctx.Err()
+	}
+}
+
+// TryAcquire acquires n if there is room right away, returning the amount
+// acquired (see Acquire) and TRUE if it did.
+func (s *Weighted) TryAcquire(n int64) (int64, bool) {
+	n = s.bound(n)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size-s.cur < n || s.waiters.Len() > 0 {
+		return 0, false
+	}
+
+	s.cur += n
+	return n, true
+}
+
+// Release gives back n, acquired earlier.
+func (s *Weighted) Release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cur -= n
+	if s.cur < 0 {
+		panic("sema: released more than acquired")
+	}
+	s.notify()
+}
+
+func (s *Weighted) bound(n int64) int64 {
+	return max(min(n, s.size), 0)
+}
+
+// notify wakes up the waiters that fit, in order
+func (s *Weighted) notify() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+
+		w := front.Value.(*waiter)
+		if s.size-s.cur < w.n {
+			return
+		}
+
+		s.cur += w.n
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,106 @@
+// Package sliceu prepares the inputs of worker pools and collates their
+// results, with the generic slice functions missing from the slices package.
+package sliceu
+
+// Chunk splits input into slices of up to 'size' items, the last one
+// holding whatever is left over.  The chunks share input's backing array.
+//
+// e.g. Chunk([]int{1, 2, 3, 4, 5}, 2) -> [[1 2] [3 4] [5]]
+func Chunk[T any](input []T, size int) [][]T {
+	if size < 1 {
+		size = 1
+	}
+
+	chunks := make([][]T, 0, (len(input)+size-1)/size)
+	for size < len(input) {
+		input, chunks = input[size:], append(chunks, input[:size:size])
+	}
+
+	if len(input) > 0 {
+		chunks = append(chunks, input)
+	}
+
+	return chunks
+}
+
+// Unique returns the items of input without duplicates, keeping the first
+// of each.
+//
+// e.g. Unique([]int{3, 1, 3, 2, 1}) -> [3 1 2]
+func Unique[T comparable](input []T) []T {
+	return UniqueBy(input, func(v T) T { return v })
+}
+
+// UniqueBy returns the items of input with distinct keys, keeping the first
+// of each.
+//
+// e.g. UniqueBy([]string{"a", "B", "A"}, strings.ToLower) -> [a B]
+func UniqueBy[T any, K comparable](input []T, key func(T) K) []T {
+	seen := make(map[K]bool, len(input))
+	out := make([]T, 0, len(input))
+	for _, v := range input {
+		k := key(v)
+		if !seen[k] {
+			seen[k] = true
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
+
+// Map returns fn applied to every item of input.
+//
+// e.g. Map([]int{1, 2, 3}, strconv.Itoa) -> ["1" "2" "3"]
+func Map[T, R any](input []T, fn func(T) R) []R {
+	out := make([]R, len(input))
+	for i, v := range input {
+		out[i] = fn(v)
+	}
+
+	return out
+}
+
+// Filter returns the items of input for which keep is TRUE.
+//
+// e.g. Filter([]int{1, 2, 3, 4}, isEven) -> [2 4]
+func Filter[T any](input []T, keep func(T) bool) []T {
+	var out []T
+	for _, v := range input {
+		if keep(v) {
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
+
+// GroupBy groups the items of input by key, keeping their order within
+// each group.
+//
+// e.g. GroupBy([]string{"ant", "bee", "ape"}, firstLetter) -> map[a:[ant ape] b:[bee]]
+func GroupBy[T any, K comparable](input []T, key func(T) K) map[K][]T {
+	groups := make(map[K][]T)
+	for _, v := range input {
+		k := key(v)
+		groups[k] = append(groups[k], v)
+	}
+
+	return groups
+}
+
+// Partition splits input into the items for which match is TRUE, and the
+// others, e.g. the results that succeeded and those that failed.
+//
+// e.g. Partition([]int{1, 2, 3, 4}, isEven) -> [2 4] [1 3]
+func Partition[T any](input []T, match func(T) bool) (matched, rest []T) {
+	for _, v := range input {
+		if match(v) {
+			matched = append(matched, v)
+		} else {
+			rest = append(rest, v)
+		}
+	}
+
+	return matched, rest
+}
@@ -0,0 +1,69 @@
+package sliceu_test
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sspencer/goal/sliceu"
+)
+
+func isEven(n int) bool {
+	return n%2 == 0
+}
+
+func TestChunk(t *testing.T) {
+	tests := []struct {
+		input []int
+		size  int
+		want  [][]int
+	}{
+		{[]int{1, 2, 3, 4, 5}, 2, [][]int{{1, 2}, {3, 4}, {5}}},
+		{[]int{1, 2}, 0, [][]int{{1}, {2}}},
+		{[]int{1, 2}, 5, [][]int{{1, 2}}},
+		{nil, 3, [][]int{}},
+	}
+
+	for _, tt := range tests {
+		if got := sliceu.Chunk(tt.input, tt.size); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Chunk(%v, %d): expected %v, received %v", tt.input, tt.size, tt.want, got)
+		}
+	}
+}
+
+func TestUnique(t *testing.T) {
+	if got := sliceu.Unique([]int{3, 1, 3, 2, 1}); !reflect.DeepEqual(got, []int{3, 1, 2}) {
+		t.Errorf("Expected [3 1 2], received %v", got)
+	}
+
+	if got := sliceu.UniqueBy([]string{"a", "B", "A", "b"}, strings.ToLower); !reflect.DeepEqual(got, []string{"a", "B"}) {
+		t.Errorf("Expected [a B], received %v", got)
+	}
+}
+
+func TestMapFilter(t *testing.T) {
+	if got := sliceu.Map([]int{1, 2, 3}, strconv.Itoa); !reflect.DeepEqual(got, []string{"1", "2", "3"}) {
+		t.Errorf("Expected [1 2 3], received %q", got)
+	}
+
+	if got := sliceu.Filter([]int{1, 2, 3, 4}, isEven); !reflect.DeepEqual(got, []int{2, 4}) {
+		t.Errorf("Expected [2 4], received %v", got)
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	got := sliceu.GroupBy([]string{"ant", "bee", "ape"}, func(s string) byte { return s[0] })
+	want := map[byte][]string{'a': {"ant", "ape"}, 'b': {"bee"}}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, received %v", want, got)
+	}
+}
+
+func TestPartition(t *testing.T) {
+	even, odd := sliceu.Partition([]int{1, 2, 3, 4, 5}, isEven)
+	if !reflect.DeepEqual(even, []int{2, 4}) || !reflect.DeepEqual(odd, []int{1, 3, 5}) {
+		t.Errorf("Expected [2 4] [1 3 5], received %v %v", even, odd)
+	}
+}
@@ -0,0 +1,166 @@
+package sse
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Option configures a Broker.
+type Option func(*Broker)
+
+// Heartbeat sends a comment to idle clients every d, so proxies don't close
+// their connections.  There are no heartbeats by default.
+func Heartbeat(d time.Duration) Option {
+	return func(b *Broker) {
+		b.heartbeat = d
+	}
+}
+
+// Buffer queues up to n events per client (16 by default, at least 1).
+// Clients too slow to keep up are disconnected, and catch up with History
+// when they reconnect.
+func Buffer(n int) Option {
+	return func(b *Broker) {
+		b.buffer = max(n, 1)
+	}
+}
+
+// History keeps the last n events, resent to clients reconnecting with the
+// ID of an event they received.  There is no history by default.
+func History(n int) Option {
+	return func(b *Broker) {
+		b.history = n
+	}
+}
+
+// Broker publishes events to every client connected to it.  Events without
+// an ID are numbered, so clients can resume after reconnecting.
+type Broker struct {
+	heartbeat time.Duration
+	buffer    int
+	history   int
+
+	mu      sync.Mutex
+	clients map[chan Event]bool
+	events  []Event // history, oldest first
+	next    int64
+}
+
+// NewBroker returns a Broker without clients.
+func NewBroker(opts ...Option) *Broker {
+	b := &Broker{buffer: 16, clients: make(map[chan Event]bool)}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// Publish sends e to every client connected.
+func (b *Broker) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.next++
+	if e.ID == "" {
+		e.ID = strconv.FormatInt(b.next, 10)
+	}
+
+	if b.history > 0 {
+		b.events = append(b.events, e)
+		if len(b.events) > b.history {
+			b.events = b.events[len(b.events)-b.history:]
+		}
+	}
+
+	for ch := range b.clients {
+		select {
+		case ch <- e:
+		default:
+			// too slow, disconnect
+			delete(b.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+// Clients returns the number of clients connected.
+func (b *Broker) Clients() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.clients)
+}
+
+// ServeHTTP streams the events published to the client, until it
+// disconnects.
+func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s, err := NewStream(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ch, missed := b.subscribe(s.LastEventID())
+	defer b.unsubscribe(ch)
+
+	for _, e := range missed {
+		if s.Send(e) != nil {
+			return
+		}
+	}
+
+	var tick <-chan time.Time
+	if b.heartbeat > 0 {
+		t := time.NewTicker(b.heartbeat)
+		defer t.Stop()
+		tick = t.C
+	}
+
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok || s.Send(e) != nil {
+				return
+			}
+		case <-tick:
+			if s.Comment("heartbeat") != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// subscribe adds a client, returning the events it missed since lastID
+func (b *Broker) subscribe(lastID string) (chan Event, []Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var missed []Event
+	if lastID != "" {
+		for i, e := range b.events {
+			if e.ID == lastID {
+				missed = append(missed, b.events[i+1:]...)
+				break
+			}
+		}
+	}
+
+	ch := make(chan Event, b.buffer)
+	b.clients[ch] = true
+	return ch, missed
+}
+
+func (b *Broker) unsubscribe(ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.clients[ch] {
+		delete(b.clients, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,116 @@
+// Package sse serves Server-Sent Events: a Stream writes events to a single
+// client, and a Broker publishes events to every client connected, e.g.
+//
+//	b := sse.NewBroker(sse.Heartbeat(15*time.Second), sse.History(100))
+//	mux.Handle("GET /events", b)
+//	b.Publish(sse.Event{Event: "price", Data: `{"btc":64000}`})
+package sse
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType is the http content type of event streams
+const ContentType = "text/event-stream"
+
+// ErrNoFlush is returned by NewStream for writers that can't flush, which
+// would hold events back.
+var ErrNoFlush = errors.New("sse: response writer can't flush")
+
+// Event is a server-sent event.  Only Data is required.
+type Event struct {
+	ID    string        // sent back by clients in Last-Event-ID when reconnecting
+	Event string        // type of the event, "message" when empty
+	Data  string        // payload, which may span several lines
+	Retry time.Duration // asks clients to wait this long before reconnecting
+}
+
+// WriteTo writes e in the event stream format.
+func (e Event) WriteTo(w io.Writer) (int64, error) {
+	var b bytes.Buffer
+	if e.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", oneLine(e.ID))
+	}
+	if e.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", oneLine(e.Event))
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", e.Retry.Milliseconds())
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(e.Data, "\r\n", "\n"), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteByte('\n')
+
+	return b.WriteTo(w)
+}
+
+// oneLine drops line breaks, which would end a field early
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// Stream writes events to a client, flushing every one.  It is safe for
+// concurrent use.
+type Stream struct {
+	mu  sync.Mutex
+	w   http.ResponseWriter
+	rc  *http.ResponseController
+	req *http.Request
+}
+
+// NewStream starts the event stream of request r, writing its headers.
+func NewStream(w http.ResponseWriter, r *http.Request) (*Stream, error) {
+	rc := http.NewResponseController(w)
+
+	h := w.Header()
+	h.Set("Content-Type", ContentType)
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no") // nginx
+	w.WriteHeader(http.StatusOK)
+
+	if err := rc.Flush(); err != nil {
+		return nil, ErrNoFlush
+	}
+
+	return &Stream{w: w, rc: rc, req: r}, nil
+}
+
+// LastEventID returns the ID of the last event the client received before
+// reconnecting, if any.
+func (s *Stream) LastEventID() string {
+	return s.req.Header.Get("Last-Event-ID")
+}
+
+// Send writes e and flushes it to the client.
+func (s *Stream) Send(e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := e.WriteTo(s.w); err != nil {
+		return err
+	}
+
+	return s.rc.Flush()
+}
+
+// Comment writes a comment, ignored by clients, e.g. to keep the connection
+// alive through proxies.
+func (s *Stream) Comment(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := fmt.Fprintf(s.w, ": %s\n\n", oneLine(text)); err != nil {
+		return err
+	}
+
+	return s.rc.Flush()
+}
@@ -0,0 +1,184 @@
+package sse_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/sse"
+)
+
+func TestEvent(t *testing.T) {
+	tests := []struct {
+		event sse.Event
+		want  string
+	}{
+		{sse.Event{Data: "hi"}, "data: hi\n\n"},
+		{sse.Event{ID: "7", Event: "price", Data: "a\nb", Retry: time.Second}, "id: 7\nevent: price\nretry: 1000\ndata: a\ndata: b\n\n"},
+		{sse.Event{Event: "bad\ntype", Data: ""}, "event: badtype\ndata: \n\n"},
+	}
+
+	for _, tt := range tests {
+		var b bytes.Buffer
+		tt.event.WriteTo(&b)
+		if b.String() != tt.want {
+			t.Errorf("Expected %q, received %q", tt.want, b.String())
+		}
+	}
+}
+
+// readEvents reads n events (or comments) from the stream at url
+func readEvents(t *testing.T, url, lastID string, n int) []string {
+	r, _ := http.NewRequest("GET", url, nil)
+	if lastID != "" {
+		r.Header.Set("Last-Event-ID", lastID)
+	}
+
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != sse.ContentType {
+		t.Errorf("Expected %s, received %s", sse.ContentType, ct)
+	}
+
+	var events []string
+	var current []string
+	scanner := bufio.NewScanner(resp.Body)
+	for len(events) < n && scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			current = append(current, line)
+			continue
+		}
+		events = append(events, strings.Join(current, "|"))
+		current = nil
+	}
+
+	return events
+}
+
+func waitClients(b *sse.Broker, n int) {
+	for b.Clients() != n {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBroker(t *testing.T) {
+	b := sse.NewBroker(sse.History(10))
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+
+	done := make(chan []string)
+	go func() { done <- readEvents(t, srv.URL, "", 2) }()
+
+	waitClients(b, 1)
+	b.Publish(sse.Event{Data: "one"})
+	b.Publish(sse.Event{Event: "two", Data: "2"})
+
+	want := []string{"id: 1|data: one", "id: 2|event: two|data: 2"}
+	if got := <-done; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %q, received %q", want, got)
+	}
+
+	// reconnecting resends the events missed
+	b.Publish(sse.Event{Data: "three"})
+	if got := readEvents(t, srv.URL, "1", 2); len(got) != 2 || got[0] != want[1] || got[1] != "id: 3|data: three" {
+		t.Errorf("Expected events 2 and 3, received %q", got)
+	}
+
+	waitClients(b, 0)
+}
+
+func TestHeartbeat(t *testing.T) {
+	b := sse.NewBroker(sse.Heartbeat(5 * time.Millisecond))
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+
+	if got := readEvents(t, srv.URL, "", 1); len(got) != 1 || got[0] != ": heartbeat" {
+		t.Errorf("Expected a heartbeat, received %q", got)
+	}
+}
+
+func TestBufferMin(t *testing.T) {
+	// unbuffered, a client busy sending an event would be dropped
+	b := sse.NewBroker(sse.Buffer(0))
+
+	w := &blockingWriter{ResponseRecorder: httptest.NewRecorder(), block: make(chan struct{}), writing: make(chan struct{}, 1)}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequestWithContext(ctx, "GET", "/", nil)
+	served := make(chan bool)
+	go func() {
+		b.ServeHTTP(w, r)
+		served <- true
+	}()
+
+	waitClients(b, 1)
+	w.blocking.Store(true)
+	b.Publish(sse.Event{Data: "one"})
+	<-w.writing
+	b.Publish(sse.Event{Data: "two"})
+
+	if b.Clients() != 1 {
+		t.Errorf("Expected the client to be kept, received %d clients", b.Clients())
+	}
+
+	close(w.block)
+	cancel()
+	<-served
+}
+
+func TestSlowClient(t *testing.T) {
+	b := sse.NewBroker(sse.Buffer(1))
+
+	// a client that never reads, its handler blocked on the first event
+	w := &blockingWriter{ResponseRecorder: httptest.NewRecorder(), block: make(chan struct{})}
+	r := httptest.NewRequest("GET", "/", nil)
+	served := make(chan bool)
+	go func() {
+		b.ServeHTTP(w, r)
+		served <- true
+	}()
+
+	waitClients(b, 1)
+	w.blocking.Store(true)
+	for i := 0; i < 5; i++ {
+		b.Publish(sse.Event{Data: "x"})
+	}
+
+	if b.Clients() != 0 {
+		t.Errorf("Expected the slow client to be dropped, received %d clients", b.Clients())
+	}
+
+	close(w.block)
+	<-served
+}
+
+// blockingWriter blocks writes once blocking is set, until block is closed,
+// signaling blocked writes on writing when not nil
+type blockingWriter struct {
+	*httptest.ResponseRecorder
+	blocking atomic.Bool
+	block    chan struct{}
+	writing  chan struct{}
+}
+
+func (w *blockingWriter) Write(b []byte) (int, error) {
+	if w.blocking.Load() {
+		if w.writing != nil {
+			select {
+			case w.writing <- struct{}{}:
+			default:
+			}
+		}
+		<-w.block
+	}
+	return w.ResponseRecorder.Write(b)
+}
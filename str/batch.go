@@ -0,0 +1,605 @@
+package str
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sspencer/goal/ratelimit"
+	"github.com/sspencer/goal/sema"
+)
+
+// job is a single work item, with its position in the input
+type job[T any] struct {
+	index  int
+	input  T
+	queued time.Time // when submitted to a Pool, batches are queued at once
+}
+
+// feed sends the input slice to the workers, until ctx is done.  The
+// index of every input is its position, unless indexes are given.
+func feed[T any](ctx context.Context, input []T, indexes []int) <-chan job[T] {
+	jobs := make(chan job[T])
+
+	go func() {
+		defer close(jobs)
+		for i, in := range input {
+			if indexes != nil {
+				i = indexes[i]
+			}
+
+			select {
+			case jobs <- job[T]{index: i, input: in}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return jobs
+}
+
+// source hands out the jobs of a slice to the workers, which claim the next
+// one with an atomic add.  Unlike feed, there is no goroutine handing over
+// every job on a channel, which dominates for sub-millisecond items.
+type source[T any] struct {
+	ctx     context.Context
+	input   []T
+	indexes []int
+	next    atomic.Int64
+}
+
+// take returns the next job, or FALSE once there are none or ctx is done
+func (s *source[T]) take() (job[T], bool) {
+	if s.ctx.Err() != nil {
+		return job[T]{}, false
+	}
+
+	i := int(s.next.Add(1) - 1)
+	if i >= len(s.input) {
+		return job[T]{}, false
+	}
+
+	j := job[T]{index: i, input: s.input[i]}
+	if s.indexes != nil {
+		j.index = s.indexes[i]
+	}
+
+	return j, true
+}
+
+// feedChan sends input to the workers as it arrives, until input is
+// closed or ctx is done.  Duplicates are skipped with the Dedupe options,
+// as are inputs already done with the Resume option, and are not counted
+// in the index.
+func feedChan[T any](ctx context.Context, input <-chan T, o *options) <-chan job[T] {
+	jobs := make(chan job[T])
+
+	go func() {
+		defer close(jobs)
+
+		seen := make(map[any]bool)
+		for i := 0; ; {
+			var in T
+			var ok bool
+
+			select {
+			case in, ok = <-input:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+
+			if o.checkpoint != nil && o.checkpoint.Done(in) {
+				continue
+			}
+
+			if o.dedupeKey != nil {
+				key := o.dedupeKey(in)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+
+			if o.metrics != nil {
+				o.metrics.Queued(1)
+			}
+
+			select {
+			case jobs <- job[T]{index: i, input: in}:
+				i++
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return jobs
+}
+
+// progress counts completed items for the OnProgress option
+type progress struct {
+	mu    sync.Mutex
+	done  int
+	total int
+	fn    func(done, total int)
+}
+
+func (p *progress) add() {
+	if p.fn == nil {
+		return
+	}
+
+	p.mu.Lock()
+	p.done++
+	p.fn(p.done, p.total)
+	p.mu.Unlock()
+}
+
+// ErrIncomplete is the cause of batches stopped by the BatchTimeout option.
+var ErrIncomplete = errors.New("batch incomplete: timed out")
+
+// batch is a single run of the worker pool
+type batch[T, R any] struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	fn     func(context.Context, T) (R, error)
+	o      *options
+	total  int // number of inputs, -1 when unknown
+	start  time.Time
+
+	jobs    <-chan job[T]
+	src     *source[T] // instead of jobs, for slices
+	results chan Result[T, R]
+	prog    *progress
+	limit   *ratelimit.TokenBucket
+	sem     *sema.Weighted
+	stats   *statsCollector
+	wg      sync.WaitGroup
+	size    atomic.Int32 // current number of workers
+	ids     atomic.Int32 // next worker ID
+	scaling bool         // set by pools with the Scale option
+
+	// set by pools to start and complete the Futures of items
+	begin   func(context.Context, int) (context.Context, bool)
+	settle  func(Result[T, R])
+	discard func() bool // skips sending results nobody reads
+
+	// inputs are fed until feedCtx is done, which differs from ctx when
+	// in-flight items may finish after the BatchTimeout
+	feedCtx  context.Context
+	stopFeed func()
+
+	done chan struct{} // closed once every worker exits
+}
+
+func newBatch[T, R any](ctx context.Context, fn func(context.Context, T) (R, error), o *options, total int) *batch[T, R] {
+	ctx, cancel := context.WithCancelCause(ctx)
+	b := &batch[T, R]{
+		ctx:      ctx,
+		cancel:   cancel,
+		fn:       wrap(fn, o),
+		o:        o,
+		total:    total,
+		start:    time.Now(),
+		prog:     &progress{total: total, fn: o.onProgress},
+		limit:    o.limiter(),
+		sem:      o.semaphore(),
+		feedCtx:  ctx,
+		stopFeed: func() {},
+		done:     make(chan struct{}),
+	}
+
+	// misconfigured batches fail at once, like those whose Setup fails
+	if err := checkTypes[T](o); err != nil {
+		cancel(err)
+	}
+
+	// durations are kept for every item, so only when asked for
+	if o.stats != nil {
+		b.stats = newStatsCollector()
+	}
+
+	if o.batchTimeout > 0 {
+		if o.drain {
+			b.feedCtx, b.stopFeed = context.WithTimeoutCause(ctx, o.batchTimeout, ErrIncomplete)
+		} else {
+			timer := time.AfterFunc(o.batchTimeout, func() { cancel(ErrIncomplete) })
+			b.stopFeed = func() { timer.Stop() }
+		}
+	}
+
+	return b
+}
+
+// finish releases the BatchTimeout, fills in the CollectStats option and
+// writes the Resume checkpoint
+func (b *batch[T, R]) finish() {
+	b.stopFeed()
+
+	if b.o.checkpoint != nil {
+		b.o.checkpoint.flush()
+	}
+
+	if b.o.stats != nil {
+		*b.o.stats = b.stats.snapshot()
+	}
+}
+
+// runSlice is like run, for the input of a slice.  The index of every input
+// is its position, unless indexes are given.
+func (b *batch[T, R]) runSlice(numWorkers int, input []T, indexes []int, streaming bool) <-chan Result[T, R] {
+	if b.o.metrics != nil {
+		b.o.metrics.Queued(len(input))
+	}
+
+	if b.o.shardKey != nil {
+		return b.run(numWorkers, feed(b.feedCtx, input, indexes), streaming)
+	}
+
+	b.src = &source[T]{ctx: b.feedCtx, input: input, indexes: indexes}
+	return b.run(numWorkers, nil, streaming)
+}
+
+// run starts (n) workers calling fn on every job.  Results are sent in the
+// order they complete, and the channel is closed once every worker exits.
+// Results completing after the batch is canceled are dropped, since nobody
+// is waiting for them.  A streaming batch is released once it is closed,
+// otherwise the caller must cancel it.
+func (b *batch[T, R]) run(numWorkers int, jobs <-chan job[T], streaming bool) <-chan Result[T, R] {
+	b.jobs = jobs
+	b.results = make(chan Result[T, R], numWorkers)
+
+	if b.scaling {
+		numWorkers = min(max(numWorkers, b.o.minWorkers), b.o.maxWorkers)
+	}
+
+	b.size.Store(int32(numWorkers))
+	b.wg.Add(numWorkers)
+
+	if b.o.shardKey != nil {
+		for _, shard := range b.shard(numWorkers) {
+			go b.worker(shard)
+		}
+	} else {
+		for w := 0; w < numWorkers; w++ {
+			go b.worker(b.jobs)
+		}
+	}
+
+	go func() {
+		b.wg.Wait()
+		if streaming {
+			b.finish()
+		}
+		close(b.done)
+		close(b.results)
+		if streaming {
+			b.cancel(nil)
+		}
+	}()
+
+	return b.results
+}
+
+// worker processes jobs until there are no more, or the batch is canceled.
+// With the Scale or IdleTimeout options, it also exits after being idle for
+// a while, as long as there are more than the minimum number of workers.
+func (b *batch[T, R]) worker(jobs <-chan job[T]) {
+	defer b.wg.Done()
+
+	ctx, cleanup, err := b.startWorker()
+	if err != nil {
+		b.cancel(err)
+		return
+	}
+	defer cleanup()
+
+	if b.src != nil {
+		for {
+			j, ok := b.src.take()
+			if !ok || !b.process(ctx, j) {
+				return
+			}
+		}
+	}
+
+	var idle <-chan time.Time
+	var timer *time.Timer
+	if b.scaling {
+		timer = time.NewTimer(b.o.idleTimeout)
+		defer timer.Stop()
+		idle = timer.C
+	}
+
+	for {
+		select {
+		case j, ok := <-jobs:
+			if !ok {
+				return
+			}
+
+			if !b.process(ctx, j) {
+				return
+			}
+
+			if timer != nil {
+				timer.Reset(b.o.idleTimeout)
+			}
+		case <-idle:
+			if b.shrink() {
+				if len(jobs) > 0 {
+					b.grow() // submitted while shrinking
+				}
+				return
+			}
+			timer.Reset(b.o.idleTimeout)
+		}
+	}
+}
+
+// process runs a single job and sends its result, returning FALSE if the
+// batch was canceled
+func (b *batch[T, R]) process(ctx context.Context, j job[T]) bool {
+	if b.begin != nil {
+		var ok bool
+		if ctx, ok = b.begin(ctx, j.index); !ok {
+			if b.o.metrics != nil {
+				b.o.metrics.Started(time.Since(b.queued(j)))
+				b.o.metrics.Done(0, ErrCanceled)
+			}
+			return b.send(Result[T, R]{Index: j.index, Input: j.input, Err: ErrCanceled})
+		}
+	}
+
+	var cost int64
+	if b.sem != nil {
+		var err error
+		if cost, err = b.sem.Acquire(ctx, b.o.cost(j.input)); err != nil {
+			return false
+		}
+	}
+
+	if b.o.stagger(ctx) != nil || b.limit != nil && b.limit.Wait(ctx) != nil {
+		if b.sem != nil {
+			b.sem.Release(cost)
+		}
+		return false
+	}
+
+	var start time.Time
+	if b.stats != nil || b.o.logger != nil || b.o.metrics != nil {
+		start = time.Now()
+	}
+
+	if b.o.metrics != nil {
+		b.o.metrics.Started(start.Sub(b.queued(j)))
+	}
+
+	if b.o.logger != nil {
+		b.o.logger.Log(ctx, slog.LevelDebug, "item started", "index", j.index, "input", j.input, "worker", WorkerID(ctx))
+	}
+
+	r := do(ctx, b.fn, j, b.o)
+	if b.sem != nil {
+		b.sem.Release(cost)
+	}
+	b.record(ctx, r, start)
+
+	if r.Err != nil && b.o.failFast {
+		b.cancel(r.Err)
+	}
+
+	return b.send(r)
+}
+
+// record reports the result of a job to the options asking for it
+func (b *batch[T, R]) record(ctx context.Context, r Result[T, R], start time.Time) {
+	var elapsed time.Duration
+	if !start.IsZero() {
+		elapsed = time.Since(start)
+	}
+
+	if b.o.metrics != nil {
+		b.o.metrics.Done(elapsed, r.Err)
+	}
+	if b.o.checkpoint != nil && r.Err == nil {
+		b.o.checkpoint.record(r.Input, r.Output)
+	}
+	if b.stats != nil {
+		b.stats.record(WorkerID(ctx), elapsed, r.Attempts, r.Err)
+	}
+	if b.o.logger != nil {
+		b.log(ctx, r, elapsed)
+	}
+	b.prog.add()
+}
+
+// queued returns when a job was queued: when it was submitted to a Pool,
+// or when the batch started
+func (b *batch[T, R]) queued(j job[T]) time.Time {
+	if j.queued.IsZero() {
+		return b.start
+	}
+
+	return j.queued
+}
+
+// log records the result of an item on the Logger option
+func (b *batch[T, R]) log(ctx context.Context, r Result[T, R], d time.Duration) {
+	attrs := []any{"index", r.Index, "input", r.Input, "worker", WorkerID(ctx), "duration", d, "attempts", r.Attempts}
+	if r.Err != nil {
+		b.o.logger.Log(ctx, slog.LevelWarn, "item failed", append(attrs, "error", r.Err)...)
+		return
+	}
+
+	b.o.logger.Log(ctx, slog.LevelDebug, "item done", attrs...)
+}
+
+// send completes the Future of a pool item and sends its result, returning
+// FALSE if the batch was canceled
+func (b *batch[T, R]) send(r Result[T, R]) bool {
+	if b.settle != nil {
+		b.settle(r)
+	}
+
+	// results are buffered, so check first to drop those completing late
+	if b.ctx.Err() != nil {
+		return false
+	}
+
+	if b.discard != nil && b.discard() {
+		return true
+	}
+
+	select {
+	case b.results <- r:
+		return true
+	case <-b.ctx.Done():
+		return false
+	}
+}
+
+// shard routes every job to one of (n) channels, by the hash of its
+// ShardBy key, so jobs with the same key are processed sequentially by the
+// same worker
+func (b *batch[T, R]) shard(numWorkers int) []chan job[T] {
+	shards := make([]chan job[T], numWorkers)
+	for i := range shards {
+		shards[i] = make(chan job[T])
+	}
+
+	go func() {
+		defer func() {
+			for _, shard := range shards {
+				close(shard)
+			}
+		}()
+
+		for j := range b.jobs {
+			h := fnv.New32a()
+			h.Write([]byte(b.o.shardKey(j.input)))
+
+			select {
+			case shards[h.Sum32()%uint32(numWorkers)] <- j:
+			case <-b.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return shards
+}
+
+// grow adds a worker, unless the Scale maximum is reached
+func (b *batch[T, R]) grow() bool {
+	if !b.scaling {
+		return false
+	}
+
+	for {
+		n := b.size.Load()
+		if int(n) >= b.o.maxWorkers {
+			return false
+		}
+
+		if b.size.CompareAndSwap(n, n+1) {
+			b.wg.Add(1)
+			go b.worker(b.jobs)
+			return true
+		}
+	}
+}
+
+// shrink removes a worker, unless the Scale minimum is reached
+func (b *batch[T, R]) shrink() bool {
+	for {
+		n := b.size.Load()
+		if int(n) <= b.o.minWorkers {
+			return false
+		}
+
+		if b.size.CompareAndSwap(n, n-1) {
+			return true
+		}
+	}
+}
+
+// gather collects results until the workers finish or the batch is
+// canceled, in which case it returns promptly with the results so far.
+// When the total number of inputs is known, a batch that fully completed
+// is not canceled, even if ctx expired meanwhile.
+func (b *batch[T, R]) gather(results <-chan Result[T, R]) ([]Result[T, R], error) {
+	defer b.finish()
+
+	// not nil when every input is skipped, like the output of an empty input
+	output := make([]Result[T, R], 0, max(b.total, 0))
+
+	for {
+		if b.ctx.Err() != nil {
+			output = collect(output, results)
+			return inOrder(b.o, output), b.err(output, true)
+		}
+
+		select {
+		case r, ok := <-results:
+			if !ok {
+				return inOrder(b.o, output), b.err(output, len(output) != b.total)
+			}
+			output = append(output, r)
+		case <-b.ctx.Done():
+			output = collect(output, results)
+			return inOrder(b.o, output), b.err(output, true)
+		}
+	}
+}
+
+// err returns the error of a batch.  Failing fast, it is the cause of the
+// cancellation (the first item error, or the context's error).  Otherwise
+// every item error is joined, along with the context's error if canceled.
+func (b *batch[T, R]) err(output []Result[T, R], canceled bool) error {
+	var cause error
+	if canceled || b.o.failFast {
+		cause = context.Cause(b.feedCtx)
+	}
+
+	if b.o.failFast {
+		if cause == nil {
+			for _, r := range output {
+				if r.Err != nil {
+					return r.Err
+				}
+			}
+		}
+		return cause
+	}
+
+	var errs []error
+	if cause != nil {
+		errs = append(errs, cause)
+	}
+
+	return join(itemErrors(output, errs))
+}
+
+// collect appends the results that are already done, without blocking
+func collect[T, R any](output []Result[T, R], results <-chan Result[T, R]) []Result[T, R] {
+	for {
+		select {
+		case r, ok := <-results:
+			if !ok {
+				return output
+			}
+			output = append(output, r)
+		default:
+			return output
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package str
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// accents maps accented Latin letters to their plain ASCII forms
+var accents = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "A", 'Å': "A", 'Ā': "A", 'Ă': "A", 'Ą': "A",
+	'æ': "ae", 'Æ': "AE", 'ç': "c", 'ć': "c", 'č': "c", 'Ç': "C", 'Ć': "C", 'Č': "C",
+	'ď': "d", 'đ': "d", 'ð': "d", 'Ď': "D", 'Đ': "D", 'Ð': "D",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ę': "e", 'ě': "e",
+	'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ē': "E", 'Ę': "E", 'Ě': "E",
+	'ğ': "g", 'Ğ': "G", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'ı': "i",
+	'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I", 'Ī': "I", 'İ': "I",
+	'ł': "l", 'Ł': "L", 'ñ': "n", 'ń': "n", 'ň': "n", 'Ñ': "N", 'Ń': "N", 'Ň': "N",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o",
+	'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ö': "O", 'Ø': "O", 'Ō': "O", 'Ő': "O",
+	'œ': "oe", 'Œ': "OE", 'ř': "r", 'Ř': "R", 'ś': "s", 'š': "s", 'ş': "s", 'Ś': "S", 'Š': "S", 'Ş': "S",
+	'ß': "ss", 'ť': "t", 'ţ': "t", 'Ť': "T", 'Ţ': "T", 'þ': "th", 'Þ': "TH",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u",
+	'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "U", 'Ū': "U", 'Ů': "U", 'Ű': "U",
+	'ý': "y", 'ÿ': "y", 'Ý': "Y", 'Ÿ': "Y", 'ź': "z", 'ż': "z", 'ž': "z", 'Ź': "Z", 'Ż': "Z", 'Ž': "Z",
+}
+
+// FoldAccents replaces accented Latin letters with their plain forms.
+//
+// e.g. FoldAccents("Crème Brûlée") -> "Creme Brulee"
+func FoldAccents(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if plain, ok := accents[r]; ok {
+			b.WriteString(plain)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// Slugify makes s fit in a URL: accents are folded, letters lowercased, and
+// anything but ASCII letters and digits collapsed into single dashes.
+//
+// e.g. Slugify("Hello, Wörld!") -> "hello-world"
+func Slugify(s string) string {
+	return strings.Join(words(strings.ToLower(FoldAccents(s)), true), "-")
+}
+
+// CamelCase joins the words of s, capitalizing all but the first.
+//
+// e.g. CamelCase("user_id") -> "userId", CamelCase("HTTP server") -> "httpServer"
+func CamelCase(s string) string {
+	var b strings.Builder
+	for i, w := range words(s, false) {
+		w = strings.ToLower(w)
+		if i > 0 {
+			w = capitalize(w)
+		}
+		b.WriteString(w)
+	}
+
+	return b.String()
+}
+
+// PascalCase joins the words of s, capitalizing all of them.
+//
+// e.g. PascalCase("user_id") -> "UserId"
+func PascalCase(s string) string {
+	var b strings.Builder
+	for _, w := range words(s, false) {
+		b.WriteString(capitalize(strings.ToLower(w)))
+	}
+
+	return b.String()
+}
+
+// SnakeCase joins the lowercased words of s with underscores.
+//
+// e.g. SnakeCase("userID") -> "user_id", SnakeCase("HTTPServer") -> "http_server"
+func SnakeCase(s string) string {
+	return strings.ToLower(strings.Join(words(s, false), "_"))
+}
+
+// KebabCase joins the lowercased words of s with dashes.
+//
+// e.g. KebabCase("userID") -> "user-id"
+func KebabCase(s string) string {
+	return strings.ToLower(strings.Join(words(s, false), "-"))
+}
+
+// Truncate shortens s to at most n characters (runes), ending it with "…"
+// when cut.  Words are kept whole when a space is close enough.
+//
+// e.g. Truncate("The quick brown fox", 12) -> "The quick…"
+func Truncate(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	cut := string([]rune(s)[:n-1])
+	if i := strings.LastIndexFunc(cut, unicode.IsSpace); i > 0 && utf8.RuneCountInString(cut[:i]) > (n-1)*2/3 {
+		cut = cut[:i]
+	}
+
+	return strings.TrimRightFunc(cut, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + "…"
+}
+
+// words splits s into words at anything but letters and digits, and at case
+// changes ("userID" -> user, ID; "HTTPServer" -> HTTP, Server).  With
+// ascii, only ASCII letters and digits are kept.
+func words(s string, ascii bool) []string {
+	isWord := func(r rune) bool {
+		if ascii {
+			return r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))
+		}
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+
+	var out []string
+	runes := []rune(s)
+	start := -1
+	for i, r := range runes {
+		if !isWord(r) {
+			if start >= 0 {
+				out = append(out, string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+
+		if start >= 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				out = append(out, string(runes[start:i]))
+				start = i
+			}
+		}
+
+		if start < 0 {
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		out = append(out, string(runes[start:]))
+	}
+
+	return out
+}
+
+// capitalize uppercases the first letter of w
+func capitalize(w string) string {
+	r, size := utf8.DecodeRuneInString(w)
+	return string(unicode.ToUpper(r)) + w[size:]
+}
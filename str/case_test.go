@@ -0,0 +1,82 @@
+package str_test
+
+import (
+	"testing"
+
+	"github.com/sspencer/goal/str"
+)
+
+func TestCase(t *testing.T) {
+	tests := []struct {
+		input                       string
+		camel, pascal, snake, kebab string
+	}{
+		{"user_id", "userId", "UserId", "user_id", "user-id"},
+		{"userID", "userId", "UserId", "user_id", "user-id"},
+		{"HTTPServer", "httpServer", "HttpServer", "http_server", "http-server"},
+		{"Hello World", "helloWorld", "HelloWorld", "hello_world", "hello-world"},
+		{"  kebab-case--input ", "kebabCaseInput", "KebabCaseInput", "kebab_case_input", "kebab-case-input"},
+		{"version2Update", "version2Update", "Version2Update", "version2_update", "version2-update"},
+		{"", "", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := str.CamelCase(tt.input); got != tt.camel {
+			t.Errorf("CamelCase(%q): expected %q, received %q", tt.input, tt.camel, got)
+		}
+		if got := str.PascalCase(tt.input); got != tt.pascal {
+			t.Errorf("PascalCase(%q): expected %q, received %q", tt.input, tt.pascal, got)
+		}
+		if got := str.SnakeCase(tt.input); got != tt.snake {
+			t.Errorf("SnakeCase(%q): expected %q, received %q", tt.input, tt.snake, got)
+		}
+		if got := str.KebabCase(tt.input); got != tt.kebab {
+			t.Errorf("KebabCase(%q): expected %q, received %q", tt.input, tt.kebab, got)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		input, output string
+	}{
+		{"Hello, Wörld!", "hello-world"},
+		{"Crème Brûlée -- 2024", "creme-brulee-2024"},
+		{"  Straße  ", "strasse"},
+		{"日本語 title", "title"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := str.Slugify(tt.input); got != tt.output {
+			t.Errorf("Slugify(%q): expected %q, received %q", tt.input, tt.output, got)
+		}
+	}
+}
+
+func TestFoldAccents(t *testing.T) {
+	if got := str.FoldAccents("Crème Brûlée, Ångström, Łódź"); got != "Creme Brulee, Angstrom, Lodz" {
+		t.Errorf("Expected plain letters, received %q", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		input  string
+		n      int
+		output string
+	}{
+		{"The quick brown fox", 12, "The quick…"},
+		{"The quick brown fox", 19, "The quick brown fox"},
+		{"Supercalifragilistic", 6, "Super…"},
+		{"héllo wörld", 8, "héllo…"},
+		{"Hello, world", 7, "Hello…"},
+		{"abc", 0, ""},
+	}
+
+	for _, tt := range tests {
+		if got := str.Truncate(tt.input, tt.n); got != tt.output {
+			t.Errorf("Truncate(%q, %d): expected %q, received %q", tt.input, tt.n, tt.output, got)
+		}
+	}
+}
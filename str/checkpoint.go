@@ -0,0 +1,142 @@
+package str
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// checkpointInterval is how often completed items are written to disk
+const checkpointInterval = time.Second
+
+// Checkpoint records which inputs of a batch completed successfully, along
+// with a digest of their output, so a rerun after a crash can skip them
+// (see Resume).  Inputs are identified by a digest of their JSON encoding.
+type Checkpoint struct {
+	mu   sync.Mutex
+	f    *os.File
+	w    *bufio.Writer
+	done map[string]string // output digest by input digest
+	last time.Time
+}
+
+// OpenCheckpoint opens the checkpoint file at path, creating it if needed.
+func OpenCheckpoint(path string) (*Checkpoint, error) {
+	done := make(map[string]string)
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if in, out, ok := strings.Cut(line, " "); ok {
+			done[in] = out
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Checkpoint{f: f, w: bufio.NewWriter(f), done: done, last: time.Now()}, nil
+}
+
+// Resume skips the inputs already done according to c, and records the
+// inputs completing successfully in c.  Skipped inputs get no Result.
+// Completed inputs are written to disk every second, and once the batch is
+// done.
+func Resume(c *Checkpoint) Option {
+	return func(o *options) {
+		o.checkpoint = c
+	}
+}
+
+// Done returns TRUE if input completed in an earlier run.
+func (c *Checkpoint) Done(input any) bool {
+	key := digest(input)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.done[key]
+	return ok
+}
+
+// Output returns the digest of the output of input, if it is done.  A rerun
+// can compare it to detect outputs that changed.
+func (c *Checkpoint) Output(input any) (string, bool) {
+	key := digest(input)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out, ok := c.done[key]
+	return out, ok
+}
+
+// Len returns the number of inputs done.
+func (c *Checkpoint) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.done)
+}
+
+// Close writes the inputs done so far, and closes the file.
+func (c *Checkpoint) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.w.Flush(); err != nil {
+		c.f.Close()
+		return err
+	}
+
+	return c.f.Close()
+}
+
+// record adds a completed input, writing to disk at most every second
+func (c *Checkpoint) record(input, output any) {
+	in, out := digest(input), digest(output)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.done[in] = out
+	fmt.Fprintf(c.w, "%s %s\n", in, out)
+
+	if time.Since(c.last) >= checkpointInterval {
+		c.w.Flush()
+		c.last = time.Now()
+	}
+}
+
+// flush writes the inputs done so far to disk
+func (c *Checkpoint) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.w.Flush()
+	c.last = time.Now()
+}
+
+// digest identifies a value by the hash of its JSON encoding, or of its Go
+// syntax when it can't be encoded
+func digest(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%#v", v))
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
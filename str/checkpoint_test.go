@@ -0,0 +1,70 @@
+package str_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/sspencer/goal/str"
+)
+
+func TestResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "batch.checkpoint")
+	input := []int{1, 2, 3, 4, 5}
+
+	run := func(fail int) []int {
+		c, err := str.OpenCheckpoint(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+
+		results, _ := str.MapCtx(context.Background(), 2, input, func(_ context.Context, n int) (int, error) {
+			if n == fail {
+				return 0, errors.New("crash")
+			}
+			return n * 10, nil
+		}, str.Resume(c))
+
+		var ran []int
+		for _, r := range results {
+			ran = append(ran, r.Input)
+		}
+		sort.Ints(ran)
+		return ran
+	}
+
+	if ran := run(4); len(ran) != 5 {
+		t.Errorf("Expected every input to run, ran %v", ran)
+	}
+
+	// only the failed input runs again
+	if ran := run(0); len(ran) != 1 || ran[0] != 4 {
+		t.Errorf("Expected only 4 to run again, ran %v", ran)
+	}
+
+	if ran := run(0); len(ran) != 0 {
+		t.Errorf("Expected nothing to run, ran %v", ran)
+	}
+
+	c, _ := str.OpenCheckpoint(path)
+	defer c.Close()
+
+	if c.Len() != 5 || !c.Done(3) || c.Done(6) {
+		t.Errorf("Unexpected checkpoint of %d inputs", c.Len())
+	}
+
+	if a, _ := c.Output(1); a == "" {
+		t.Error("Expected the digest of an output")
+	}
+
+	// like an empty input, everything skipped is no results, not nil
+	results, err := str.MapCtx(context.Background(), 2, input, func(_ context.Context, n int) (int, error) {
+		return n, nil
+	}, str.Resume(c))
+	if results == nil || len(results) != 0 || err != nil {
+		t.Errorf("Expected empty results, received %v, %v", results, err)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"math"
 	"strconv"
 	"strings"
+
+	"github.com/sspencer/goal/sliceu"
 )
 
 // ChunkString chops string into as many equal size parts as possible,
@@ -43,6 +45,11 @@ func ChunkString(buf string, limit int) []string {
 	return parts
 }
 
+// Chunk is sliceu.Chunk, kept for compatibility.
+func Chunk[T any](input []T, size int) [][]T {
+	return sliceu.Chunk(input, size)
+}
+
 // Comma creates a human readable integer by adding commas
 // for thousands separators.
 //
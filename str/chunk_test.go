@@ -1,10 +1,11 @@
 package str_test
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
-	"github.com/sspencer/str"
+	"github.com/sspencer/goal/str"
 )
 
 func BenchmarkChunk(b *testing.B) {
@@ -75,3 +76,48 @@ func TestCommas(t *testing.T) {
 		})
 	}
 }
+
+func TestChunk(t *testing.T) {
+	tt := []struct {
+		size   int
+		input  []int
+		output [][]int
+	}{
+		{2, []int{}, [][]int{}},
+		{2, []int{1, 2, 3, 4}, [][]int{{1, 2}, {3, 4}}},
+		{2, []int{1, 2, 3, 4, 5}, [][]int{{1, 2}, {3, 4}, {5}}},
+		{5, []int{1, 2, 3}, [][]int{{1, 2, 3}}},
+		{0, []int{1, 2}, [][]int{{1}, {2}}},
+	}
+
+	for _, tc := range tt {
+		t.Run(fmt.Sprintf("Size_%d_Len_%d", tc.size, len(tc.input)), func(t *testing.T) {
+			r := str.Chunk(tc.input, tc.size)
+			if fmt.Sprint(r) != fmt.Sprint(tc.output) {
+				t.Errorf("For %v (size=%d), expected %v, not %v", tc.input, tc.size, tc.output, r)
+			}
+		})
+	}
+}
+
+func TestMapChunks(t *testing.T) {
+	input := []int{1, 2, 3, 4, 5, 6, 7}
+	results, err := str.MapChunks(context.Background(), 2, 3, input, func(_ context.Context, chunk []int) (int, error) {
+		sum := 0
+		for _, n := range chunk {
+			sum += n
+		}
+		return sum, nil
+	}, str.Ordered())
+
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	expected := []int{6, 15, 7}
+	for i, r := range results {
+		if r.Output != expected[i] {
+			t.Errorf("For chunk %d, expected %d, not %d", i, expected[i], r.Output)
+		}
+	}
+}
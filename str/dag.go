@@ -0,0 +1,240 @@
+package str
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrCycle is returned by MapDAG when the dependencies of nodes form a cycle.
+var ErrCycle = errors.New("dependency cycle")
+
+// ErrUnknownNode is returned by MapDAG when a node depends on a missing ID,
+// or IDs are not unique.
+var ErrUnknownNode = errors.New("unknown or duplicate node")
+
+// Node is an input of MapDAG, identified by ID, which runs once every node
+// it depends on has succeeded.
+type Node[K comparable, T any] struct {
+	ID    K
+	Input T
+	Deps  []K
+}
+
+// DependencyError is the error of nodes that didn't run because a node they
+// depend on (directly or not) failed.
+type DependencyError struct {
+	Dependency any // ID of the node that failed
+	Err        error
+}
+
+// Error implements the Error method for DependencyErrors
+func (e *DependencyError) Error() string {
+	return fmt.Sprintf("dependency %v failed: %v", e.Dependency, e.Err)
+}
+
+// Unwrap returns the error of the failed dependency
+func (e *DependencyError) Unwrap() error {
+	return e.Err
+}
+
+// MapDAG is like MapCtx for inputs that depend on each other, e.g. build
+// steps or ETL jobs.  Every node starts as soon as all its dependencies
+// succeeded, up to 'numWorkers' at a time.  When a node fails, the nodes
+// depending on it are not run, and fail with a DependencyError instead.
+// Result.Index is the position of the node in nodes.  Nodes depending on
+// unknown IDs, and cycles, fail the whole batch before anything runs.
+func MapDAG[K comparable, T, R any](ctx context.Context, numWorkers int, nodes []Node[K, T], fn func(context.Context, T) (R, error), opts ...Option) ([]Result[T, R], error) {
+	if len(nodes) == 0 {
+		return []Result[T, R]{}, nil
+	}
+
+	g, err := newGraph(nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
+	numWorkers = boundWorkers(numWorkers, len(nodes))
+
+	b := newBatch(ctx, fn, o, len(nodes))
+	defer b.cancel(nil)
+
+	if o.metrics != nil {
+		o.metrics.Queued(len(nodes))
+	}
+
+	jobs := make(chan job[T])
+	return b.gather(schedule(g, b, nodes, jobs, b.run(numWorkers, jobs, false)))
+}
+
+// graph holds the dependencies of MapDAG nodes, by position
+type graph struct {
+	dependents [][]int
+	pending    []int // number of dependencies not done yet
+	skipped    []bool
+	ids        []any
+}
+
+func newGraph[K comparable, T any](nodes []Node[K, T]) (*graph, error) {
+	positions := make(map[K]int, len(nodes))
+	for i, n := range nodes {
+		if _, ok := positions[n.ID]; ok {
+			return nil, fmt.Errorf("%w: %v", ErrUnknownNode, n.ID)
+		}
+		positions[n.ID] = i
+	}
+
+	g := &graph{
+		dependents: make([][]int, len(nodes)),
+		pending:    make([]int, len(nodes)),
+		skipped:    make([]bool, len(nodes)),
+		ids:        make([]any, len(nodes)),
+	}
+
+	for i, n := range nodes {
+		g.ids[i] = n.ID
+		for _, dep := range n.Deps {
+			d, ok := positions[dep]
+			if !ok {
+				return nil, fmt.Errorf("%w: %v depends on %v", ErrUnknownNode, n.ID, dep)
+			}
+			g.dependents[d] = append(g.dependents[d], i)
+			g.pending[i]++
+		}
+	}
+
+	// Kahn's algorithm visits every node, unless there is a cycle
+	pending := append([]int(nil), g.pending...)
+	queue := g.roots()
+	visited := 0
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		visited++
+
+		for _, d := range g.dependents[i] {
+			if pending[d]--; pending[d] == 0 {
+				queue = append(queue, d)
+			}
+		}
+	}
+
+	if visited != len(nodes) {
+		return nil, ErrCycle
+	}
+
+	return g, nil
+}
+
+// roots returns the nodes without dependencies
+func (g *graph) roots() []int {
+	var roots []int
+	for i, n := range g.pending {
+		if n == 0 {
+			roots = append(roots, i)
+		}
+	}
+
+	return roots
+}
+
+// schedule sends nodes to the workers on jobs as their dependencies
+// succeed, and forwards the results of the workers, along with those of the
+// nodes skipped because a dependency failed
+func schedule[K comparable, T, R any](g *graph, b *batch[T, R], nodes []Node[K, T], jobs chan<- job[T], results <-chan Result[T, R]) <-chan Result[T, R] {
+	out := make(chan Result[T, R])
+
+	forward := func(r Result[T, R]) bool {
+		select {
+		case out <- r:
+			return true
+		case <-b.ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(out)
+
+		stop := sync.OnceFunc(func() { close(jobs) })
+		defer stop()
+
+		ready := g.roots()
+		inFlight := 0
+
+		for len(ready) > 0 || inFlight > 0 {
+			// only send when a node is ready
+			var send chan<- job[T]
+			var next job[T]
+			if len(ready) > 0 {
+				send = jobs
+				next = job[T]{index: ready[0], input: nodes[ready[0]].Input}
+			}
+
+			select {
+			case send <- next:
+				ready = ready[1:]
+				inFlight++
+			case r, ok := <-results:
+				if !ok {
+					return // canceled
+				}
+				inFlight--
+
+				if !forward(r) {
+					return
+				}
+
+				if r.Err != nil {
+					if !skip(g, r.Index, r.Err, nodes, forward) {
+						return
+					}
+					continue
+				}
+
+				for _, d := range g.dependents[r.Index] {
+					if g.pending[d]--; g.pending[d] == 0 {
+						ready = append(ready, d)
+					}
+				}
+			case <-b.feedCtx.Done():
+				// with DrainInFlight, in-flight nodes still complete
+				stop()
+				for r := range results {
+					if !forward(r) {
+						return
+					}
+				}
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// skip fails the nodes depending on the failed node i, directly or not,
+// returning FALSE if the batch was canceled
+func skip[K comparable, T, R any](g *graph, i int, err error, nodes []Node[K, T], forward func(Result[T, R]) bool) bool {
+	failed := &DependencyError{g.ids[i], err}
+
+	queue := append([]int(nil), g.dependents[i]...)
+	for len(queue) > 0 {
+		d := queue[0]
+		queue = queue[1:]
+
+		if g.skipped[d] {
+			continue
+		}
+		g.skipped[d] = true
+
+		if !forward(Result[T, R]{Index: d, Input: nodes[d].Input, Err: failed}) {
+			return false
+		}
+		queue = append(queue, g.dependents[d]...)
+	}
+
+	return true
+}
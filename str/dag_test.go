@@ -0,0 +1,104 @@
+package str_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/sspencer/goal/str"
+)
+
+func TestMapDAG(t *testing.T) {
+	// a -> b, c -> d
+	nodes := []str.Node[string, string]{
+		{ID: "d", Input: "d", Deps: []string{"b", "c"}},
+		{ID: "b", Input: "b", Deps: []string{"a"}},
+		{ID: "c", Input: "c", Deps: []string{"a"}},
+		{ID: "a", Input: "a"},
+	}
+
+	var mu sync.Mutex
+	done := map[string]bool{}
+	deps := map[string][]string{"a": nil, "b": {"a"}, "c": {"a"}, "d": {"b", "c"}}
+
+	results, err := str.MapDAG(context.Background(), 4, nodes, func(_ context.Context, id string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, dep := range deps[id] {
+			if !done[dep] {
+				t.Errorf("%s started before its dependency %s", id, dep)
+			}
+		}
+		done[id] = true
+		return id, nil
+	}, str.Ordered())
+
+	if err != nil || len(results) != len(nodes) {
+		t.Fatalf("Expected %d results, received %d, %v", len(nodes), len(results), err)
+	}
+
+	for i, r := range results {
+		if r.Index != i || r.Output != nodes[i].ID {
+			t.Errorf("For index %d, unexpected result %+v", i, r)
+		}
+	}
+}
+
+func TestMapDAGFailure(t *testing.T) {
+	nodes := []str.Node[int, int]{
+		{ID: 1, Input: 1},
+		{ID: 2, Input: 2, Deps: []int{1}},
+		{ID: 3, Input: 3, Deps: []int{2}},
+		{ID: 4, Input: 4},
+	}
+
+	boom := errors.New("boom")
+	results, err := str.MapDAG(context.Background(), 2, nodes, func(_ context.Context, n int) (int, error) {
+		if n == 1 {
+			return 0, boom
+		}
+		if n != 4 {
+			t.Errorf("Unexpected call for %d", n)
+		}
+		return n, nil
+	}, str.Ordered())
+
+	if len(results) != len(nodes) || !errors.Is(err, boom) {
+		t.Fatalf("Expected %d results and boom, received %d, %v", len(nodes), len(results), err)
+	}
+
+	for _, i := range []int{1, 2} {
+		var dep *str.DependencyError
+		if !errors.As(results[i].Err, &dep) || dep.Dependency != 1 || !errors.Is(results[i].Err, boom) {
+			t.Errorf("For index %d, expected a DependencyError on 1, received %v", i, results[i].Err)
+		}
+	}
+
+	if results[3].Err != nil || results[3].Output != 4 {
+		t.Errorf("Expected independent node to succeed, received %+v", results[3])
+	}
+}
+
+func TestMapDAGInvalid(t *testing.T) {
+	tests := []struct {
+		nodes []str.Node[string, int]
+		err   error
+	}{
+		{[]str.Node[string, int]{{ID: "a", Deps: []string{"b"}}, {ID: "b", Deps: []string{"a"}}}, str.ErrCycle},
+		{[]str.Node[string, int]{{ID: "a", Deps: []string{"z"}}}, str.ErrUnknownNode},
+		{[]str.Node[string, int]{{ID: "a"}, {ID: "a"}}, str.ErrUnknownNode},
+	}
+
+	for _, tc := range tests {
+		_, err := str.MapDAG(context.Background(), 2, tc.nodes, func(context.Context, int) (int, error) {
+			t.Error("Unexpected call")
+			return 0, nil
+		})
+
+		if !errors.Is(err, tc.err) {
+			t.Errorf("Expected %v, received %v", tc.err, err)
+		}
+	}
+}
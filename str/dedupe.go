@@ -0,0 +1,76 @@
+package str
+
+import (
+	"context"
+)
+
+// Dedupe skips inputs equal to an earlier input, e.g. a list of URLs with
+// repeats, so duplicates don't waste work or quota.  Result.Index is the
+// position of the first occurrence.  Inputs must be comparable.
+func Dedupe() Option {
+	return func(o *options) {
+		o.dedupeKey = func(v any) any {
+			return v
+		}
+	}
+}
+
+// DedupeBy is like Dedupe, for inputs with the same key.  T must be the
+// type of the inputs, or the batch fails with ErrOptionType.
+func DedupeBy[T any, K comparable](key func(T) K) Option {
+	return func(o *options) {
+		expect[T](o, "DedupeBy")
+		o.dedupeKey = func(v any) any {
+			in, ok := v.(T)
+			if !ok {
+				return v // the batch failed with ErrOptionType
+			}
+			return key(in)
+		}
+	}
+}
+
+// MapUnique is like MapCtx, for unique inputs only, returning the result
+// for every one of them keyed by input.
+func MapUnique[T comparable, R any](ctx context.Context, numWorkers int, input []T, fn func(context.Context, T) (R, error), opts ...Option) (map[T]Result[T, R], error) {
+	results, err := MapCtx(ctx, numWorkers, input, fn, append(opts, Dedupe())...)
+
+	output := make(map[T]Result[T, R], len(results))
+	for _, r := range results {
+		output[r.Input] = r
+	}
+
+	return output, err
+}
+
+// unique returns the unique inputs with the Dedupe options, without those
+// already done with the Resume option, along with their positions in the
+// original input.  Otherwise indexes are nil.
+func unique[T any](input []T, o *options) ([]T, []int) {
+	if o.dedupeKey == nil && o.checkpoint == nil {
+		return input, nil
+	}
+
+	seen := make(map[any]bool, len(input))
+	output := make([]T, 0, len(input))
+	indexes := make([]int, 0, len(input))
+
+	for i, in := range input {
+		if o.checkpoint != nil && o.checkpoint.Done(in) {
+			continue
+		}
+
+		if o.dedupeKey != nil {
+			key := o.dedupeKey(in)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+
+		output = append(output, in)
+		indexes = append(indexes, i)
+	}
+
+	return output, indexes
+}
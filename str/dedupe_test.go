@@ -0,0 +1,38 @@
+package str_test
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sspencer/goal/str"
+)
+
+func TestDedupe(t *testing.T) {
+	var calls atomic.Int32
+	input := []string{"a", "b", "a", "c", "b"}
+
+	results, err := str.MapUnique(context.Background(), 2, input, func(_ context.Context, s string) (string, error) {
+		calls.Add(1)
+		return strings.ToUpper(s), nil
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if calls.Load() != 3 || len(results) != 3 {
+		t.Errorf("Expected 3 calls and results, received %d and %d", calls.Load(), len(results))
+	}
+	if r := results["c"]; r.Output != "C" || r.Index != 3 {
+		t.Errorf("Unexpected result for c: %+v", r)
+	}
+}
+
+func TestDedupeBy(t *testing.T) {
+	input := []string{"a", "A", "b", "B", "c"}
+	output := str.Map(2, input, strings.ToLower, str.DedupeBy(strings.ToLower), str.Ordered())
+	if strings.Join(output, "") != "abc" {
+		t.Errorf("Expected [a b c], received %v", output)
+	}
+}
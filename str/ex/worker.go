@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"math/rand"
+	"os"
 	"time"
 
-	"github.com/sspencer/str"
+	"github.com/sspencer/goal/cli"
+	"github.com/sspencer/goal/str"
 )
 
 var rnd *rand.Rand
@@ -14,29 +19,49 @@ func init() {
 	rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
 }
 
-type myWorker int
-
-func (m myWorker) StringWork(fn string) string {
-	fmt.Println("processing", fn)
+func process(fn string) (string, error) {
 	time.Sleep(time.Duration(rnd.Float32()*1000+200) * time.Millisecond)
 	if rnd.Float32() < 0.25 {
-		fmt.Println("error processing", fn)
-		return "" // return empty string on error
+		return "", errors.New("could not process file")
 	}
 
-	return fn + ".processed"
+	return fn + ".processed", nil
 }
 
 func main() {
+	app := cli.New("worker", "Process fake files with a pool of workers.")
+
+	var files, workers *int
+	var verbose *bool
+	cmd := app.Command("run", "Process the files, some of which fail.", func(ctx context.Context, args []string) error {
+		return run(ctx, *files, *workers, *verbose)
+	})
+	files = cmd.Int("files", 23, "number of files")
+	workers = cmd.Int("workers", 4, "number of workers")
+	verbose = cmd.Bool("v", true, "log every item")
+
+	app.Main()
+}
+
+func run(ctx context.Context, files, workers int, verbose bool) error {
 	var input []string
-	for i := 0; i < 23; i++ {
+	for i := 0; i < files; i++ {
 		input = append(input, fmt.Sprintf("/tmp/f%d.txt", i))
 	}
 
-	var w myWorker
+	var opts []str.Option
+	if verbose {
+		// the pool logs every item, so process doesn't have to
+		logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		opts = append(opts, str.Logger(logger))
+	}
 
-	results := str.Worker(4, input, w)
-	for _, f := range results {
-		fmt.Println("==>", f)
+	results := str.MapErr(workers, input, process, opts...)
+	for _, r := range results {
+		if r.Err == nil {
+			fmt.Println("==>", r.Output)
+		}
 	}
+
+	return ctx.Err()
 }
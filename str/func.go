@@ -0,0 +1,21 @@
+package str
+
+import (
+	"context"
+)
+
+// Func adapts a function without a context or error to the work function
+// taken by MapCtx, MapChan, Stream and NewPool.
+func Func[T, R any](fn func(T) R) func(context.Context, T) (R, error) {
+	return func(_ context.Context, in T) (R, error) {
+		return fn(in), nil
+	}
+}
+
+// FuncErr adapts a function without a context to the work function taken
+// by MapCtx, MapChan, Stream and NewPool.
+func FuncErr[T, R any](fn func(T) (R, error)) func(context.Context, T) (R, error) {
+	return func(_ context.Context, in T) (R, error) {
+		return fn(in)
+	}
+}
@@ -0,0 +1,157 @@
+package str
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrCanceled is the error of items canceled with Future.Cancel.
+var ErrCanceled = errors.New("item canceled")
+
+// Future is the handle of an item submitted to a Pool, to await its
+// output without reading every result of the pool, or to cancel it.
+type Future[R any] struct {
+	once   sync.Once
+	done   chan struct{}
+	output R
+	err    error
+
+	mu       sync.Mutex
+	canceled bool
+	stop     context.CancelCauseFunc // set once the item is in flight
+}
+
+func newFuture[R any]() *Future[R] {
+	return &Future[R]{done: make(chan struct{})}
+}
+
+// Done returns a channel closed once the item is done.
+func (f *Future[R]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the item is done, returning its output and error, or
+// until ctx is done, returning ctx.Err().  Items dropped by Pool.Stop fail
+// with ErrPoolStopped.
+func (f *Future[R]) Wait(ctx context.Context) (R, error) {
+	select {
+	case <-f.done:
+		return f.output, f.err
+	case <-ctx.Done():
+		var zero R
+		return zero, ctx.Err()
+	}
+}
+
+// Cancel cancels the item.  A queued item is skipped, failing with
+// ErrCanceled, and an in-flight item has its context canceled with
+// ErrCanceled as the cause.  Cancel returns FALSE if the item was already
+// done.
+func (f *Future[R]) Cancel() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return false
+	default:
+	}
+
+	f.canceled = true
+	if f.stop != nil {
+		f.stop(ErrCanceled)
+	}
+
+	return true
+}
+
+// begin returns the context of the item as it starts, or FALSE if it was
+// canceled while queued
+func (f *Future[R]) begin(ctx context.Context) (context.Context, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.canceled {
+		return ctx, false
+	}
+
+	ctx, f.stop = context.WithCancelCause(ctx)
+	return ctx, true
+}
+
+// settle completes the future, only the first time it is called
+func (f *Future[R]) settle(output R, err error) {
+	f.once.Do(func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if f.stop != nil {
+			f.stop(nil)
+		}
+
+		f.output = output
+		f.err = err
+		close(f.done)
+	})
+}
+
+// futures tracks the Futures of a Pool by item index
+type futures[R any] struct {
+	mu      sync.Mutex
+	pending map[int]*Future[R]
+}
+
+// add returns a new Future for the item at index
+func (fs *futures[R]) add(index int) *Future[R] {
+	f := newFuture[R]()
+
+	fs.mu.Lock()
+	fs.pending[index] = f
+	fs.mu.Unlock()
+
+	return f
+}
+
+// begin returns the context of the item at index as it starts, or FALSE if
+// it was canceled while queued
+func (fs *futures[R]) begin(ctx context.Context, index int) (context.Context, bool) {
+	fs.mu.Lock()
+	f := fs.pending[index]
+	fs.mu.Unlock()
+
+	if f == nil {
+		return ctx, true
+	}
+
+	return f.begin(ctx)
+}
+
+// remove forgets the Future of the item at index, returning it if any
+func (fs *futures[R]) remove(index int) *Future[R] {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	f := fs.pending[index]
+	delete(fs.pending, index)
+	return f
+}
+
+// settle completes the Future of the item at index
+func (fs *futures[R]) settle(index int, output R, err error) {
+	if f := fs.remove(index); f != nil {
+		f.settle(output, err)
+	}
+}
+
+// fail completes every Future still pending with err
+func (fs *futures[R]) fail(err error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	var zero R
+	for index, f := range fs.pending {
+		f.settle(zero, err)
+		delete(fs.pending, index)
+	}
+}
@@ -0,0 +1,34 @@
+package str
+
+import (
+	"context"
+)
+
+// Group is the method of *errgroup.Group (golang.org/x/sync/errgroup) that
+// GroupMap needs, so this package doesn't depend on it.
+type Group interface {
+	Go(func() error)
+}
+
+// GroupMap runs a batch as one goroutine of an errgroup, e.g.
+//
+//	g, ctx := errgroup.WithContext(ctx)
+//	var results []str.Result[string, int]
+//	str.GroupMap(ctx, g, 8, input, fn, &results)
+//	g.Go(...) // other work
+//	err := g.Wait()
+//
+// The batch fails fast: its first item error is returned to the group,
+// which cancels the group's context and so the other goroutines, and a
+// canceled group context stops the batch.  Results are stored in output
+// (if not nil) before the group's Wait returns.
+func GroupMap[T, R any](ctx context.Context, g Group, numWorkers int, input []T, fn func(context.Context, T) (R, error), output *[]Result[T, R], opts ...Option) {
+	g.Go(func() error {
+		results, err := MapCtx(ctx, numWorkers, input, fn, append(opts, FailFast())...)
+		if output != nil {
+			*output = results
+		}
+
+		return err
+	})
+}
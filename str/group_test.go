@@ -0,0 +1,74 @@
+package str_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/sspencer/goal/str"
+)
+
+// group is the part of errgroup.Group used with GroupMap
+type group struct {
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+	cancel context.CancelFunc
+}
+
+func (g *group) Go(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := fn(); err != nil {
+			g.once.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}()
+}
+
+func (g *group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+func TestGroupMap(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g := &group{cancel: cancel}
+
+	var results []str.Result[int, int]
+	str.GroupMap(ctx, g, 2, []int{1, 2, 3}, square, &results)
+
+	if err := g.Wait(); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(results) != 3 {
+		t.Errorf("Expected 3 results, received %d", len(results))
+	}
+}
+
+func TestGroupMapError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g := &group{cancel: cancel}
+
+	failure := errors.New("fail")
+	str.GroupMap(ctx, g, 2, []int{1, 2, 3}, func(_ context.Context, n int) (int, error) {
+		return 0, failure
+	}, nil)
+
+	canceled := make(chan bool)
+	g.Go(func() error {
+		<-ctx.Done()
+		close(canceled)
+		return nil
+	})
+
+	if err := g.Wait(); err != failure {
+		t.Errorf("Expected failure, received %v", err)
+	}
+	<-canceled
+}
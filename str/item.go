@@ -0,0 +1,98 @@
+package str
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// ErrTimeout is the error of items that ran longer than the ItemTimeout option.
+var ErrTimeout = errors.New("work item timed out")
+
+// PanicError is the error of items whose work panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+// Error implements the Error method for PanicErrors
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("work item panicked: %v", e.Value)
+}
+
+// do runs a single job, retrying it according to the RetryPolicy option.
+// The worker waits out the backoff, so retries still respect numWorkers.
+func do[T, R any](ctx context.Context, fn func(context.Context, T) (R, error), j job[T], o *options) Result[T, R] {
+	r := Result[T, R]{Index: j.index, Input: j.input}
+
+	for {
+		r.Attempts++
+		r.Output, r.Err = fn(ctx, j.input)
+		if r.Err == nil || r.Attempts >= o.maxAttempts {
+			return r
+		}
+
+		select {
+		case <-time.After(o.retryBackoff.Delay(r.Attempts)):
+		case <-ctx.Done():
+			return r
+		}
+	}
+}
+
+// wrap applies the per-item options to the work function
+func wrap[T, R any](fn func(context.Context, T) (R, error), o *options) func(context.Context, T) (R, error) {
+	// innermost, since timeouts run fn in a goroutine of its own
+	if !o.rethrow {
+		fn = withRecover(fn)
+	}
+
+	if o.itemTimeout > 0 {
+		fn = withTimeout(fn, o.itemTimeout)
+	}
+
+	return fn
+}
+
+// withRecover turns a panic in fn into a PanicError
+func withRecover[T, R any](fn func(context.Context, T) (R, error)) func(context.Context, T) (R, error) {
+	return func(ctx context.Context, in T) (out R, err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				err = &PanicError{v, debug.Stack()}
+			}
+		}()
+
+		return fn(ctx, in)
+	}
+}
+
+// withTimeout stops waiting for fn after d.  The item's context is canceled,
+// but work that ignores it keeps running in the background.
+func withTimeout[T, R any](fn func(context.Context, T) (R, error), d time.Duration) func(context.Context, T) (R, error) {
+	type outcome struct {
+		out R
+		err error
+	}
+
+	return func(ctx context.Context, in T) (R, error) {
+		ctx, cancel := context.WithTimeoutCause(ctx, d, ErrTimeout)
+		defer cancel()
+
+		done := make(chan outcome, 1)
+		go func() {
+			out, err := fn(ctx, in)
+			done <- outcome{out, err}
+		}()
+
+		select {
+		case o := <-done:
+			return o.out, o.err
+		case <-ctx.Done():
+			var zero R
+			return zero, context.Cause(ctx)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package str
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/sspencer/goal/fsu"
+)
+
+// NewJournaledPool is like NewPool, but journals submitted items to an
+// append-only log at path, so a crashed or restarted job resumes where it
+// left off: items submitted but not done (including those dropped by Stop)
+// are submitted again in the background, ahead of new items.  Results must
+// be read, as resumed items are only reported there.  Items are stored as
+// JSON, so T must be encodable.
+// Failed items are done too, and are not retried on restart.
+func NewJournaledPool[T, R any](path string, numWorkers int, fn func(context.Context, T) (R, error), opts ...Option) (*Pool[T, R], error) {
+	j, pending, err := openJournal(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := NewPool(numWorkers, fn, opts...)
+	p.journal = j
+	p.reading.Store(true) // resumed items are only reported on Results
+
+	go func() {
+		<-p.b.done
+		j.close()
+	}()
+
+	go p.resume(pending)
+
+	return p, nil
+}
+
+// resume submits the items left over in the journal again
+func (p *Pool[T, R]) resume(pending []record) {
+	for _, r := range pending {
+		var item T
+		if err := json.Unmarshal(r.Item, &item); err != nil {
+			p.journal.forget(r.ID)
+			continue
+		}
+
+		if _, err := p.enqueue(item, 0, true, r.ID); err != nil {
+			return // closed, still pending next time
+		}
+	}
+}
+
+// record is a line of the journal: an item was added, or is done
+type record struct {
+	Op   string          `json:"op"`
+	ID   int64           `json:"id"`
+	Item json.RawMessage `json:"item,omitempty"`
+}
+
+// journal is the append-only log of a NewJournaledPool
+type journal struct {
+	mu   sync.Mutex
+	f    *os.File
+	enc  *json.Encoder
+	next int64
+	ids  map[int]int64 // journal ID by pool index
+}
+
+// openJournal reads the journal at path, returning the items still pending.
+// The journal is compacted to just those, so it doesn't grow forever.
+func openJournal(path string) (*journal, []record, error) {
+	pending, next, err := readJournal(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// written atomically, so a crash never loses the journal
+	err = fsu.WriteAtomic(path, 0o644, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		for _, r := range pending {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	j := &journal{f: f, enc: json.NewEncoder(f), next: next, ids: make(map[int]int64)}
+	return j, pending, nil
+}
+
+// readJournal returns the pending items of the journal at path, in the
+// order they were added, and the next free ID
+func readJournal(path string) ([]record, int64, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	var order []int64
+	added := make(map[int64]record)
+	var next int64
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue // torn write from a crash
+		}
+
+		switch r.Op {
+		case "add":
+			order = append(order, r.ID)
+			added[r.ID] = r
+		case "done":
+			delete(added, r.ID)
+		}
+
+		if r.ID >= next {
+			next = r.ID + 1
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var pending []record
+	for _, id := range order {
+		if r, ok := added[id]; ok {
+			pending = append(pending, r)
+		}
+	}
+
+	return pending, next, nil
+}
+
+// add records the item at pool index, unless it is already in the journal
+// (resumed items have an ID >= 0)
+func (j *journal) add(index int, item any, id int64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if id < 0 {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+
+		id = j.next
+		if err := j.enc.Encode(record{"add", id, data}); err != nil {
+			return err
+		}
+		j.next++
+	}
+
+	j.ids[index] = id
+	return nil
+}
+
+// done records that the item at pool index is done
+func (j *journal) done(index int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	id, ok := j.ids[index]
+	if !ok {
+		return
+	}
+
+	delete(j.ids, index)
+	j.enc.Encode(record{Op: "done", ID: id})
+}
+
+// forget records that the item with the journal ID is done, without
+// having been submitted
+func (j *journal) forget(id int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.enc.Encode(record{Op: "done", ID: id})
+}
+
+func (j *journal) close() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.f.Close()
+}
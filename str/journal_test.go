@@ -0,0 +1,82 @@
+package str_test
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/str"
+)
+
+func TestJournaledPool(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.log")
+
+	// items 3 and 4 hang until the pool is stopped
+	p, err := str.NewJournaledPool(path, 2, func(ctx context.Context, n int) (int, error) {
+		if n >= 3 {
+			<-ctx.Done()
+			return 0, context.Cause(ctx)
+		}
+		return n, nil
+	}, str.QueueSize(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for range p.Results() {
+		}
+	}()
+
+	for n := 0; n < 5; n++ {
+		p.Submit(n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	p.Stop(ctx)
+
+	// the restarted pool resumes the items that didn't complete
+	p, err = str.NewJournaledPool(path, 2, square)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var inputs []int
+	for r := range p.Results() {
+		inputs = append(inputs, r.Input)
+		if len(inputs) == 2 {
+			break
+		}
+	}
+	p.Drain()
+
+	sort.Ints(inputs)
+	if len(inputs) != 2 || inputs[0] != 3 || inputs[1] != 4 {
+		t.Errorf("Expected [3 4] to be resumed, received %v", inputs)
+	}
+
+	// and then there is nothing left
+	p, err = str.NewJournaledPool(path, 2, square)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	read := make(chan bool)
+	go func() {
+		for range p.Results() {
+			count++
+		}
+		close(read)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	p.Drain()
+	<-read
+	if count != 0 {
+		t.Errorf("Expected nothing to be resumed, received %d results", count)
+	}
+}
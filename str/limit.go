@@ -0,0 +1,36 @@
+package str
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/sspencer/goal/ratelimit"
+)
+
+// limiter returns the token bucket for the RateLimit option, if any
+func (o *options) limiter() *ratelimit.TokenBucket {
+	if o.rate <= 0 {
+		return nil
+	}
+
+	return ratelimit.NewTokenBucket(o.rate, o.burst)
+}
+
+// stagger waits a random duration of up to the Jitter option, or until ctx
+// is done
+func (o *options) stagger(ctx context.Context) error {
+	if o.jitter <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(rand.N(o.jitter))
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
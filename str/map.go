@@ -0,0 +1,154 @@
+package str
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Result pairs the output of a work item with its input and any error.
+type Result[T, R any] struct {
+	Index    int // position of Input in the input slice
+	Input    T
+	Output   R
+	Err      error
+	Attempts int // more than 1 with the RetryPolicy option
+}
+
+// ItemError identifies the input of a failed item.
+type ItemError[T any] struct {
+	Index int
+	Input T
+	Err   error
+}
+
+// Error implements the Error method for ItemErrors
+func (e *ItemError[T]) Error() string {
+	return fmt.Sprintf("input %v: %v", e.Input, e.Err)
+}
+
+// Unwrap returns the error of the item
+func (e *ItemError[T]) Unwrap() error {
+	return e.Err
+}
+
+// Errors joins the errors of all failed results as ItemErrors, or returns
+// nil if every item succeeded.
+func Errors[T, R any](results []Result[T, R]) error {
+	return join(itemErrors(results, nil))
+}
+
+// itemErrors appends the ItemErrors of failed results to errs
+func itemErrors[T, R any](results []Result[T, R], errs []error) []error {
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, &ItemError[T]{r.Index, r.Input, r.Err})
+		}
+	}
+
+	return errs
+}
+
+// join is errors.Join, without wrapping a single error
+func join(errs []error) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+
+	return errors.Join(errs...)
+}
+
+// Map concurrently calls fn on every input, up to 'numWorkers' at a time.
+// Outputs are returned in the order they complete, which is not necessarily
+// the order of the input, unless the Ordered option is given.
+func Map[T, R any](numWorkers int, input []T, fn func(T) R, opts ...Option) []R {
+	results, _ := MapCtx(context.Background(), numWorkers, input, Func(fn), opts...)
+
+	output := make([]R, len(results))
+	for i, r := range results {
+		output[i] = r.Output
+	}
+
+	return output
+}
+
+// MapErr is like Map for work that can fail.  Every input gets a Result,
+// so failures can be reported (and retried) with the input that caused them.
+func MapErr[T, R any](numWorkers int, input []T, fn func(T) (R, error), opts ...Option) []Result[T, R] {
+	results, _ := MapCtx(context.Background(), numWorkers, input, FuncErr(fn), opts...)
+
+	return results
+}
+
+// MapCtx is like MapErr, but stops dispatching new inputs when ctx is
+// canceled or times out.  It then returns promptly, without waiting for
+// in-flight work, with the results completed so far and ctx.Err().  The
+// context is passed on to fn so in-flight work can stop early too.
+//
+// The error joins the ItemErrors of every failed item (see Errors) and the
+// context's error, if any.  With the FailFast option, the first item error
+// cancels the batch instead, and is the only error returned.
+func MapCtx[T, R any](ctx context.Context, numWorkers int, input []T, fn func(context.Context, T) (R, error), opts ...Option) ([]Result[T, R], error) {
+	// short circuit on empty input
+	if len(input) == 0 {
+		return []Result[T, R]{}, nil
+	}
+
+	o := newOptions(opts)
+	input, indexes := unique(input, o)
+	numWorkers = boundWorkers(numWorkers, len(input))
+
+	b := newBatch(ctx, fn, o, len(input))
+	defer b.cancel(nil)
+
+	return b.gather(b.runSlice(numWorkers, input, indexes, false))
+}
+
+// MapChan is like MapCtx, but consumes input from a channel until it is
+// closed, so producers (directory walkers, queue consumers) can feed the
+// pool incrementally.  Result.Index is the order inputs were received in.
+func MapChan[T, R any](ctx context.Context, numWorkers int, input <-chan T, fn func(context.Context, T) (R, error), opts ...Option) ([]Result[T, R], error) {
+	o := newOptions(opts)
+	numWorkers = boundWorkers(numWorkers, MaxThreads)
+
+	b := newBatch(ctx, fn, o, -1)
+	defer b.cancel(nil)
+
+	return b.gather(b.run(numWorkers, feedChan(b.feedCtx, input, o), false))
+}
+
+// MapChunks is like MapCtx, but delivers input to fn in chunks of up to
+// 'chunkSize' items (e.g. 500 rows per DB insert), the last chunk holding
+// whatever is left over.  Result.Index is the index of the chunk.
+func MapChunks[T, R any](ctx context.Context, numWorkers, chunkSize int, input []T, fn func(context.Context, []T) (R, error), opts ...Option) ([]Result[[]T, R], error) {
+	return MapCtx(ctx, numWorkers, Chunk(input, chunkSize), fn, opts...)
+}
+
+// inOrder puts results back into input order when the Ordered option is set.
+// When every index is present (no dedupe or cancellation), results are
+// swapped into place in linear time, instead of sorting.
+func inOrder[T, R any](o *options, results []Result[T, R]) []Result[T, R] {
+	if !o.ordered {
+		return results
+	}
+
+	for _, r := range results {
+		if r.Index < 0 || r.Index >= len(results) {
+			slices.SortFunc(results, func(a, b Result[T, R]) int {
+				return a.Index - b.Index
+			})
+			return results
+		}
+	}
+
+	// indexes are unique, so each swap puts one result in its final place
+	for i := range results {
+		for results[i].Index != i {
+			j := results[i].Index
+			results[i], results[j] = results[j], results[i]
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,432 @@
+package str_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/backoff"
+	"github.com/sspencer/goal/str"
+)
+
+func TestMap(t *testing.T) {
+	input := []int{1, 2, 3, 4, 5, 6, 7}
+	output := str.Map(3, input, func(n int) int { return n * n })
+	if len(output) != len(input) {
+		t.Fatalf("Expected %d results, received %d", len(input), len(output))
+	}
+
+	sort.Ints(output)
+	for i, n := range input {
+		if output[i] != n*n {
+			t.Errorf("For index %d, expected %d, not %d", i, n*n, output[i])
+		}
+	}
+}
+
+func TestMapEmpty(t *testing.T) {
+	output := str.Map(3, nil, func(s string) int { return len(s) })
+	if output == nil || len(output) != 0 {
+		t.Errorf("Expected empty results, received %v", output)
+	}
+}
+
+func TestMapErr(t *testing.T) {
+	input := []int{1, 2, 3, 4, 5, 6, 7}
+	results := str.MapErr(3, input, func(n int) (int, error) {
+		if n%2 == 0 {
+			return 0, fmt.Errorf("even %d", n)
+		}
+		return n * n, nil
+	})
+
+	if len(results) != len(input) {
+		t.Fatalf("Expected %d results, received %d", len(input), len(results))
+	}
+
+	for _, r := range results {
+		if (r.Input%2 == 0) != (r.Err != nil) {
+			t.Errorf("For %d, unexpected error %v", r.Input, r.Err)
+		}
+		if r.Err == nil && r.Output != r.Input*r.Input {
+			t.Errorf("For %d, expected %d, not %d", r.Input, r.Input*r.Input, r.Output)
+		}
+	}
+}
+
+func TestMapChan(t *testing.T) {
+	input := make(chan int)
+	go func() {
+		for n := 1; n <= 7; n++ {
+			input <- n
+		}
+		close(input)
+	}()
+
+	results, err := str.MapChan(context.Background(), 3, input, func(_ context.Context, n int) (int, error) {
+		return n * n, nil
+	}, str.Ordered())
+
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(results) != 7 {
+		t.Fatalf("Expected 7 results, received %d", len(results))
+	}
+
+	for i, r := range results {
+		if r.Index != i || r.Input != i+1 || r.Output != r.Input*r.Input {
+			t.Errorf("For index %d, unexpected result %+v", i, r)
+		}
+	}
+}
+
+func TestOnProgress(t *testing.T) {
+	input := []int{1, 2, 3, 4, 5, 6, 7}
+
+	var calls []int
+	str.Map(3, input, func(n int) int { return n }, str.OnProgress(func(done, total int) {
+		if total != len(input) {
+			t.Errorf("Expected total %d, received %d", len(input), total)
+		}
+		calls = append(calls, done)
+	}))
+
+	if len(calls) != len(input) {
+		t.Fatalf("Expected %d progress calls, received %d", len(input), len(calls))
+	}
+	for i, done := range calls {
+		if done != i+1 {
+			t.Errorf("For call %d, expected done %d, not %d", i, i+1, done)
+		}
+	}
+}
+
+func TestItemTimeout(t *testing.T) {
+	input := []int{1, 2, 3, 4}
+	results := str.MapErr(4, input, func(n int) (int, error) {
+		if n == 3 {
+			time.Sleep(time.Second)
+		}
+		return n, nil
+	}, str.ItemTimeout(20*time.Millisecond), str.Ordered())
+
+	for _, r := range results {
+		if r.Input == 3 {
+			if !errors.Is(r.Err, str.ErrTimeout) {
+				t.Errorf("Expected timeout for %d, received %v", r.Input, r.Err)
+			}
+		} else if r.Err != nil {
+			t.Errorf("Unexpected error for %d: %v", r.Input, r.Err)
+		}
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	var mutex sync.Mutex
+	attempts := map[int]int{}
+
+	input := []int{1, 2, 3}
+	results := str.MapErr(3, input, func(n int) (int, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		attempts[n]++
+		if attempts[n] < n {
+			return 0, fmt.Errorf("attempt %d of %d", attempts[n], n)
+		}
+		return n, nil
+	}, str.RetryPolicy(2, time.Millisecond), str.Ordered())
+
+	expected := []struct {
+		attempts int
+		failed   bool
+	}{{1, false}, {2, false}, {2, true}}
+
+	for i, r := range results {
+		if r.Attempts != expected[i].attempts || (r.Err != nil) != expected[i].failed {
+			t.Errorf("For %d, expected %d attempts (failed %v), received %d (%v)", r.Input, expected[i].attempts, expected[i].failed, r.Attempts, r.Err)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	var delays []int
+	strategy := backoff.Func(func(attempt int) time.Duration {
+		delays = append(delays, attempt)
+		return 0
+	})
+
+	results := str.MapErr(1, []int{1}, func(n int) (int, error) {
+		return 0, errors.New("failed")
+	}, str.RetryBackoff(strategy), str.RetryPolicy(3, time.Hour))
+
+	if results[0].Attempts != 3 || len(delays) != 2 || delays[0] != 1 || delays[1] != 2 {
+		t.Errorf("Expected 3 attempts with custom delays after 1 and 2, received %d and %v", results[0].Attempts, delays)
+	}
+}
+
+func TestPanicRecovery(t *testing.T) {
+	input := []int{1, 2, 3}
+	results := str.MapErr(2, input, func(n int) (int, error) {
+		if n == 2 {
+			panic("two")
+		}
+		return n, nil
+	}, str.Ordered())
+
+	var pe *str.PanicError
+	if !errors.As(results[1].Err, &pe) || pe.Value != "two" || len(pe.Stack) == 0 {
+		t.Errorf("Expected panic error for 2, received %v", results[1].Err)
+	}
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Errorf("Unexpected errors %v, %v", results[0].Err, results[2].Err)
+	}
+}
+
+func TestFailFast(t *testing.T) {
+	input := make([]int, 100)
+	for i := range input {
+		input[i] = i
+	}
+
+	failure := errors.New("fail")
+	results, err := str.MapCtx(context.Background(), 2, input, func(_ context.Context, n int) (int, error) {
+		if n == 5 {
+			return 0, failure
+		}
+		time.Sleep(time.Millisecond)
+		return n, nil
+	}, str.FailFast())
+
+	if err != failure {
+		t.Errorf("Expected failure, received %v", err)
+	}
+	if len(results) >= len(input) {
+		t.Errorf("Expected partial results, received %d", len(results))
+	}
+}
+
+func TestAggregatedErrors(t *testing.T) {
+	input := []int{1, 2, 3, 4}
+	failure := errors.New("odd")
+	_, err := str.MapCtx(context.Background(), 2, input, func(_ context.Context, n int) (int, error) {
+		if n%2 == 1 {
+			return 0, failure
+		}
+		return n, nil
+	})
+
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected joined failures, received %v", err)
+	}
+
+	errs := err.(interface{ Unwrap() []error }).Unwrap()
+	if len(errs) != 2 {
+		t.Errorf("Expected 2 errors, received %d", len(errs))
+	}
+
+	var ie *str.ItemError[int]
+	if !errors.As(err, &ie) || ie.Input%2 != 1 {
+		t.Errorf("Expected item error for odd input, received %v", ie)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	input := []int{1, 2, 3, 4, 5, 6}
+
+	start := time.Now()
+	str.Map(6, input, func(n int) int { return n }, str.RateLimit(100, 1))
+
+	// 1 immediately, then 5 more at 10ms intervals
+	if elapsed := time.Since(start); elapsed < 45*time.Millisecond {
+		t.Errorf("Expected rate limited batch, took %v", elapsed)
+	}
+}
+
+func TestShardBy(t *testing.T) {
+	type event struct {
+		customer string
+		seq      int
+	}
+
+	var input []event
+	for seq := 0; seq < 10; seq++ {
+		for _, c := range []string{"a", "b", "c"} {
+			input = append(input, event{c, seq})
+		}
+	}
+
+	var mutex sync.Mutex
+	running := map[string]bool{}
+	last := map[string]int{}
+
+	results, _ := str.MapCtx(context.Background(), 3, input, func(_ context.Context, e event) (bool, error) {
+		mutex.Lock()
+		ok := !running[e.customer] && last[e.customer] == e.seq
+		running[e.customer] = true
+		mutex.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mutex.Lock()
+		running[e.customer] = false
+		last[e.customer] = e.seq + 1
+		mutex.Unlock()
+
+		return ok, nil
+	}, str.ShardBy(func(e event) string { return e.customer }))
+
+	for _, r := range results {
+		if !r.Output {
+			t.Errorf("Event %+v was not processed sequentially", r.Input)
+		}
+	}
+}
+
+func TestBatchTimeout(t *testing.T) {
+	input := []int{1, 2, 3, 4, 5, 6}
+	sleep := func(ctx context.Context, n int) (int, error) {
+		select {
+		case <-time.After(30 * time.Millisecond):
+			return n, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	// 2 items start at 0ms, 2 more at 30ms, and those are canceled at 50ms
+	results, err := str.MapCtx(context.Background(), 2, input, sleep, str.BatchTimeout(50*time.Millisecond))
+	if !errors.Is(err, str.ErrIncomplete) || len(results) != 2 {
+		t.Errorf("Expected 2 results and ErrIncomplete, received %d and %v", len(results), err)
+	}
+
+	// the 2 items started at 30ms finish at 60ms
+	results, err = str.MapCtx(context.Background(), 2, input, sleep, str.BatchTimeout(50*time.Millisecond), str.DrainInFlight())
+	if !errors.Is(err, str.ErrIncomplete) || len(results) != 4 || str.Errors(results) != nil {
+		t.Errorf("Expected 4 results and ErrIncomplete, received %d and %v", len(results), err)
+	}
+}
+
+func TestMapOrderedLarge(t *testing.T) {
+	input := make([]int, 10000)
+	for i := range input {
+		input[i] = i
+	}
+
+	output := str.Map(8, input, func(n int) int { return n }, str.Ordered())
+	for i, n := range output {
+		if n != i {
+			t.Fatalf("For index %d, expected %d, not %d", i, i, n)
+		}
+	}
+}
+
+func benchmarkMap(b *testing.B, size int, opts ...str.Option) {
+	input := make([]int, size)
+	for i := range input {
+		input[i] = i
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		str.Map(8, input, func(n int) int { return n * n }, opts...)
+	}
+}
+
+func BenchmarkMap1K(b *testing.B)        { benchmarkMap(b, 1000) }
+func BenchmarkMap1M(b *testing.B)        { benchmarkMap(b, 1000000) }
+func BenchmarkMapOrdered1M(b *testing.B) { benchmarkMap(b, 1000000, str.Ordered()) }
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	str.MapErr(2, []int{1, 2, 3}, func(n int) (int, error) {
+		if n == 2 {
+			return 0, errors.New("two")
+		}
+		return n, nil
+	}, str.Logger(logger))
+
+	log := buf.String()
+	if n := strings.Count(log, "msg=\"item started\""); n != 3 {
+		t.Errorf("Expected 3 items started, logged %d", n)
+	}
+	if n := strings.Count(log, "msg=\"item done\""); n != 2 {
+		t.Errorf("Expected 2 items done, logged %d", n)
+	}
+	if !strings.Contains(log, "level=WARN msg=\"item failed\" index=1 input=2") || !strings.Contains(log, "error=two") {
+		t.Errorf("Expected failure of input 2 to be logged, received %s", log)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	var mu sync.Mutex
+	var starts []time.Time
+
+	start := time.Now()
+	str.Map(4, []int{1, 2, 3, 4}, func(n int) int {
+		mu.Lock()
+		starts = append(starts, time.Now())
+		mu.Unlock()
+		return n
+	}, str.Jitter(50*time.Millisecond))
+
+	for _, s := range starts {
+		if d := s.Sub(start); d > 100*time.Millisecond {
+			t.Errorf("Expected items to start within the jitter window, started after %v", d)
+		}
+	}
+
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	if starts[3].Sub(starts[0]) == 0 {
+		t.Error("Expected staggered starts")
+	}
+}
+
+func BenchmarkMapWorkers(b *testing.B) {
+	input := make([]int, 100000)
+	for _, n := range []int{1, 4, 16, 64} {
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				str.Map(n, input, func(n int) int { return n + 1 })
+			}
+		})
+	}
+}
+
+func TestOptionType(t *testing.T) {
+	tests := []struct {
+		name   string
+		option str.Option
+		err    error
+	}{
+		{"ShardBy", str.ShardBy(func(s string) string { return s }), str.ErrOptionType},
+		{"DedupeBy", str.DedupeBy(func(s string) string { return s }), str.ErrOptionType},
+		{"Weighted", str.Weighted(10, func(s string) int64 { return 1 }), str.ErrOptionType},
+		{"matching", str.ShardBy(func(n int) string { return fmt.Sprint(n % 2) }), nil},
+		{"interface", str.DedupeBy(func(s fmt.Stringer) string { return s.String() }), str.ErrOptionType},
+	}
+
+	for _, tt := range tests {
+		_, err := str.MapCtx(context.Background(), 2, []int{1, 2, 3}, square, tt.option)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected %v, received %v", tt.name, tt.err, err)
+		}
+
+		p := str.NewPool(2, square, tt.option)
+		if _, err := p.Submit(1); !errors.Is(err, tt.err) {
+			t.Errorf("%s pool: expected %v, received %v", tt.name, tt.err, err)
+		}
+		p.Close()
+	}
+}
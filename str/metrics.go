@@ -0,0 +1,154 @@
+package str
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metrics receives the events of a batch or Pool, to monitor it (see
+// PrometheusMetrics).  Methods are called concurrently by the workers.
+type Metrics interface {
+	// Queued is called when n items are queued
+	Queued(n int)
+
+	// Started is called when an item starts, after waiting in the queue
+	Started(wait time.Duration)
+
+	// Done is called when an item completes, after running for d
+	Done(d time.Duration, err error)
+}
+
+// ReportMetrics sends the events of a batch or Pool to m.
+func ReportMetrics(m Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// PrometheusMetrics collects the Metrics of pools by name, and serves them
+// in the Prometheus text format, e.g.
+//
+//	metrics := str.NewPrometheusMetrics()
+//	uploads := str.NewPool(8, upload, str.ReportMetrics(metrics.Pool("uploads")))
+//	http.Handle("/metrics", metrics)
+type PrometheusMetrics struct {
+	mu    sync.Mutex
+	pools map[string]*poolMetrics
+}
+
+// NewPrometheusMetrics returns an empty PrometheusMetrics.
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{pools: make(map[string]*poolMetrics)}
+}
+
+// Pool returns the Metrics of the pool (or batch) called name, labeled
+// pool="name".
+func (m *PrometheusMetrics) Pool(name string) Metrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.pools[name]
+	if !ok {
+		p = &poolMetrics{}
+		m.pools[name] = p
+	}
+
+	return p
+}
+
+// metric describes one of the metrics written by PrometheusMetrics
+type metric struct {
+	name, kind, help string
+	value            func(c counts) float64
+}
+
+var metrics = []metric{
+	{"str_items_queued_total", "counter", "Items queued.", func(c counts) float64 { return float64(c.queued) }},
+	{"str_items_waiting", "gauge", "Items waiting in the queue.", func(c counts) float64 { return float64(c.queued - c.started) }},
+	{"str_items_in_flight", "gauge", "Items being processed.", func(c counts) float64 { return float64(c.started - c.completed) }},
+	{"str_items_completed_total", "counter", "Items completed, including failures.", func(c counts) float64 { return float64(c.completed) }},
+	{"str_items_failed_total", "counter", "Items that failed.", func(c counts) float64 { return float64(c.failed) }},
+	{"str_item_queue_seconds_sum", "counter", "Time items waited in the queue.", func(c counts) float64 { return c.wait.Seconds() }},
+	{"str_item_work_seconds_sum", "counter", "Time items took to process.", func(c counts) float64 { return c.work.Seconds() }},
+}
+
+// WriteTo writes the metrics of every pool to w, in the Prometheus text
+// format.
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	names := make([]string, 0, len(m.pools))
+	snapshots := make(map[string]counts, len(m.pools))
+	for name, p := range m.pools {
+		names = append(names, name)
+		snapshots[name] = p.snapshot()
+	}
+	m.mu.Unlock()
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, metric := range metrics {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind)
+		for _, name := range names {
+			fmt.Fprintf(&buf, "%s{pool=%q} %g\n", metric.name, name, metric.value(snapshots[name]))
+		}
+	}
+
+	return buf.WriteTo(w)
+}
+
+// ServeHTTP serves the metrics of every pool, for Prometheus to scrape.
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.WriteTo(w)
+}
+
+// counts are the metrics of a pool
+type counts struct {
+	queued    int64
+	started   int64
+	completed int64
+	failed    int64
+	wait      time.Duration
+	work      time.Duration
+}
+
+// poolMetrics counts the events of a pool
+type poolMetrics struct {
+	mu sync.Mutex
+	c  counts
+}
+
+func (p *poolMetrics) Queued(n int) {
+	p.mu.Lock()
+	p.c.queued += int64(n)
+	p.mu.Unlock()
+}
+
+func (p *poolMetrics) Started(wait time.Duration) {
+	p.mu.Lock()
+	p.c.started++
+	p.c.wait += wait
+	p.mu.Unlock()
+}
+
+func (p *poolMetrics) Done(d time.Duration, err error) {
+	p.mu.Lock()
+	p.c.completed++
+	p.c.work += d
+	if err != nil {
+		p.c.failed++
+	}
+	p.mu.Unlock()
+}
+
+func (p *poolMetrics) snapshot() counts {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.c
+}
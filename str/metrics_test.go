@@ -0,0 +1,39 @@
+package str_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sspencer/goal/str"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	metrics := str.NewPrometheusMetrics()
+
+	str.MapErr(2, []int{1, 2, 3, 4}, func(n int) (int, error) {
+		if n == 4 {
+			return 0, errors.New("four")
+		}
+		return n, nil
+	}, str.ReportMetrics(metrics.Pool("squares")))
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, expected := range []string{
+		"# TYPE str_items_queued_total counter",
+		`str_items_queued_total{pool="squares"} 4`,
+		`str_items_waiting{pool="squares"} 0`,
+		`str_items_in_flight{pool="squares"} 0`,
+		`str_items_completed_total{pool="squares"} 4`,
+		`str_items_failed_total{pool="squares"} 1`,
+		`str_item_work_seconds_sum{pool="squares"} `,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %q in metrics:\n%s", expected, body)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package str
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/sspencer/goal/backoff"
+	"github.com/sspencer/goal/logx"
+)
+
+// ErrOptionType is the error of batches and pools given an option for
+// items of another type, e.g. ShardBy[string] for a batch of ints.
+var ErrOptionType = errors.New("option for another item type")
+
+// Option configures how the worker pool processes a batch.
+type Option func(*options)
+
+type options struct {
+	ordered     bool
+	onProgress  func(done, total int)
+	itemTimeout time.Duration
+
+	maxAttempts   int
+	retryBackoff  backoff.Strategy
+	customBackoff bool
+
+	rethrow  bool
+	failFast bool
+
+	minWorkers  int
+	maxWorkers  int
+	idleTimeout time.Duration
+
+	rate  float64
+	burst int
+
+	queueSize int
+
+	setup   func(workerID int) (any, error)
+	cleanup func(state any)
+
+	aging time.Duration
+
+	shardKey func(any) string
+
+	stats *Stats
+
+	batchTimeout time.Duration
+	drain        bool
+
+	dedupeKey func(any) any
+
+	logger logx.Logger
+
+	capacity int64
+	cost     func(any) int64
+
+	dropEmpty bool
+
+	jitter time.Duration
+
+	checkpoint *Checkpoint
+
+	metrics Metrics
+
+	itemTypes []itemType // expected by the typed options
+}
+
+// itemType is the type of items an option applies to
+type itemType struct {
+	option string
+	t      reflect.Type
+}
+
+// expect records that the option applies to items of type T
+func expect[T any](o *options, option string) {
+	o.itemTypes = append(o.itemTypes, itemType{option, reflect.TypeFor[T]()})
+}
+
+// checkTypes returns ErrOptionType if an option applies to items other than
+// those of type T
+func checkTypes[T any](o *options) error {
+	t := reflect.TypeFor[T]()
+	for _, it := range o.itemTypes {
+		if !t.AssignableTo(it.t) {
+			return fmt.Errorf("%w: %s for %s, items are %s", ErrOptionType, it.option, it.t, t)
+		}
+	}
+
+	return nil
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{maxAttempts: 1}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// Ordered returns results in the order of the input instead of the order
+// they complete, so output[i] belongs to input[i].
+func Ordered() Option {
+	return func(o *options) {
+		o.ordered = true
+	}
+}
+
+// DropEmpty leaves the empty strings returned by a StringWorker out of the
+// output of Worker and WorkerCtx.  By default, every input gets an output.
+func DropEmpty() Option {
+	return func(o *options) {
+		o.dropEmpty = true
+	}
+}
+
+// OnProgress calls fn after every item completes, with the number of items
+// done so far and the total (-1 when reading input from a channel), e.g. to
+// render "37/230 processed".  Calls are serialized.
+func OnProgress(fn func(done, total int)) Option {
+	return func(o *options) {
+		o.onProgress = fn
+	}
+}
+
+// ItemTimeout bounds how long a single item may run.  Items that exceed it
+// get ErrTimeout as their error, so one hung item can't stall the batch.
+func ItemTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.itemTimeout = d
+	}
+}
+
+// RetryPolicy retries items whose work returns an error, up to maxAttempts
+// in total.  The delay before a retry starts at delay and doubles every
+// time.  Result.Attempts records how many attempts were made.
+func RetryPolicy(maxAttempts int, delay time.Duration) Option {
+	return func(o *options) {
+		if maxAttempts < 1 {
+			maxAttempts = 1
+		}
+		o.maxAttempts = maxAttempts
+		if !o.customBackoff {
+			o.retryBackoff = backoff.Exponential(delay, 2)
+		}
+	}
+}
+
+// RetryBackoff replaces the delays between the retries of RetryPolicy with
+// those of s, e.g. backoff.DecorrelatedJitter to spread out retries.  It
+// takes precedence over the delay of RetryPolicy, whatever the order of the
+// options.
+func RetryBackoff(s backoff.Strategy) Option {
+	return func(o *options) {
+		o.retryBackoff = s
+		o.customBackoff = true
+	}
+}
+
+// RethrowPanics lets panics in work items crash the program.  By default,
+// they are recovered and reported as a PanicError for the item.
+func RethrowPanics() Option {
+	return func(o *options) {
+		o.rethrow = true
+	}
+}
+
+// FailFast cancels all pending work as soon as any item fails, and returns
+// that error, for pipelines where partial results are useless.
+func FailFast() Option {
+	return func(o *options) {
+		o.failFast = true
+	}
+}
+
+// Scale lets a Pool (not a batch) grow from min up to max workers when
+// submitted items back up, and shrink back when workers have been idle
+// for a second (see IdleTimeout).  With a min of 0, all workers exit while
+// the pool is idle.
+func Scale(min, max int) Option {
+	return func(o *options) {
+		if min < 0 {
+			min = 0
+		}
+		o.minWorkers = min
+		if o.minWorkers > MaxThreads {
+			o.minWorkers = MaxThreads
+		}
+		o.maxWorkers = boundWorkers(max, MaxThreads)
+		if o.maxWorkers < o.minWorkers {
+			o.maxWorkers = o.minWorkers
+		}
+		if o.idleTimeout == 0 {
+			o.idleTimeout = time.Second
+		}
+	}
+}
+
+// IdleTimeout lets the workers of a Pool exit once they have been idle for
+// d, releasing their Setup state, so a long-lived service doesn't hold on
+// to resources between rare bursts.  Workers are started again as items
+// are submitted.  Without Scale, the pool shrinks all the way to zero.
+func IdleTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = d
+	}
+}
+
+// RateLimit limits how many items start per second, independent of the
+// number of workers, allowing bursts of up to 'burst' items.  A 32 worker
+// pool calling an external API can then stay under its quota.
+func RateLimit(perSecond float64, burst int) Option {
+	return func(o *options) {
+		o.rate = perSecond
+		o.burst = burst
+	}
+}
+
+// Jitter delays the start of every item by a random duration of up to
+// window, so workers don't all hit the same downstream service at once when
+// a batch starts.
+func Jitter(window time.Duration) Option {
+	return func(o *options) {
+		o.jitter = window
+	}
+}
+
+// QueueSize lets a Pool queue up to n submitted items while all workers are
+// busy.  Once the queue is full, Submit blocks and TrySubmit fails with
+// ErrQueueFull, so memory stays flat when workers fall behind.
+func QueueSize(n int) Option {
+	return func(o *options) {
+		o.queueSize = n
+	}
+}
+
+// Priorities schedules the items of a Pool by the priority given to
+// SubmitPriority (higher first) instead of first come, first served.  To
+// keep low priority items from starving, each priority level is worth
+// 'aging' of waiting time: an item of priority 0 that has waited 3*aging
+// goes ahead of a new item of priority 2.
+func Priorities(aging time.Duration) Option {
+	return func(o *options) {
+		if aging <= 0 {
+			aging = time.Second
+		}
+		o.aging = aging
+	}
+}
+
+// ShardBy sends every item to a fixed worker, picked by the hash of its key.
+// Items with the same key (same customer, same file) are then processed
+// sequentially, in the order they were submitted, while different keys run
+// in parallel.  Sharded pools don't Scale.  T must be the type of the
+// items, or the batch fails with ErrOptionType.
+func ShardBy[T any](key func(T) string) Option {
+	return func(o *options) {
+		expect[T](o, "ShardBy")
+		o.shardKey = func(v any) string {
+			in, ok := v.(T)
+			if !ok {
+				return "" // the batch failed with ErrOptionType
+			}
+			return key(in)
+		}
+	}
+}
+
+// BatchTimeout bounds the runtime of a whole batch (not a Pool).  Once it
+// expires, no more items are dispatched, in-flight items are canceled, and
+// the results so far are returned with ErrIncomplete.
+func BatchTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.batchTimeout = d
+	}
+}
+
+// DrainInFlight lets in-flight items finish after the BatchTimeout expires,
+// instead of canceling them.  Their results are returned too.
+func DrainInFlight() Option {
+	return func(o *options) {
+		o.drain = true
+	}
+}
+
+// Logger records every item on l: its start and completion at debug level,
+// and failures at warn level, along with the worker ID, duration and
+// attempts, so work functions don't need logging of their own.  A
+// *slog.Logger is a logx.Logger.
+func Logger(l logx.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
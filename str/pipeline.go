@@ -0,0 +1,105 @@
+package str
+
+import (
+	"context"
+	"sync"
+)
+
+// Pipeline chains worker stages (parse -> transform -> upload), each with
+// its own concurrency.  Stages are connected by bounded channels, so a slow
+// stage holds back the ones before it, and the first item error cancels
+// every stage.
+//
+//	p := str.NewPipeline(ctx, 100)
+//	parsed := str.Stage(p, 4, str.Source(p, files), parse)
+//	uploaded := str.Stage(p, 16, parsed, upload)
+//	urls, err := str.Collect(p, uploaded)
+type Pipeline struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	buffer int
+	wg     sync.WaitGroup
+}
+
+// NewPipeline creates a pipeline whose stages are connected by channels
+// buffering up to 'buffer' items.
+func NewPipeline(ctx context.Context, buffer int) *Pipeline {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Pipeline{ctx: ctx, cancel: cancel, buffer: buffer}
+}
+
+// Context returns the context shared by all stages, which is canceled when
+// any stage fails.
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Source feeds input into the pipeline.
+func Source[T any](p *Pipeline, input []T) <-chan T {
+	out := make(chan T, p.buffer)
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(out)
+
+		for _, in := range input {
+			select {
+			case out <- in:
+			case <-p.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Stage adds a stage calling fn on every item from input with up to
+// 'numWorkers' workers.  Outputs are sent on, in the order they complete.
+// An item error cancels the pipeline, with an ItemError.
+func Stage[T, R any](p *Pipeline, numWorkers int, input <-chan T, fn func(context.Context, T) (R, error), opts ...Option) <-chan R {
+	out := make(chan R, p.buffer)
+	results := StreamChan(p.ctx, numWorkers, input, fn, opts...)
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(out)
+
+		for r := range results {
+			if r.Err != nil {
+				p.cancel(&ItemError[T]{r.Index, r.Input, r.Err})
+				continue // until the stream closes
+			}
+
+			select {
+			case out <- r.Output:
+			case <-p.ctx.Done():
+			}
+		}
+	}()
+
+	return out
+}
+
+// Wait waits for every stage to finish, and returns the error that
+// canceled the pipeline, if any.  The output of the last stage must be
+// read for the stages to finish.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	err := context.Cause(p.ctx)
+	p.cancel(nil)
+
+	return err
+}
+
+// Collect reads all output of the last stage, then waits for the pipeline.
+func Collect[T any](p *Pipeline, input <-chan T) ([]T, error) {
+	var output []T
+	for out := range input {
+		output = append(output, out)
+	}
+
+	return output, p.Wait()
+}
@@ -0,0 +1,57 @@
+package str_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/sspencer/goal/str"
+)
+
+func TestPipeline(t *testing.T) {
+	p := str.NewPipeline(context.Background(), 2)
+
+	parsed := str.Stage(p, 2, str.Source(p, []string{"1", "2", "3", "4"}), func(_ context.Context, s string) (int, error) {
+		return strconv.Atoi(s)
+	})
+	squared := str.Stage(p, 3, parsed, square)
+
+	output, err := str.Collect(p, squared)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	sort.Ints(output)
+	expected := []int{1, 4, 9, 16}
+	for i := range expected {
+		if output[i] != expected[i] {
+			t.Errorf("Expected %v, received %v", expected, output)
+			break
+		}
+	}
+}
+
+func TestPipelineError(t *testing.T) {
+	input := make([]string, 1000)
+	for i := range input {
+		input[i] = strconv.Itoa(i)
+	}
+	input[5] = "five"
+
+	p := str.NewPipeline(context.Background(), 2)
+	parsed := str.Stage(p, 2, str.Source(p, input), func(_ context.Context, s string) (int, error) {
+		return strconv.Atoi(s)
+	})
+
+	output, err := str.Collect(p, str.Stage(p, 2, parsed, square))
+
+	var ie *str.ItemError[string]
+	if !errors.As(err, &ie) || ie.Input != "five" {
+		t.Errorf("Expected item error for five, received %v", err)
+	}
+	if len(output) >= len(input)-1 {
+		t.Errorf("Expected pipeline to stop early, received %d", len(output))
+	}
+}
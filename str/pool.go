@@ -0,0 +1,250 @@
+package str
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrPoolClosed is returned when submitting to a closed Pool.
+var ErrPoolClosed = errors.New("pool is closed")
+
+// ErrPoolStopped is the error of items canceled by Pool.Stop.
+var ErrPoolStopped = errors.New("pool stopped")
+
+// ErrQueueFull is returned by TrySubmit when the Pool can't take more work.
+var ErrQueueFull = errors.New("pool queue is full")
+
+// Pool is a long-lived worker pool.  Work is submitted continuously instead
+// of in batches, so services can keep their workers warm.
+type Pool[T, R any] struct {
+	b       *batch[T, R]
+	jobs    chan job[T]
+	results <-chan Result[T, R]
+
+	mu      sync.RWMutex // guards closing jobs while submitting
+	closed  bool
+	closing chan struct{} // closed first by Close, to wake up blocked submitters
+	once    sync.Once
+	next    atomic.Int64
+	queue   *priorityQueue[T] // with the Priorities option
+	futures futures[R]
+	journal *journal    // with NewJournaledPool
+	reading atomic.Bool // once Results is called
+}
+
+// NewPool starts 'numWorkers' workers calling fn on every submitted item.
+// Options apply to every item, e.g. ItemTimeout or RetryPolicy.
+func NewPool[T, R any](numWorkers int, fn func(context.Context, T) (R, error), opts ...Option) *Pool[T, R] {
+	o := newOptions(opts)
+	p := &Pool[T, R]{b: newBatch(context.Background(), fn, o, -1), closing: make(chan struct{})}
+	if p.b.stats == nil {
+		p.b.stats = newStatsCollector() // for Stats
+	}
+	if o.idleTimeout > 0 && o.maxWorkers == 0 {
+		o.maxWorkers = boundWorkers(numWorkers, MaxThreads)
+	}
+	p.b.scaling = o.maxWorkers > 0 && o.shardKey == nil
+
+	// released once no more jobs are sent, so the pool isn't closed while
+	// it is scaled down to zero workers
+	p.b.wg.Add(1)
+	p.b.begin = p.futures.begin
+	p.b.settle = p.settle
+	p.b.discard = func() bool { return !p.reading.Load() }
+	p.futures.pending = make(map[int]*Future[R])
+
+	if o.aging > 0 {
+		// the queue holds the backlog, so the dispatcher hands jobs over directly
+		p.jobs = make(chan job[T])
+		p.queue = newPriorityQueue[T](o.aging, o.queueSize)
+		go dispatch(p.queue, p.b, p.jobs)
+	} else {
+		p.jobs = make(chan job[T], o.queueSize)
+	}
+
+	p.results = p.b.run(boundWorkers(numWorkers, MaxThreads), p.jobs, true)
+
+	go func() {
+		// items dropped by Stop never complete
+		<-p.b.done
+		p.futures.fail(ErrPoolStopped)
+	}()
+
+	return p
+}
+
+// Submit queues an item, blocking until a worker is free to take it (or
+// there is room in the queue), or adding a worker with the Scale option.
+// The returned Future completes with the output of the item, which is also
+// sent on Results once it has been called.  Results must then be read
+// concurrently, or workers stall once they are done.
+func (p *Pool[T, R]) Submit(item T) (*Future[R], error) {
+	return p.submit(item, 0, true)
+}
+
+// TrySubmit is like Submit, but fails with ErrQueueFull instead of blocking
+// when no worker is free and the queue (see QueueSize) is full.
+func (p *Pool[T, R]) TrySubmit(item T) (*Future[R], error) {
+	return p.submit(item, 0, false)
+}
+
+// SubmitPriority is like Submit, for pools with the Priorities option.
+// Items with a higher priority are processed first.
+func (p *Pool[T, R]) SubmitPriority(item T, priority int) (*Future[R], error) {
+	return p.submit(item, priority, true)
+}
+
+func (p *Pool[T, R]) submit(item T, priority int, block bool) (*Future[R], error) {
+	return p.enqueue(item, priority, block, -1)
+}
+
+// enqueue queues an item, recording it in the journal (if any) under a new
+// ID, or the given ID for items resumed from the journal
+func (p *Pool[T, R]) enqueue(item T, priority int, block bool, journalID int64) (*Future[R], error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	select {
+	case <-p.closing:
+		return nil, ErrPoolClosed
+	default:
+	}
+	if err := context.Cause(p.b.ctx); errors.Is(err, ErrOptionType) {
+		return nil, err
+	}
+
+	j := job[T]{index: int(p.next.Add(1) - 1), input: item}
+	if p.b.o.metrics != nil {
+		j.queued = time.Now()
+	}
+
+	if p.journal != nil {
+		if err := p.journal.add(j.index, item, journalID); err != nil {
+			return nil, err
+		}
+	}
+
+	// the item may complete before pushing returns
+	f := p.futures.add(j.index)
+	if err := p.push(j, priority, block); err != nil {
+		p.futures.remove(j.index)
+		if p.journal != nil && journalID < 0 {
+			p.journal.done(j.index)
+		}
+		return nil, err
+	}
+
+	if p.b.o.metrics != nil {
+		p.b.o.metrics.Queued(1)
+	}
+
+	return f, nil
+}
+
+// settle completes the Future of an item, and records it as done in the
+// journal, unless it was interrupted by Stop
+func (p *Pool[T, R]) settle(r Result[T, R]) {
+	p.futures.settle(r.Index, r.Output, r.Err)
+
+	if p.journal != nil && p.b.ctx.Err() == nil {
+		p.journal.done(r.Index)
+	}
+}
+
+func (p *Pool[T, R]) push(j job[T], priority int, block bool) error {
+	if p.queue != nil {
+		return p.queue.push(p.b.ctx, j, priority, block)
+	}
+
+	select {
+	case p.jobs <- j:
+		if p.b.size.Load() == 0 {
+			p.b.grow() // queued while scaled down to zero workers
+		}
+		return nil
+	default:
+	}
+
+	if !p.b.grow() && !block {
+		return ErrQueueFull
+	}
+
+	// submitters hold mu while blocked here, so they give up for Close
+	select {
+	case p.jobs <- j:
+		return nil
+	case <-p.closing:
+		return ErrPoolClosed
+	case <-p.b.ctx.Done():
+		return context.Cause(p.b.ctx)
+	}
+}
+
+// Size returns the current number of workers.
+func (p *Pool[T, R]) Size() int {
+	return int(p.b.size.Load())
+}
+
+// Stats returns the statistics of the pool so far.
+func (p *Pool[T, R]) Stats() Stats {
+	return p.b.stats.snapshot()
+}
+
+// Results returns the results of submitted items as they complete.  The
+// channel is closed after Close, once all submitted work is done.  Only
+// the items completing after the first call are sent, so pools used
+// through Futures alone don't need their results read.
+func (p *Pool[T, R]) Results() <-chan Result[T, R] {
+	p.reading.Store(true)
+	return p.results
+}
+
+// Close stops accepting items.  Work already submitted still completes,
+// and submissions blocked waiting for room fail with ErrPoolClosed.
+func (p *Pool[T, R]) Close() {
+	p.once.Do(func() {
+		close(p.closing)
+		if p.queue != nil {
+			p.queue.close() // the dispatcher closes jobs once the queue is empty
+		}
+	})
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	p.closed = true
+	if p.queue == nil {
+		close(p.jobs)
+		p.b.wg.Done()
+	}
+}
+
+// Drain stops accepting items and waits until all submitted work is done.
+// Results, once called, must be read concurrently, or Drain waits forever.
+func (p *Pool[T, R]) Drain() {
+	p.Close()
+	<-p.b.done
+}
+
+// Stop stops accepting items and waits for submitted work until ctx is
+// done.  Outstanding work is then canceled with ErrPoolStopped (queued
+// items are dropped, in-flight items have their context canceled) and
+// ctx.Err() is returned without waiting any longer.
+func (p *Pool[T, R]) Stop(ctx context.Context) error {
+	go p.Close() // returns once blocked submissions give up
+
+	select {
+	case <-p.b.done:
+		return nil
+	case <-ctx.Done():
+		p.b.cancel(ErrPoolStopped)
+		return ctx.Err()
+	}
+}
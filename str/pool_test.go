@@ -0,0 +1,433 @@
+package str_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/str"
+)
+
+func square(_ context.Context, n int) (int, error) {
+	return n * n, nil
+}
+
+func TestPool(t *testing.T) {
+	p := str.NewPool(3, square)
+	results := p.Results()
+
+	go func() {
+		for n := 0; n < 10; n++ {
+			if _, err := p.Submit(n); err != nil {
+				t.Errorf("Unexpected submit error %v", err)
+			}
+		}
+		p.Close()
+	}()
+
+	count := 0
+	for r := range results {
+		if r.Output != r.Input*r.Input {
+			t.Errorf("For %d, expected %d, not %d", r.Input, r.Input*r.Input, r.Output)
+		}
+		count++
+	}
+
+	if count != 10 {
+		t.Errorf("Expected 10 results, received %d", count)
+	}
+
+	if _, err := p.Submit(1); err != str.ErrPoolClosed {
+		t.Errorf("Expected ErrPoolClosed, received %v", err)
+	}
+}
+
+func TestPoolScale(t *testing.T) {
+	release := make(chan bool)
+	p := str.NewPool(1, func(_ context.Context, n int) (int, error) {
+		<-release
+		return n, nil
+	}, str.Scale(1, 4))
+
+	go func() {
+		for range p.Results() {
+		}
+	}()
+
+	for n := 0; n < 4; n++ {
+		p.Submit(n)
+	}
+
+	if size := p.Size(); size != 4 {
+		t.Errorf("Expected pool to grow to 4 workers, has %d", size)
+	}
+
+	close(release)
+	p.Close()
+}
+
+func TestPoolQueueFull(t *testing.T) {
+	release := make(chan bool)
+	p := str.NewPool(1, func(_ context.Context, n int) (int, error) {
+		<-release
+		return n, nil
+	}, str.QueueSize(2))
+
+	go func() {
+		for range p.Results() {
+		}
+	}()
+
+	// one for the worker, two for the queue
+	for n := 0; n < 3; n++ {
+		if _, err := p.Submit(n); err != nil {
+			t.Fatalf("Unexpected submit error %v", err)
+		}
+	}
+
+	if _, err := p.TrySubmit(3); err != str.ErrQueueFull {
+		t.Errorf("Expected ErrQueueFull, received %v", err)
+	}
+
+	close(release)
+	p.Close()
+}
+
+// blockedPool returns a single worker pool, busy with item 0 until release
+// is closed, so that items submitted next queue up
+func blockedPool(release chan bool, opts ...str.Option) *str.Pool[int, int] {
+	started := make(chan bool)
+	p := str.NewPool(1, func(_ context.Context, n int) (int, error) {
+		if n == 0 {
+			close(started)
+		}
+		<-release
+		return n, nil
+	}, opts...)
+
+	p.Submit(0)
+	<-started
+
+	return p
+}
+
+func TestPoolPriorities(t *testing.T) {
+	release := make(chan bool)
+	p := blockedPool(release, str.Priorities(time.Hour))
+	results := p.Results()
+
+	for n := 1; n <= 3; n++ {
+		p.SubmitPriority(n, n)
+	}
+
+	close(release)
+	p.Close()
+
+	var order []int
+	for r := range results {
+		order = append(order, r.Input)
+	}
+
+	// the dispatcher may take 1 as soon as it is queued, waiting for the worker
+	if o := fmt.Sprint(order); o != "[0 1 3 2]" && o != "[0 3 2 1]" {
+		t.Errorf("Expected higher priorities first, received %v", order)
+	}
+}
+
+func TestPoolPriorityAging(t *testing.T) {
+	release := make(chan bool)
+	p := blockedPool(release, str.Priorities(time.Millisecond))
+	results := p.Results()
+
+	p.SubmitPriority(1, 0)
+	time.Sleep(20 * time.Millisecond)
+	p.SubmitPriority(2, 5)
+
+	close(release)
+	p.Close()
+
+	var order []int
+	for r := range results {
+		order = append(order, r.Input)
+	}
+
+	if len(order) != 3 || order[1] != 1 {
+		t.Errorf("Expected old low priority item first, received %v", order)
+	}
+}
+
+func TestPoolDrain(t *testing.T) {
+	p := str.NewPool(2, square, str.QueueSize(10))
+	results := p.Results()
+
+	for n := 0; n < 10; n++ {
+		p.Submit(n)
+	}
+
+	count := 0
+	read := make(chan bool)
+	go func() {
+		for range results {
+			count++
+		}
+		close(read)
+	}()
+
+	p.Drain()
+	<-read
+	if count != 10 {
+		t.Errorf("Expected 10 results after drain, received %d", count)
+	}
+}
+
+func TestPoolStop(t *testing.T) {
+	p := str.NewPool(1, func(ctx context.Context, n int) (int, error) {
+		<-ctx.Done()
+		return 0, context.Cause(ctx)
+	}, str.QueueSize(5))
+
+	go func() {
+		for range p.Results() {
+		}
+	}()
+
+	for n := 0; n < 5; n++ {
+		p.Submit(n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := p.Stop(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected deadline exceeded, received %v", err)
+	}
+}
+
+func TestPoolStopBlocked(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []str.Option
+	}{
+		{"queue", []str.Option{str.QueueSize(1)}},
+		{"priorities", []str.Option{str.QueueSize(1), str.Priorities(time.Hour)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := str.NewPool(1, func(ctx context.Context, n int) (int, error) {
+				<-ctx.Done()
+				return 0, context.Cause(ctx)
+			}, tt.opts...)
+
+			// more items than the worker and queue take, so Submit blocks
+			submitted := make(chan error)
+			go func() {
+				var err error
+				for n := 0; n < 10 && err == nil; n++ {
+					_, err = p.Submit(n)
+				}
+				submitted <- err
+			}()
+			time.Sleep(10 * time.Millisecond)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			stopped := make(chan error)
+			go func() { stopped <- p.Stop(ctx) }()
+
+			select {
+			case err := <-stopped:
+				if err != context.DeadlineExceeded {
+					t.Errorf("Expected deadline exceeded, received %v", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Stop did not honor its deadline while a Submit was blocked")
+			}
+
+			if err := <-submitted; err != str.ErrPoolClosed {
+				t.Errorf("Expected the blocked Submit to fail with ErrPoolClosed, received %v", err)
+			}
+		})
+	}
+}
+
+func TestPoolFuture(t *testing.T) {
+	p := str.NewPool(2, square)
+	defer p.Close()
+
+	go func() {
+		for range p.Results() {
+		}
+	}()
+
+	f, err := p.Submit(7)
+	if err != nil {
+		t.Fatalf("Unexpected submit error %v", err)
+	}
+
+	<-f.Done()
+	if out, err := f.Wait(context.Background()); out != 49 || err != nil {
+		t.Errorf("Expected 49, received %d, %v", out, err)
+	}
+}
+
+func TestPoolFutureOnly(t *testing.T) {
+	p := str.NewPool(2, square)
+	defer p.Close()
+
+	// Results is never read, so workers must not wait for it
+	for n := 0; n < 10; n++ {
+		f, err := p.Submit(n)
+		if err != nil {
+			t.Fatalf("Unexpected submit error %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		out, err := f.Wait(ctx)
+		cancel()
+		if out != n*n || err != nil {
+			t.Fatalf("For %d, expected %d, received %d, %v", n, n*n, out, err)
+		}
+	}
+}
+
+func TestPoolFutureStopped(t *testing.T) {
+	p := str.NewPool(1, func(ctx context.Context, n int) (int, error) {
+		<-ctx.Done()
+		return 0, context.Cause(ctx)
+	}, str.QueueSize(5))
+
+	go func() {
+		for range p.Results() {
+		}
+	}()
+
+	var futures []*str.Future[int]
+	for n := 0; n < 3; n++ {
+		f, _ := p.Submit(n)
+		futures = append(futures, f)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	p.Stop(ctx)
+
+	for n, f := range futures {
+		if _, err := f.Wait(context.Background()); !errors.Is(err, str.ErrPoolStopped) {
+			t.Errorf("For %d, expected ErrPoolStopped, received %v", n, err)
+		}
+	}
+}
+
+func TestPoolCancel(t *testing.T) {
+	release := make(chan bool)
+	started := make(chan int, 3)
+	p := str.NewPool(1, func(ctx context.Context, n int) (int, error) {
+		started <- n
+		if n == 0 {
+			<-release
+		} else {
+			<-ctx.Done()
+		}
+		return n, context.Cause(ctx)
+	}, str.QueueSize(2))
+	defer p.Close()
+
+	go func() {
+		for range p.Results() {
+		}
+	}()
+
+	first, _ := p.Submit(0)
+	queued, _ := p.Submit(1)
+	inFlight, _ := p.Submit(2)
+
+	<-started
+	if !queued.Cancel() {
+		t.Error("Expected queued item to be canceled")
+	}
+	close(release)
+	first.Wait(context.Background())
+
+	if n := <-started; n != 2 {
+		t.Errorf("Expected canceled item to be skipped, started %d", n)
+	}
+	inFlight.Cancel()
+
+	for n, f := range []*str.Future[int]{queued, inFlight} {
+		if _, err := f.Wait(context.Background()); err != str.ErrCanceled {
+			t.Errorf("For %d, expected ErrCanceled, received %v", n, err)
+		}
+	}
+
+	if first.Cancel() {
+		t.Error("Expected Cancel to fail once done")
+	}
+}
+
+func TestPoolIdleTimeout(t *testing.T) {
+	var mu sync.Mutex
+	started, stopped := 0, 0
+	p := str.NewPool(2, square, str.IdleTimeout(20*time.Millisecond), str.Setup(func(int) (int, error) {
+		mu.Lock()
+		started++
+		mu.Unlock()
+		return 0, nil
+	}, func(int) {
+		mu.Lock()
+		stopped++
+		mu.Unlock()
+	}))
+
+	go func() {
+		for range p.Results() {
+		}
+	}()
+
+	f, _ := p.Submit(2)
+	f.Wait(context.Background())
+	time.Sleep(100 * time.Millisecond)
+
+	if size := p.Size(); size != 0 {
+		t.Errorf("Expected idle pool to shrink to 0 workers, has %d", size)
+	}
+
+	mu.Lock()
+	if stopped != 2 {
+		t.Errorf("Expected 2 workers to release their state, received %d", stopped)
+	}
+	mu.Unlock()
+
+	f, _ = p.Submit(3)
+	if out, err := f.Wait(context.Background()); out != 9 || err != nil {
+		t.Errorf("Expected 9 from a respawned worker, received %d, %v", out, err)
+	}
+
+	p.Drain()
+	mu.Lock()
+	if started != 3 || stopped != 3 {
+		t.Errorf("Expected 3 workers started and stopped, received %d and %d", started, stopped)
+	}
+	mu.Unlock()
+}
+
+func BenchmarkPool(b *testing.B) {
+	p := str.NewPool(8, square, str.QueueSize(64))
+	done := make(chan bool)
+	go func() {
+		for range p.Results() {
+		}
+		close(done)
+	}()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Submit(i)
+	}
+	p.Close()
+	<-done
+}
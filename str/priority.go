@@ -0,0 +1,140 @@
+package str
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// queued is a job waiting in a priorityQueue.  Its key is the time it was
+// queued, moved earlier by its priority, so old items eventually get ahead
+// of newer items with a higher priority.
+type queued[T any] struct {
+	j   job[T]
+	key int64
+}
+
+// jobHeap implements heap.Interface, earliest key first
+type jobHeap[T any] []queued[T]
+
+func (h jobHeap[T]) Len() int { return len(h) }
+func (h jobHeap[T]) Less(i, j int) bool {
+	if h[i].key == h[j].key {
+		return h[i].j.index < h[j].j.index
+	}
+	return h[i].key < h[j].key
+}
+func (h jobHeap[T]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *jobHeap[T]) Push(x any)   { *h = append(*h, x.(queued[T])) }
+func (h *jobHeap[T]) Pop() any {
+	old := *h
+	n := len(old)
+	q := old[n-1]
+	*h = old[:n-1]
+	return q
+}
+
+// priorityQueue holds the backlog of a Pool with the Priorities option
+type priorityQueue[T any] struct {
+	aging time.Duration
+	limit int // 0 is unbounded
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	heap   jobHeap[T]
+	closed bool
+}
+
+func newPriorityQueue[T any](aging time.Duration, limit int) *priorityQueue[T] {
+	q := &priorityQueue[T]{aging: aging, limit: limit}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push queues a job, waiting for room when the queue is full and block is
+// set, until the queue is closed or ctx is canceled
+func (q *priorityQueue[T]) push(ctx context.Context, j job[T], priority int, block bool) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if block && q.limit > 0 {
+		stop := context.AfterFunc(ctx, func() {
+			q.mu.Lock()
+			q.cond.Broadcast()
+			q.mu.Unlock()
+		})
+		defer stop()
+	}
+
+	for !q.closed && q.limit > 0 && len(q.heap) >= q.limit {
+		if !block {
+			return ErrQueueFull
+		}
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		q.cond.Wait()
+	}
+	if q.closed {
+		return ErrPoolClosed
+	}
+
+	key := time.Now().UnixNano() - int64(priority)*int64(q.aging)
+	heap.Push(&q.heap, queued[T]{j, key})
+	q.cond.Broadcast()
+
+	return nil
+}
+
+// pop waits for the next job, returning FALSE once closed and empty
+func (q *priorityQueue[T]) pop() (job[T], bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.heap) == 0 {
+		if q.closed {
+			return job[T]{}, false
+		}
+		q.cond.Wait()
+	}
+
+	j := heap.Pop(&q.heap).(queued[T]).j
+	q.cond.Broadcast()
+
+	return j, true
+}
+
+func (q *priorityQueue[T]) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+}
+
+// dispatch hands queued jobs to the workers of b, until the queue is closed
+// and empty, or b is canceled
+func dispatch[T, R any](q *priorityQueue[T], b *batch[T, R], jobs chan<- job[T]) {
+	defer b.wg.Done()
+	defer close(jobs)
+
+	for {
+		j, ok := q.pop()
+		if !ok {
+			return
+		}
+
+		select {
+		case jobs <- j:
+			continue
+		default:
+			b.grow()
+		}
+
+		select {
+		case jobs <- j:
+		case <-b.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package str
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sspencer/goal/fsu"
+)
+
+// ProgressBar draws a single line progress bar on w (os.Stderr when nil)
+// while a batch runs: items done, percentage, rate and ETA.  When w is not
+// a terminal, a plain log line is written every few seconds instead.  It
+// can be combined with OnProgress.
+func ProgressBar(w io.Writer) Option {
+	return func(o *options) {
+		if w == nil {
+			w = os.Stderr
+		}
+
+		bar := &progressBar{w: w, tty: fsu.IsTerminal(w), start: time.Now()}
+		bar.interval = 5 * time.Second
+		if bar.tty {
+			bar.interval = 100 * time.Millisecond
+		}
+
+		prev := o.onProgress
+		o.onProgress = func(done, total int) {
+			if prev != nil {
+				prev(done, total)
+			}
+			bar.render(done, total)
+		}
+	}
+}
+
+// progressBar renders the ProgressBar option.  Calls are serialized by
+// the batch.
+type progressBar struct {
+	w        io.Writer
+	tty      bool
+	interval time.Duration
+	start    time.Time
+	last     time.Time
+}
+
+const barWidth = 30
+
+func (p *progressBar) render(done, total int) {
+	now := time.Now()
+	final := done == total
+	if !final && now.Sub(p.last) < p.interval {
+		return
+	}
+	p.last = now
+
+	line := p.line(done, total, now.Sub(p.start))
+	if p.tty {
+		fmt.Fprintf(p.w, "\r%s", line)
+		if final {
+			fmt.Fprintln(p.w)
+		}
+	} else {
+		fmt.Fprintln(p.w, line)
+	}
+}
+
+// line formats the progress, e.g.
+//
+//	[=========>          ] 37/112  33% 12.3/s ETA 6s
+func (p *progressBar) line(done, total int, elapsed time.Duration) string {
+	rate := float64(done) / elapsed.Seconds()
+
+	// the total is unknown when reading input from a channel
+	if total < 0 {
+		return fmt.Sprintf("%d done %.1f/s", done, rate)
+	}
+
+	filled := 0
+	if total > 0 {
+		filled = done * barWidth / total
+	}
+
+	bar := strings.Repeat("=", filled)
+	if filled < barWidth {
+		bar += ">" + strings.Repeat(" ", barWidth-filled-1)
+	}
+
+	eta := "?"
+	if rate > 0 {
+		eta = time.Duration(float64(total-done) / rate * float64(time.Second)).Round(time.Second).String()
+	}
+
+	return fmt.Sprintf("[%s] %d/%d %3d%% %.1f/s ETA %s", bar, done, total, done*100/max(total, 1), rate, eta)
+}
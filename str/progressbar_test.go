@@ -0,0 +1,31 @@
+package str_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sspencer/goal/str"
+)
+
+func TestProgressBar(t *testing.T) {
+	var buf bytes.Buffer
+	calls := 0
+
+	str.Map(2, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, func(n int) int { return n },
+		str.OnProgress(func(done, total int) { calls++ }), str.ProgressBar(&buf))
+
+	if calls != 10 {
+		t.Errorf("Expected OnProgress to be called 10 times, received %d", calls)
+	}
+
+	// not a terminal: the first update and the final one
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, received %q", lines)
+	}
+
+	if !strings.HasPrefix(lines[1], "["+strings.Repeat("=", 30)+"] 10/10 100% ") {
+		t.Errorf("Unexpected final line %q", lines[1])
+	}
+}
@@ -0,0 +1,57 @@
+package str
+
+import (
+	"context"
+)
+
+// FilterN keeps the input for which keep returns TRUE, calling it with up
+// to 'numWorkers' workers.  The output keeps the order of the input.
+func FilterN[T any](numWorkers int, input []T, keep func(T) bool, opts ...Option) []T {
+	opts = append(opts, Ordered())
+	results, _ := MapCtx(context.Background(), numWorkers, input, Func(keep), opts...)
+
+	output := make([]T, 0, len(results))
+	for _, r := range results {
+		if r.Output {
+			output = append(output, r.Input)
+		}
+	}
+
+	return output
+}
+
+// ReduceN folds input into a single value with up to 'numWorkers' workers.
+// The input is split into one chunk per worker, every chunk is folded
+// starting from identity, and the partial values are folded in order.
+// combine must therefore be associative, and identity must not change the
+// value it is combined with (e.g. 0 for +, 1 for *, "" for concatenation).
+func ReduceN[T any](numWorkers int, input []T, identity T, combine func(T, T) T) T {
+	if len(input) == 0 {
+		return identity
+	}
+
+	numWorkers = boundWorkers(numWorkers, len(input))
+	chunks := Chunk(input, (len(input)+numWorkers-1)/numWorkers)
+
+	fold := func(values []T) T {
+		acc := identity
+		for _, v := range values {
+			acc = combine(acc, v)
+		}
+		return acc
+	}
+
+	return fold(Map(numWorkers, chunks, fold, Ordered()))
+}
+
+// ForEach calls fn on every item with up to 'numWorkers' workers, for work
+// done only for its side effects (deletes, notifications).  The error joins
+// the ItemErrors of every failed item, or with the FailFast option, is the
+// first item error, which also stops the remaining work.
+func ForEach[T any](numWorkers int, items []T, fn func(T) error, opts ...Option) error {
+	_, err := MapCtx(context.Background(), numWorkers, items, func(_ context.Context, item T) (struct{}, error) {
+		return struct{}{}, fn(item)
+	}, opts...)
+
+	return err
+}
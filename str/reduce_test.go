@@ -0,0 +1,61 @@
+package str_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sspencer/goal/str"
+)
+
+func TestFilterN(t *testing.T) {
+	input := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	output := str.FilterN(3, input, func(n int) bool { return n%2 == 0 })
+	if fmt.Sprint(output) != "[2 4 6 8]" {
+		t.Errorf("Expected [2 4 6 8], received %v", output)
+	}
+}
+
+func TestReduceN(t *testing.T) {
+	tt := []struct {
+		workers int
+		input   []string
+		output  string
+	}{
+		{3, []string{}, ""},
+		{3, []string{"a"}, "a"},
+		{3, strings.Split("abcdefghij", ""), "abcdefghij"},
+		{20, strings.Split("abcdefghij", ""), "abcdefghij"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.output, func(t *testing.T) {
+			r := str.ReduceN(tc.workers, tc.input, "", func(a, b string) string { return a + b })
+			if r != tc.output {
+				t.Errorf("Expected %q, received %q", tc.output, r)
+			}
+		})
+	}
+}
+
+func TestForEach(t *testing.T) {
+	var count atomic.Int32
+	err := str.ForEach(3, []int{1, 2, 3, 4}, func(n int) error {
+		count.Add(1)
+		if n%2 == 0 {
+			return fmt.Errorf("even %d", n)
+		}
+		return nil
+	})
+
+	if count.Load() != 4 {
+		t.Errorf("Expected 4 calls, received %d", count.Load())
+	}
+
+	var ie *str.ItemError[int]
+	if !errors.As(err, &ie) || ie.Input%2 != 0 {
+		t.Errorf("Expected item error for even input, received %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package str
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrPoolExists is returned when registering a Pool under a name in use.
+var ErrPoolExists = errors.New("pool name already registered")
+
+// Task is a unit of work for the Default pool.
+type Task func(context.Context) (any, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]any)
+)
+
+// Register shares a Pool under name, so libraries within an app can use the
+// same tuned pool (see Lookup) instead of each starting workers of their
+// own.  The owner of the pool still reads its Results, or users of a
+// shared pool can rely on the Futures returned by Submit.
+func Register[T, R any](name string, p *Pool[T, R]) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[name]; ok {
+		return ErrPoolExists
+	}
+
+	registry[name] = p
+	return nil
+}
+
+// Lookup returns the Pool registered under name, or FALSE if there is none
+// of that type.
+func Lookup[T, R any](name string) (*Pool[T, R], bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	p, ok := registry[name].(*Pool[T, R])
+	return p, ok
+}
+
+// Unregister removes the Pool registered under name, without closing it.
+func Unregister(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	delete(registry, name)
+}
+
+var defaultPool = sync.OnceValue(func() *Pool[Task, any] {
+	p := NewPool(CPUBound(), func(ctx context.Context, t Task) (any, error) {
+		return t(ctx)
+	}, IdleTimeout(time.Minute))
+
+	// nobody owns the default pool, so results are only seen through Futures
+	return p
+})
+
+// Default returns a process-wide Pool of Tasks, with one worker per CPU,
+// started on first use.  Callers await their Tasks with the Future returned
+// by Submit, and must not Close it.
+func Default() *Pool[Task, any] {
+	return defaultPool()
+}
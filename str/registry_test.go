@@ -0,0 +1,52 @@
+package str_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sspencer/goal/str"
+)
+
+func TestRegister(t *testing.T) {
+	p := str.NewPool(2, square)
+	defer p.Close()
+	defer str.Unregister("squares")
+
+	if err := str.Register("squares", p); err != nil {
+		t.Fatalf("Unexpected register error %v", err)
+	}
+
+	if err := str.Register("squares", p); err != str.ErrPoolExists {
+		t.Errorf("Expected ErrPoolExists, received %v", err)
+	}
+
+	if found, ok := str.Lookup[int, int]("squares"); !ok || found != p {
+		t.Error("Expected to find the registered pool")
+	}
+
+	if _, ok := str.Lookup[string, int]("squares"); ok {
+		t.Error("Expected no pool of another type")
+	}
+
+	str.Unregister("squares")
+	if _, ok := str.Lookup[int, int]("squares"); ok {
+		t.Error("Expected no pool after Unregister")
+	}
+}
+
+func TestDefault(t *testing.T) {
+	if str.Default() != str.Default() {
+		t.Error("Expected a single default pool")
+	}
+
+	f, err := str.Default().Submit(func(context.Context) (any, error) {
+		return "done", nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected submit error %v", err)
+	}
+
+	if out, err := f.Wait(context.Background()); out != "done" || err != nil {
+		t.Errorf("Expected done, received %v, %v", out, err)
+	}
+}
@@ -0,0 +1,80 @@
+package str
+
+import (
+	"context"
+	"fmt"
+)
+
+// workerKey is the context key of the running worker
+type workerKey struct{}
+
+// workerInfo identifies a worker, along with its Setup state
+type workerInfo struct {
+	id    int
+	state any
+}
+
+// Setup gives every worker state of its own, e.g. a DB connection, HTTP
+// client or temp dir that is created once and reused across items.  init
+// is called when a worker starts, and cleanup (if not nil) when it exits.
+// Work functions get the state with WorkerState.  If init fails, the batch
+// is canceled with its error.
+func Setup[S any](init func(workerID int) (S, error), cleanup func(S)) Option {
+	return func(o *options) {
+		o.setup = func(id int) (any, error) {
+			return init(id)
+		}
+
+		if cleanup != nil {
+			o.cleanup = func(state any) {
+				cleanup(state.(S))
+			}
+		}
+	}
+}
+
+// WorkerState returns the Setup state of the worker running the item with
+// context ctx.
+func WorkerState[S any](ctx context.Context) S {
+	var zero S
+	w, ok := ctx.Value(workerKey{}).(*workerInfo)
+	if !ok {
+		return zero
+	}
+
+	s, _ := w.state.(S)
+	return s
+}
+
+// WorkerID returns the ID of the worker running the item with context ctx,
+// or -1 when called outside of a worker.
+func WorkerID(ctx context.Context) int {
+	if w, ok := ctx.Value(workerKey{}).(*workerInfo); ok {
+		return w.id
+	}
+
+	return -1
+}
+
+// startWorker runs the Setup hook and returns the context for the worker's
+// items, and a function to release its state
+func (b *batch[T, R]) startWorker() (context.Context, func(), error) {
+	w := &workerInfo{id: int(b.ids.Add(1) - 1)}
+	ctx := context.WithValue(b.ctx, workerKey{}, w)
+
+	if b.o.setup == nil {
+		return ctx, func() {}, nil
+	}
+
+	state, err := b.o.setup(w.id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("worker %d setup: %w", w.id, err)
+	}
+	w.state = state
+
+	return ctx, func() {
+		if b.o.cleanup != nil {
+			b.o.cleanup(state)
+		}
+	}, nil
+}
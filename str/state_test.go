@@ -0,0 +1,58 @@
+package str_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sspencer/goal/str"
+)
+
+type conn struct {
+	id    int
+	items int
+}
+
+func TestSetup(t *testing.T) {
+	var inits, cleanups atomic.Int32
+
+	setup := str.Setup(func(id int) (*conn, error) {
+		inits.Add(1)
+		return &conn{id: id}, nil
+	}, func(c *conn) {
+		cleanups.Add(1)
+	})
+
+	input := make([]int, 20)
+	results, err := str.MapCtx(context.Background(), 3, input, func(ctx context.Context, _ int) (int, error) {
+		c := str.WorkerState[*conn](ctx)
+		if c == nil || c.id != str.WorkerID(ctx) {
+			return 0, errors.New("missing worker state")
+		}
+		c.items++
+		return c.id, nil
+	}, setup)
+
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(results) != len(input) {
+		t.Errorf("Expected %d results, received %d", len(input), len(results))
+	}
+	if inits.Load() != 3 || cleanups.Load() != 3 {
+		t.Errorf("Expected 3 inits and cleanups, received %d and %d", inits.Load(), cleanups.Load())
+	}
+}
+
+func TestSetupError(t *testing.T) {
+	failure := errors.New("no connection")
+	setup := str.Setup(func(id int) (*conn, error) {
+		return nil, failure
+	}, nil)
+
+	_, err := str.MapCtx(context.Background(), 2, []int{1, 2, 3}, square, setup)
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected setup failure, received %v", err)
+	}
+}
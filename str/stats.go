@@ -0,0 +1,102 @@
+package str
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Stats summarizes a batch, or a Pool so far.
+type Stats struct {
+	Processed int // items completed, including failures
+	Failed    int
+	Retried   int // items that took more than one attempt
+
+	Min time.Duration // item durations, including retries
+	Avg time.Duration
+	P95 time.Duration
+	Max time.Duration
+
+	Wall      time.Duration // since the batch or pool started
+	PerWorker map[int]int   // items processed by worker ID
+}
+
+// String returns a one line summary, for logging
+func (s Stats) String() string {
+	return fmt.Sprintf("%d processed, %d failed, %d retried in %v (min %v, avg %v, p95 %v, max %v) by %d workers",
+		s.Processed, s.Failed, s.Retried, s.Wall.Round(time.Millisecond),
+		s.Min, s.Avg, s.P95, s.Max, len(s.PerWorker))
+}
+
+// CollectStats fills s with the statistics of a batch once it is done.
+// For streams, s is filled before the results channel is closed.
+func CollectStats(s *Stats) Option {
+	return func(o *options) {
+		o.stats = s
+	}
+}
+
+// statsCollector records every item of a batch
+type statsCollector struct {
+	mu        sync.Mutex
+	start     time.Time
+	durations []time.Duration
+	failed    int
+	retried   int
+	perWorker map[int]int
+}
+
+func newStatsCollector() *statsCollector {
+	return &statsCollector{start: time.Now(), perWorker: make(map[int]int)}
+}
+
+func (c *statsCollector) record(workerID int, d time.Duration, attempts int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.durations = append(c.durations, d)
+	c.perWorker[workerID]++
+	if err != nil {
+		c.failed++
+	}
+	if attempts > 1 {
+		c.retried++
+	}
+}
+
+func (c *statsCollector) snapshot() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := Stats{
+		Processed: len(c.durations),
+		Failed:    c.failed,
+		Retried:   c.retried,
+		Wall:      time.Since(c.start),
+		PerWorker: make(map[int]int, len(c.perWorker)),
+	}
+
+	for id, n := range c.perWorker {
+		s.PerWorker[id] = n
+	}
+
+	if len(c.durations) == 0 {
+		return s
+	}
+
+	sorted := append([]time.Duration(nil), c.durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+
+	s.Min = sorted[0]
+	s.Max = sorted[len(sorted)-1]
+	s.Avg = total / time.Duration(len(sorted))
+	s.P95 = sorted[(len(sorted)*95+99)/100-1]
+
+	return s
+}
@@ -0,0 +1,33 @@
+package str_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/str"
+)
+
+func TestCollectStats(t *testing.T) {
+	var stats str.Stats
+	input := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	str.MapCtx(context.Background(), 2, input, func(_ context.Context, n int) (int, error) {
+		time.Sleep(time.Duration(n) * time.Millisecond)
+		if n == 10 {
+			return 0, errors.New("ten")
+		}
+		return n, nil
+	}, str.CollectStats(&stats))
+
+	if stats.Processed != 10 || stats.Failed != 1 || stats.Retried != 0 {
+		t.Errorf("Unexpected counts %s", stats)
+	}
+	if stats.Min < time.Millisecond || stats.Max < 10*time.Millisecond || stats.P95 != stats.Max {
+		t.Errorf("Unexpected durations %s", stats)
+	}
+	if len(stats.PerWorker) != 2 || stats.PerWorker[0]+stats.PerWorker[1] != 10 {
+		t.Errorf("Unexpected per worker counts %v", stats.PerWorker)
+	}
+}
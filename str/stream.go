@@ -0,0 +1,104 @@
+package str
+
+import (
+	"context"
+	"iter"
+)
+
+// Stream is like MapCtx, but returns a channel of results as soon as they
+// complete, instead of blocking until the whole batch is done.  The channel
+// is closed after the last result, or once ctx is canceled.  Callers must
+// read until the channel is closed, or cancel ctx, to release the workers.
+//
+// With the Ordered option, results are sent strictly in input order, as
+// soon as all earlier inputs are done.  Results completing out of order are
+// held back until then.
+func Stream[T, R any](ctx context.Context, numWorkers int, input []T, fn func(context.Context, T) (R, error), opts ...Option) <-chan Result[T, R] {
+	o := newOptions(opts)
+	input, indexes := unique(input, o)
+
+	b := newBatch(ctx, fn, o, len(input))
+	results := b.runSlice(boundWorkers(numWorkers, len(input)), input, indexes, true)
+	if o.ordered {
+		return reorder(ctx, results, indexes)
+	}
+
+	return results
+}
+
+// StreamChan is like Stream, consuming input from a channel until it is closed.
+func StreamChan[T, R any](ctx context.Context, numWorkers int, input <-chan T, fn func(context.Context, T) (R, error), opts ...Option) <-chan Result[T, R] {
+	o := newOptions(opts)
+
+	b := newBatch(ctx, fn, o, -1)
+	results := b.run(boundWorkers(numWorkers, MaxThreads), feedChan(b.feedCtx, input, o), true)
+	if o.ordered {
+		return reorder(ctx, results, nil)
+	}
+
+	return results
+}
+
+// reorder sends results on in the order of their indexes, which are 0, 1,
+// 2... or the given order.  Results after a gap are held back until it is
+// filled.  Once the caller's ctx is done, nothing more is sent.
+func reorder[T, R any](ctx context.Context, results <-chan Result[T, R], order []int) <-chan Result[T, R] {
+	out := make(chan Result[T, R])
+
+	go func() {
+		defer close(out)
+
+		pending := make(map[int]Result[T, R])
+		next := 0
+
+		for r := range results {
+			pending[r.Index] = r
+
+			for {
+				index := next
+				if order != nil {
+					if next >= len(order) {
+						break
+					}
+					index = order[next]
+				}
+
+				p, ok := pending[index]
+				if !ok {
+					break
+				}
+				delete(pending, index)
+				next++
+
+				select {
+				case out <- p:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// Results lazily runs fn over input for range-over-func loops:
+//
+//	for in, out := range str.Results(4, input, fn) { ... }
+//
+// Pairs are yielded as they complete.  Breaking out of the loop cancels the
+// remaining work.
+func Results[T, R any](numWorkers int, input []T, fn func(T) R, opts ...Option) iter.Seq2[T, R] {
+	return func(yield func(T, R) bool) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		results := Stream(ctx, numWorkers, input, Func(fn), opts...)
+
+		for r := range results {
+			if !yield(r.Input, r.Output) {
+				return
+			}
+		}
+	}
+}
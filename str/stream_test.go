@@ -0,0 +1,100 @@
+package str_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/str"
+)
+
+func TestStream(t *testing.T) {
+	input := []int{1, 2, 3, 4, 5, 6, 7}
+	results := str.Stream(context.Background(), 3, input, func(_ context.Context, n int) (int, error) {
+		return n * n, nil
+	})
+
+	count := 0
+	for r := range results {
+		if r.Output != r.Input*r.Input {
+			t.Errorf("For %d, expected %d, not %d", r.Input, r.Input*r.Input, r.Output)
+		}
+		count++
+	}
+
+	if count != len(input) {
+		t.Errorf("Expected %d results, received %d", len(input), count)
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	input := make([]int, 1000)
+	results := str.Stream(ctx, 2, input, func(_ context.Context, n int) (int, error) {
+		return n, nil
+	})
+
+	<-results
+	cancel()
+
+	count := 0
+	for range results {
+		count++
+	}
+
+	if count >= len(input)-1 {
+		t.Errorf("Expected stream to stop early, received %d", count)
+	}
+}
+
+func TestResults(t *testing.T) {
+	input := []int{1, 2, 3, 4, 5, 6, 7}
+
+	count := 0
+	for in, out := range str.Results(3, input, func(n int) int { return n * n }) {
+		if out != in*in {
+			t.Errorf("For %d, expected %d, not %d", in, in*in, out)
+		}
+		count++
+	}
+	if count != len(input) {
+		t.Errorf("Expected %d results, received %d", len(input), count)
+	}
+
+	count = 0
+	for range str.Results(3, input, func(n int) int { return n }) {
+		count++
+		if count == 2 {
+			break
+		}
+	}
+	if count != 2 {
+		t.Errorf("Expected break after 2 results, received %d", count)
+	}
+}
+
+func TestStreamOrdered(t *testing.T) {
+	input := []int{5, 1, 4, 2, 3, 1, 5}
+	results := str.Stream(context.Background(), 4, input, func(_ context.Context, n int) (int, error) {
+		time.Sleep(time.Duration(n) * time.Millisecond)
+		return n, nil
+	}, str.Ordered(), str.Dedupe())
+
+	var order []int
+	for r := range results {
+		order = append(order, r.Index)
+	}
+
+	if fmt.Sprint(order) != "[0 1 2 3 4]" {
+		t.Errorf("Expected results in input order, received %v", order)
+	}
+}
+
+func BenchmarkStream(b *testing.B) {
+	input := make([]int, 100000)
+	for i := 0; i < b.N; i++ {
+		for range str.Stream(context.Background(), 8, input, square) {
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package str
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sync"
+)
+
+// WalkFiles walks the directory tree at root and streams every file whose
+// name matches glob (see filepath.Match, "" matches everything) to fn, up
+// to 'numWorkers' at a time, while the walk is still going.  Like Stream,
+// results are sent as they complete, and canceling ctx stops both the walk
+// and the workers.  Errors reading the tree are reported as the Result of
+// the path that failed, without calling fn.
+func WalkFiles[R any](ctx context.Context, root, glob string, numWorkers int, fn func(context.Context, string) (R, error), opts ...Option) <-chan Result[string, R] {
+	ctx, cancel := context.WithCancel(ctx)
+
+	var walkErrs sync.Map
+	paths := make(chan string)
+
+	send := func(path string) error {
+		select {
+		case paths <- path:
+			return nil
+		case <-ctx.Done():
+			return filepath.SkipAll
+		}
+	}
+
+	go func() {
+		defer close(paths)
+
+		if _, err := filepath.Match(glob, ""); err != nil {
+			walkErrs.Store(root, err)
+			send(root)
+			return
+		}
+
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				walkErrs.Store(path, err)
+				return send(path)
+			}
+
+			if d.IsDir() {
+				return nil
+			}
+
+			if ok, _ := filepath.Match(glob, d.Name()); glob != "" && !ok {
+				return nil
+			}
+
+			return send(path)
+		})
+	}()
+
+	work := func(ctx context.Context, path string) (R, error) {
+		if err, ok := walkErrs.Load(path); ok {
+			var zero R
+			return zero, err.(error)
+		}
+
+		return fn(ctx, path)
+	}
+
+	results := StreamChan(ctx, numWorkers, paths, work, opts...)
+	out := make(chan Result[string, R])
+
+	go func() {
+		defer close(out)
+		defer cancel() // stops the walk if the workers stopped early
+
+		for r := range results {
+			select {
+			case out <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
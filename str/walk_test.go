@@ -0,0 +1,78 @@
+package str_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/sspencer/goal/str"
+)
+
+func TestWalkFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.txt", "b.log", "sub/c.txt", "sub/deep/d.txt"} {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results := str.WalkFiles(context.Background(), root, "*.txt", 2, func(_ context.Context, path string) (string, error) {
+		data, err := os.ReadFile(path)
+		return string(data), err
+	})
+
+	var names []string
+	for r := range results {
+		if r.Err != nil {
+			t.Errorf("Unexpected error for %s: %v", r.Input, r.Err)
+		}
+		names = append(names, r.Output)
+	}
+
+	sort.Strings(names)
+	if strings.Join(names, ",") != "a.txt,sub/c.txt,sub/deep/d.txt" {
+		t.Errorf("Expected the 3 .txt files, received %v", names)
+	}
+}
+
+func TestWalkFilesErrors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	for _, tc := range []struct{ root, glob string }{{missing, ""}, {".", "["}} {
+		var errs int
+		for r := range str.WalkFiles(context.Background(), tc.root, tc.glob, 2, func(context.Context, string) (int, error) {
+			t.Error("Unexpected call to fn")
+			return 0, nil
+		}) {
+			if r.Err != nil {
+				errs++
+			}
+		}
+
+		if errs != 1 {
+			t.Errorf("For %q %q, expected 1 error, received %d", tc.root, tc.glob, errs)
+		}
+	}
+}
+
+func TestWalkFilesCancel(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < 20; i++ {
+		os.WriteFile(filepath.Join(root, strings.Repeat("f", i+1)), nil, 0o644)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := str.WalkFiles(ctx, root, "", 1, func(_ context.Context, path string) (string, error) {
+		return path, nil
+	})
+
+	<-results
+	cancel()
+	for range results {
+	}
+}
@@ -0,0 +1,33 @@
+package str
+
+import "github.com/sspencer/goal/sema"
+
+// Weighted limits the total cost of the items in flight to 'capacity',
+// where cost returns the cost of an item (bytes, rows...).  Workers wait
+// until there is room for their item, so a few huge items run on their
+// own while many small ones run side by side, up to the number of workers.
+// Items costing more than capacity run alone.  Waiting items are started
+// first come, first served, so huge items aren't starved by small ones.
+// T must be the type of the items, or the batch fails with ErrOptionType.
+func Weighted[T any](capacity int64, cost func(T) int64) Option {
+	return func(o *options) {
+		expect[T](o, "Weighted")
+		o.capacity = capacity
+		o.cost = func(v any) int64 {
+			in, ok := v.(T)
+			if !ok {
+				return 0 // the batch failed with ErrOptionType
+			}
+			return cost(in)
+		}
+	}
+}
+
+// semaphore returns the weighted semaphore for the Weighted option, if any
+func (o *options) semaphore() *sema.Weighted {
+	if o.cost == nil || o.capacity <= 0 {
+		return nil
+	}
+
+	return sema.NewWeighted(o.capacity)
+}
@@ -0,0 +1,52 @@
+package str_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/str"
+)
+
+func TestWeighted(t *testing.T) {
+	var mu sync.Mutex
+	var inFlight, peak int
+
+	input := []int{6, 6, 3, 3, 1, 1, 20}
+	results, err := str.MapCtx(context.Background(), 4, input, func(_ context.Context, cost int) (int, error) {
+		mu.Lock()
+		inFlight += min(cost, 10)
+		peak = max(peak, inFlight)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		inFlight -= min(cost, 10)
+		mu.Unlock()
+		return cost, nil
+	}, str.Weighted(10, func(cost int) int64 { return int64(cost) }))
+
+	if err != nil || len(results) != len(input) {
+		t.Fatalf("Expected %d results, received %d, %v", len(input), len(results), err)
+	}
+
+	if peak > 10 {
+		t.Errorf("Expected at most a cost of 10 in flight, peaked at %d", peak)
+	}
+}
+
+func TestWeightedCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := str.MapCtx(ctx, 2, []int{10, 10, 10}, func(ctx context.Context, n int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, str.Weighted(10, func(cost int) int64 { return int64(cost) }))
+
+	if err == nil {
+		t.Error("Expected the batch to time out")
+	}
+}
@@ -1,61 +1,86 @@
 package str
 
 import (
-	"sync"
+	"context"
+	"runtime"
 )
 
 const MaxThreads = 100
 
+// CPUBound returns the number of workers for CPU bound work, one per CPU.
+func CPUBound() int {
+	return runtime.NumCPU()
+}
+
+// IOBound returns the number of workers for work that mostly waits on the
+// network or disk: 'multiplier' workers per CPU, up to MaxThreads.
+func IOBound(multiplier int) int {
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	return boundWorkers(runtime.NumCPU()*multiplier, MaxThreads)
+}
+
 // StringWorker describes the interface to implement when
 // your work can be paralleized.
 type StringWorker interface {
 	StringWork(string) string
 }
 
-// Worker concurrently calls the string worker up to 'numThreads' at a time.  The
-// worker either returns a non-empty string to make it part of output, or an empty
-// string if the work should be ignored.  NOTE: there may not be be a 1-1 mapping
-// between input and output, and they may not be in the same order.  A good use
-// for this is where the "strings" in question are file paths and a longer operation
-// is transforming an input file to an output file.  If there was an error processing
-// the file, an empty string is returned.
-func Worker(numWorkers int, input []string, worker StringWorker) (output []string) {
-	// sanity checks
+// WorkerFunc adapts a plain function to the StringWorker interface, e.g.
+//
+//	str.Worker(4, input, str.WorkerFunc(strings.ToUpper))
+type WorkerFunc func(string) string
 
+// StringWork calls f(s)
+func (f WorkerFunc) StringWork(s string) string {
+	return f(s)
+}
+
+// Worker concurrently calls the string worker up to 'numThreads' at a time.  Every
+// input gets an output, even an empty string, so counts always match.  NOTE: they
+// may not be in the same order, unless the Ordered option is given.  A good use
+// for this is where the "strings" in question are file paths and a longer operation
+// is transforming an input file to an output file.  With the DropEmpty option,
+// workers can return an empty string to leave an input out of output, e.g. if
+// there was an error processing the file.
+func Worker(numWorkers int, input []string, worker StringWorker, opts ...Option) (output []string) {
 	// short circuit on empty input
 	if len(input) == 0 {
 		return []string{}
 	}
 
-	numWorkers = boundWorkers(numWorkers, len(input))
-
-	// synchronize writes into output
-	var mutex sync.Mutex
-
-	// create (n) workers
-	sem := make(chan bool, numWorkers)
-
-	for _, s := range input {
-		sem <- true // blocks after (n)
+	o := newOptions(opts)
+	output = make([]string, 0, len(input))
+	for _, s := range Map(numWorkers, input, worker.StringWork, opts...) {
+		if s != "" || !o.dropEmpty {
+			output = append(output, s)
+		}
+	}
 
-		go func(inputFile string) {
-			fn := worker.StringWork(inputFile)
-			if fn != "" {
-				mutex.Lock()
-				output = append(output, fn)
-				mutex.Unlock()
-			}
+	return output
+}
 
-			<-sem // release a slot
-		}(s)
-	}
+// WorkerAuto is like Worker, with one worker per CPU (see CPUBound).
+func WorkerAuto(input []string, worker StringWorker, opts ...Option) []string {
+	return Worker(CPUBound(), input, worker, opts...)
+}
 
-	// wait until last (n) matches complete
-	for i := 0; i < cap(sem); i++ {
-		sem <- true
+// WorkerCtx is like Worker, but stops dispatching new input when ctx is
+// canceled or times out, and returns the output so far along with ctx.Err().
+func WorkerCtx(ctx context.Context, numWorkers int, input []string, worker StringWorker, opts ...Option) ([]string, error) {
+	results, err := MapCtx(ctx, numWorkers, input, Func(worker.StringWork), opts...)
+
+	o := newOptions(opts)
+	output := make([]string, 0, len(results))
+	for _, r := range results {
+		if r.Output != "" || !o.dropEmpty {
+			output = append(output, r.Output)
+		}
 	}
 
-	return output
+	return output, err
 }
 
 // boundWorkers caps the number of threads the user requested, so it is
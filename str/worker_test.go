@@ -1,9 +1,11 @@
 package str_test
 
 import (
+	"context"
+	"strings"
 	"testing"
 
-	"github.com/sspencer/str"
+	"github.com/sspencer/goal/str"
 )
 
 type emptyWorker int
@@ -30,6 +32,11 @@ func TestEmptyWorker(t *testing.T) {
 	var w emptyWorker
 	input := []string{"a", "b", "c", "d", "e", "f", "g"}
 	output := str.Worker(0, input, w)
+	if len(output) != len(input) {
+		t.Errorf("Expected %d results, received %d", len(input), len(output))
+	}
+
+	output = str.Worker(0, input, w, str.DropEmpty())
 	if len(output) != 0 {
 		t.Errorf("Expected no results, received %d", len(output))
 	}
@@ -48,9 +55,77 @@ func TestFullWorker(t *testing.T) {
 func TestPartialWorker(t *testing.T) {
 	var w partialWorker
 	input := []string{"a", "b", "c", "d", "e", "f", "g"}
-	output := str.Worker(2, input, w)
+	output := str.Worker(2, input, w, str.DropEmpty())
 	expected := len(input) - 1
 	if len(output) != expected {
 		t.Errorf("Expected %d results, received %d", expected, len(output))
 	}
 }
+
+// cancelingWorker cancels its batch on input "c"
+type cancelingWorker context.CancelFunc
+
+func (w cancelingWorker) StringWork(s string) string {
+	if s == "c" {
+		w()
+	}
+	return s
+}
+
+func TestWorkerCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	input := []string{"a", "b", "c", "d", "e", "f", "g"}
+	output, err := str.WorkerCtx(ctx, 1, input, cancelingWorker(cancel))
+	if err != context.Canceled {
+		t.Errorf("Expected canceled, received %v", err)
+	}
+
+	// a and b are done, c maybe, and nothing is dispatched after it
+	if len(output) < 2 || len(output) > 3 {
+		t.Errorf("Expected partial results, received %q", output)
+	}
+}
+
+func TestOrderedWorker(t *testing.T) {
+	var w partialWorker
+	input := []string{"a", "b", "c", "d", "e", "f", "g"}
+	output := str.Worker(3, input, w, str.Ordered())
+	if len(output) != len(input) {
+		t.Fatalf("Expected %d results, received %d", len(input), len(output))
+	}
+
+	for i, s := range input {
+		expected := w.StringWork(s)
+		if output[i] != expected {
+			t.Errorf("For index %d, expected %q, not %q", i, expected, output[i])
+		}
+	}
+}
+
+func TestWorkerFunc(t *testing.T) {
+	input := []string{"a", "b", "c"}
+	output := str.Worker(2, input, str.WorkerFunc(strings.ToUpper), str.Ordered())
+	for i, s := range input {
+		if output[i] != strings.ToUpper(s) {
+			t.Errorf("For index %d, expected %q, not %q", i, strings.ToUpper(s), output[i])
+		}
+	}
+}
+
+func TestWorkerAuto(t *testing.T) {
+	var w fullWorker
+	input := []string{"a", "b", "c", "d"}
+	if output := str.WorkerAuto(input, w); len(output) != len(input) {
+		t.Errorf("Expected %d results, received %d", len(input), len(output))
+	}
+
+	if n := str.IOBound(1000); n != str.MaxThreads {
+		t.Errorf("Expected IOBound to be capped at %d, received %d", str.MaxThreads, n)
+	}
+
+	if n := str.IOBound(0); n < 1 || n > str.MaxThreads {
+		t.Errorf("Expected IOBound(0) between 1 and %d, received %d", str.MaxThreads, n)
+	}
+}
@@ -0,0 +1,243 @@
+package testu
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sspencer/goal/jsonu"
+	"github.com/sspencer/goal/mapu"
+)
+
+// Recorded is a request received by a Server.
+type Recorded struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+}
+
+// String formats the request line, e.g. "POST /orders?dry=1"
+func (r Recorded) String() string {
+	return r.Method + " " + r.URL.RequestURI()
+}
+
+// Recorded returns the requests received, in order.
+func (s *Server) Recorded() []Recorded {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Recorded(nil), s.recorded...)
+}
+
+// Expectation is a request a Server expects to receive, checked at the end
+// of the test (or by Verify).
+type Expectation struct {
+	pattern string
+	mux     *http.ServeMux // matches pattern
+	times   int            // -1 for at least once
+	json    []byte
+	body    *string
+	header  http.Header
+	query   url.Values
+}
+
+// Expect expects requests matching the http.ServeMux pattern, e.g.
+//
+//	srv.Expect("POST /orders").JSON(Order{ID: 1}).Times(1)
+//
+// By default, the request is expected at least once.  Expectations only
+// check requests: the responses are those of the routes.
+func (s *Server) Expect(pattern string) *Expectation {
+	e := &Expectation{pattern: pattern, mux: http.NewServeMux(), times: -1, header: make(http.Header), query: make(url.Values)}
+	e.mux.HandleFunc(pattern, func(http.ResponseWriter, *http.Request) {})
+
+	s.mu.Lock()
+	s.expectations = append(s.expectations, e)
+	s.mu.Unlock()
+
+	return e
+}
+
+// JSON expects a JSON body equal to v, a string or []byte of JSON, or a
+// value encoded as JSON.  Key order and whitespace don't matter.
+func (e *Expectation) JSON(v any) *Expectation {
+	data, err := encode(v)
+	if err != nil {
+		panic(fmt.Sprintf("testu: expectation %q: %v", e.pattern, err))
+	}
+
+	e.json = data
+	return e
+}
+
+// Body expects a body of exactly body.
+func (e *Expectation) Body(body string) *Expectation {
+	e.body = &body
+	return e
+}
+
+// Header expects the header key to have value.
+func (e *Expectation) Header(key, value string) *Expectation {
+	e.header.Add(key, value)
+	return e
+}
+
+// Query expects the query parameter key to have value.
+func (e *Expectation) Query(key, value string) *Expectation {
+	e.query.Add(key, value)
+	return e
+}
+
+// Times expects exactly n matching requests.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// Never expects no matching request.
+func (e *Expectation) Never() *Expectation {
+	return e.Times(0)
+}
+
+// String describes the expectation, e.g. `1 POST /orders with JSON {"id":1}`
+func (e *Expectation) String() string {
+	var b strings.Builder
+	if e.times < 0 {
+		b.WriteString("at least 1 ")
+	} else {
+		fmt.Fprintf(&b, "%d ", e.times)
+	}
+	b.WriteString(e.pattern)
+
+	var with []string
+	for _, k := range mapu.SortedKeys(e.query) {
+		with = append(with, fmt.Sprintf("query %s=%s", k, strings.Join(e.query[k], ",")))
+	}
+	for _, k := range mapu.SortedKeys(e.header) {
+		with = append(with, fmt.Sprintf("header %s: %s", k, strings.Join(e.header[k], ",")))
+	}
+	if e.json != nil {
+		with = append(with, "JSON "+string(e.json))
+	}
+	if e.body != nil {
+		with = append(with, fmt.Sprintf("body %q", *e.body))
+	}
+	if len(with) > 0 {
+		b.WriteString(" with ")
+		b.WriteString(strings.Join(with, ", "))
+	}
+
+	return b.String()
+}
+
+// route reports whether r matches the pattern
+func (e *Expectation) route(r Recorded) bool {
+	_, pattern := e.mux.Handler(&http.Request{Method: r.Method, URL: r.URL, Host: r.URL.Host, Header: r.Header})
+	return pattern == e.pattern
+}
+
+// mismatch returns how r differs from the expectation, nothing when it
+// matches
+func (e *Expectation) mismatch(r Recorded) []string {
+	var diffs []string
+
+	q := r.URL.Query()
+	for k, values := range e.query {
+		for _, v := range values {
+			if !contains(q[k], v) {
+				diffs = append(diffs, fmt.Sprintf("query %s: expected %q, received %q", k, v, q[k]))
+			}
+		}
+	}
+
+	for k, values := range e.header {
+		for _, v := range values {
+			if !contains(r.Header.Values(k), v) {
+				diffs = append(diffs, fmt.Sprintf("header %s: expected %q, received %q", k, v, r.Header.Values(k)))
+			}
+		}
+	}
+
+	if e.body != nil && *e.body != string(r.Body) {
+		diffs = append(diffs, fmt.Sprintf("body: expected %q, received %q", *e.body, r.Body))
+	}
+
+	if e.json != nil {
+		changes, err := jsonu.Diff(e.json, r.Body)
+		if err != nil {
+			diffs = append(diffs, fmt.Sprintf("body: expected JSON, received %q", bytes.TrimSpace(r.Body)))
+		}
+		for _, c := range changes {
+			diffs = append(diffs, "JSON "+c.String())
+		}
+	}
+
+	return diffs
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Verify reports the expectations not met so far as test errors, with the
+// differences of the closest request received.  It runs at the end of the
+// test too.
+func (s *Server) Verify() {
+	s.t.Helper()
+
+	for _, msg := range s.unmet() {
+		s.t.Errorf("%s", msg)
+	}
+}
+
+// unmet returns a message per expectation not met
+func (s *Server) unmet() []string {
+	s.mu.Lock()
+	expectations := append([]*Expectation(nil), s.expectations...)
+	recorded := append([]Recorded(nil), s.recorded...)
+	s.mu.Unlock()
+
+	var msgs []string
+	for _, e := range expectations {
+		matched := 0
+		var closest []string // differences of the first request on the route
+		var closestReq Recorded
+		for _, r := range recorded {
+			if !e.route(r) {
+				continue
+			}
+
+			diffs := e.mismatch(r)
+			if len(diffs) == 0 {
+				matched++
+			} else if closest == nil {
+				closest, closestReq = diffs, r
+			}
+		}
+
+		want := e.times
+		if want < 0 {
+			want = 1
+		}
+		if matched == want || e.times < 0 && matched > want {
+			continue
+		}
+
+		msg := fmt.Sprintf("testu: expected %s, received %d", e, matched)
+		if closest != nil && matched < want {
+			msg += fmt.Sprintf("\n  closest request %s:\n    %s", closestReq, strings.Join(closest, "\n    "))
+		}
+		msgs = append(msgs, msg)
+	}
+
+	return msgs
+}
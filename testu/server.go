@@ -0,0 +1,192 @@
+// Package testu helps test code calling HTTP APIs, e.g. with the req client.
+package testu
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Fixture is a canned response.  Body is written as is when it is a string
+// or []byte, and encoded as JSON otherwise.
+type Fixture struct {
+	Status int // 200 when 0
+	Body   any
+	Header http.Header
+}
+
+// Routes maps http.ServeMux patterns, e.g. "GET /users/{id}", to the
+// response to send: a Fixture, or a body like the Body of a Fixture.
+type Routes map[string]any
+
+// Option configures a Server.
+type Option func(*Server)
+
+// Latency delays every response by d.
+func Latency(d time.Duration) Option {
+	return func(s *Server) {
+		s.latency = d
+	}
+}
+
+// FailEvery fails every nth request with status, e.g. to test retries.
+func FailEvery(n int, status int) Option {
+	return func(s *Server) {
+		s.failEvery = n
+		s.failStatus = status
+	}
+}
+
+// FailRate fails a random fraction rate of the requests with status.
+func FailRate(rate float64, status int) Option {
+	return func(s *Server) {
+		s.failRate = rate
+		s.failStatus = status
+	}
+}
+
+// Server is an httptest.Server answering with fixtures.
+type Server struct {
+	*httptest.Server
+
+	latency    time.Duration
+	failEvery  int
+	failRate   float64
+	failStatus int
+
+	t            testing.TB
+	mu           sync.Mutex
+	requests     int
+	hits         map[string]int
+	recorded     []Recorded
+	expectations []*Expectation
+}
+
+// NewServer starts a Server answering routes, closed at the end of the test,
+// e.g.
+//
+//	srv := testu.NewServer(t, testu.Routes{
+//		"GET /users/1": User{ID: 1, Name: "ann"},
+//		"POST /users":  testu.Fixture{Status: 201, Body: `{"id":2}`},
+//	}, testu.Latency(10*time.Millisecond))
+//	resp, err := req.New().Get(srv.URL + "/users/1")
+//
+// Requests matching no route get a 404 with a JSON error.  Every request is
+// recorded, to check Expectations at the end of the test.
+func NewServer(t testing.TB, routes Routes, opts ...Option) *Server {
+	s := &Server{t: t, hits: make(map[string]int)}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	mux := http.NewServeMux()
+	for pattern, fixture := range routes {
+		f, ok := fixture.(Fixture)
+		if !ok {
+			f = Fixture{Body: fixture}
+		}
+
+		body, err := encode(f.Body)
+		if err != nil {
+			t.Fatalf("testu: route %q: %v", pattern, err)
+		}
+
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			s.serve(w, r, pattern, f, body)
+		})
+	}
+
+	if _, ok := routes["/"]; !ok {
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			s.serve(w, r, "", Fixture{Status: http.StatusNotFound}, []byte(`{"error":"no route"}`))
+		})
+	}
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	t.Cleanup(s.Verify)
+
+	return s
+}
+
+// Hits returns the number of requests received for the route pattern,
+// including failed ones.
+func (s *Server) Hits(pattern string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.hits[pattern]
+}
+
+// Requests returns the number of requests received.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request, pattern string, f Fixture, body []byte) {
+	in, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	s.recorded = append(s.recorded, Recorded{r.Method, r.URL, r.Header.Clone(), in})
+	s.requests++
+	s.hits[pattern]++
+	fail := (s.failEvery > 0 && s.requests%s.failEvery == 0) || (s.failRate > 0 && rand.Float64() < s.failRate)
+	s.mu.Unlock()
+
+	if s.latency > 0 {
+		select {
+		case <-time.After(s.latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if fail {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(s.failStatus)
+		w.Write([]byte(`{"error":"injected failure"}`))
+		return
+	}
+
+	for k, v := range f.Header {
+		w.Header()[k] = v
+	}
+
+	switch f.Body.(type) {
+	case string, []byte, nil:
+	default:
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+	}
+
+	status := f.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// encode returns the bytes of a fixture body
+func encode(body any) ([]byte, error) {
+	switch b := body.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(b), nil
+	case []byte:
+		return b, nil
+	default:
+		return json.Marshal(b)
+	}
+}
@@ -0,0 +1,155 @@
+package testu_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/req"
+	"github.com/sspencer/goal/testu"
+)
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestServer(t *testing.T) {
+	srv := testu.NewServer(t, testu.Routes{
+		"GET /users/1": user{1, "ann"},
+		"POST /users":  testu.Fixture{Status: http.StatusCreated, Body: `{"id":2}`, Header: http.Header{"Location": {"/users/2"}}},
+	})
+
+	resp, err := req.New().Get(srv.URL + "/users/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var u user
+	if err := req.Unmarshal(resp.Body, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u != (user{1, "ann"}) || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected ann as JSON, received %+v (%s)", u, resp.Header.Get("Content-Type"))
+	}
+
+	resp, err = req.New().Post(srv.URL+"/users", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("Location") != "/users/2" {
+		t.Errorf("Expected 201 with a location, received %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	_, err = req.New().Get(srv.URL + "/missing")
+	var he req.HTTPError
+	if !errors.As(err, &he) || he.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a 404, received %v", err)
+	}
+
+	if srv.Hits("GET /users/1") != 1 || srv.Requests() != 3 {
+		t.Errorf("Expected 1 hit of 3 requests, received %d of %d", srv.Hits("GET /users/1"), srv.Requests())
+	}
+}
+
+func TestServerFailures(t *testing.T) {
+	srv := testu.NewServer(t, testu.Routes{"GET /": "ok"}, testu.FailEvery(2, http.StatusServiceUnavailable), testu.Latency(5*time.Millisecond))
+
+	var statuses []int
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		statuses = append(statuses, resp.StatusCode)
+	}
+
+	want := []int{200, 503, 200, 503}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Errorf("Expected %v, received %v", want, statuses)
+			break
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Expected latency, received %v for 4 requests", elapsed)
+	}
+}
+
+// fakeT records the errors of a test, and runs its cleanups on demand
+type fakeT struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (f *fakeT) Helper()           {}
+func (f *fakeT) Cleanup(fn func()) { f.cleanups = append(f.cleanups, fn) }
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) finish() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+func TestServerExpect(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect func(*testu.Server)
+		errors []string
+	}{
+		{"met", func(srv *testu.Server) {
+			srv.Expect("POST /orders").JSON(`{"id": 1, "items": ["pen"]}`).Header("Content-Type", "application/json").Times(1)
+			srv.Expect("GET /orders").Query("status", "paid")
+			srv.Expect("DELETE /orders/{id}").Never()
+		}, nil},
+		{"json diff", func(srv *testu.Server) {
+			srv.Expect("POST /orders").JSON(map[string]any{"id": 2, "items": []string{"pen"}})
+		}, []string{"testu: expected at least 1 POST /orders with JSON {\"id\":2,\"items\":[\"pen\"]}, received 0\n" +
+			"  closest request POST /orders:\n" +
+			"    JSON /id: 2 -> 1"}},
+		{"too many", func(srv *testu.Server) {
+			srv.Expect("GET /orders").Times(2)
+		}, []string{"testu: expected 2 GET /orders, received 1"}},
+		{"never", func(srv *testu.Server) {
+			srv.Expect("POST /orders").Never()
+		}, []string{"testu: expected 0 POST /orders, received 1"}},
+		{"query", func(srv *testu.Server) {
+			srv.Expect("GET /orders").Query("status", "new")
+		}, []string{"testu: expected at least 1 GET /orders with query status=new, received 0\n" +
+			"  closest request GET /orders?status=paid:\n" +
+			"    query status: expected \"new\", received [\"paid\"]"}},
+	}
+
+	for _, tt := range tests {
+		ft := &fakeT{TB: t}
+		srv := testu.NewServer(ft, testu.Routes{"/": "ok"})
+		tt.expect(srv)
+
+		r := req.New()
+		r.Do(req.MethodPost, srv.URL+"/orders", strings.NewReader(`{"items":["pen"],"id":1}`), req.ContentType(req.JSONContentType))
+		r.Get(srv.URL + "/orders?status=paid")
+		ft.finish()
+
+		if strings.Join(ft.errors, "|") != strings.Join(tt.errors, "|") {
+			t.Errorf("%s: expected %q, received %q", tt.name, tt.errors, ft.errors)
+		}
+	}
+
+	// the requests are recorded
+	srv := testu.NewServer(t, testu.Routes{"/": "ok"})
+	req.New().Do(req.MethodPut, srv.URL+"/a?b=c", strings.NewReader("body"))
+	if recorded := srv.Recorded(); len(recorded) != 1 || recorded[0].String() != "PUT /a?b=c" || string(recorded[0].Body) != "body" {
+		t.Errorf("Expected PUT /a?b=c with a body, received %v", recorded)
+	}
+}
@@ -0,0 +1,199 @@
+// Package timeu parses and formats durations the way people write them,
+// e.g. "2d", "1h30m" or "3 minutes ago".
+package timeu
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Day is 24 hours, ignoring daylight saving changes
+	Day = 24 * time.Hour
+
+	// Week is 7 days
+	Week = 7 * Day
+)
+
+// units by the names accepted by ParseHuman
+var units = map[string]time.Duration{
+	"ns": time.Nanosecond, "nanosecond": time.Nanosecond, "nanoseconds": time.Nanosecond,
+	"us": time.Microsecond, "µs": time.Microsecond, "microsecond": time.Microsecond, "microseconds": time.Microsecond,
+	"ms": time.Millisecond, "millisecond": time.Millisecond, "milliseconds": time.Millisecond,
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": Day, "day": Day, "days": Day,
+	"w": Week, "wk": Week, "week": Week, "weeks": Week,
+}
+
+// ParseHuman parses a duration like time.ParseDuration, also accepting days
+// and weeks, unit names, and spaces, e.g. "2d", "1h30m", "1.5 hours" or
+// "1 week 2 days".  A plain number is a number of seconds.
+func ParseHuman(s string) (time.Duration, error) {
+	in := s
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("timeu: invalid duration %q", in)
+	}
+
+	neg := false
+	if s[0] == '-' || s[0] == '+' {
+		neg = s[0] == '-'
+		s = strings.TrimSpace(s[1:])
+	}
+
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		if math.IsNaN(n) {
+			return 0, fmt.Errorf("timeu: invalid duration %q", in)
+		}
+		return toDuration(neg, n*float64(time.Second), in)
+	}
+
+	var total float64
+	for s != "" {
+		i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if i <= 0 {
+			return 0, fmt.Errorf("timeu: invalid duration %q", in)
+		}
+
+		n, err := strconv.ParseFloat(s[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("timeu: invalid duration %q", in)
+		}
+
+		s = strings.TrimLeft(s[i:], " ")
+		j := strings.IndexFunc(s, func(r rune) bool { return (r >= '0' && r <= '9') || r == '.' || r == ' ' || r == ',' })
+		if j < 0 {
+			j = len(s)
+		}
+
+		unit, ok := units[strings.ToLower(s[:j])]
+		if !ok {
+			return 0, fmt.Errorf("timeu: unknown unit %q in duration %q", s[:j], in)
+		}
+
+		total += n * float64(unit)
+		s = strings.TrimLeft(s[j:], " ,")
+	}
+
+	return toDuration(neg, total, in)
+}
+
+// toDuration converts ns nanoseconds, failing when out of range, infinite
+// included.  MaxInt64 rounds up to 2^63 as a float64, hence >=.
+func toDuration(neg bool, ns float64, in string) (time.Duration, error) {
+	if math.Abs(ns) >= math.MaxInt64 {
+		return 0, fmt.Errorf("timeu: duration %q out of range", in)
+	}
+
+	return sign(neg, time.Duration(ns)), nil
+}
+
+func sign(neg bool, d time.Duration) time.Duration {
+	if neg {
+		return -d
+	}
+
+	return d
+}
+
+// FormatHuman formats d with days, and without zero units, e.g. "2d",
+// "1h30m" or "1d2h3m4s".  Durations under a second keep their precision
+// ("250ms"), longer ones are truncated to the second.
+func FormatHuman(d time.Duration) string {
+	if d == math.MinInt64 {
+		d++ // can't be negated, and the nanosecond is truncated anyway
+	}
+	if d < 0 {
+		return "-" + FormatHuman(-d)
+	}
+	if d < time.Second {
+		return d.String()
+	}
+
+	var b strings.Builder
+	for _, u := range []struct {
+		d    time.Duration
+		name string
+	}{{Day, "d"}, {time.Hour, "h"}, {time.Minute, "m"}, {time.Second, "s"}} {
+		if n := d / u.d; n > 0 {
+			fmt.Fprintf(&b, "%d%s", n, u.name)
+			d -= n * u.d
+		}
+	}
+
+	return b.String()
+}
+
+// RelativeTime describes t relative to now, with the largest unit, e.g.
+// "just now", "3 minutes ago" or "in 2 days".
+func RelativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	past := d >= 0
+	if !past {
+		d = -d
+	}
+
+	if d < time.Second {
+		return "just now"
+	}
+
+	var n int64
+	var unit string
+	switch {
+	case d < time.Minute:
+		n, unit = int64(d/time.Second), "second"
+	case d < time.Hour:
+		n, unit = int64(d/time.Minute), "minute"
+	case d < Day:
+		n, unit = int64(d/time.Hour), "hour"
+	case d < Week:
+		n, unit = int64(d/Day), "day"
+	case d < 30*Day:
+		n, unit = int64(d/Week), "week"
+	case d < 365*Day:
+		n, unit = int64(d/(30*Day)), "month"
+	default:
+		n, unit = int64(d/(365*Day)), "year"
+	}
+
+	if n != 1 {
+		unit += "s"
+	}
+
+	if past {
+		return fmt.Sprintf("%d %s ago", n, unit)
+	}
+	return fmt.Sprintf("in %d %s", n, unit)
+}
+
+// Since is RelativeTime(t, time.Now()).
+func Since(t time.Time) string {
+	return RelativeTime(t, time.Now())
+}
+
+// Duration is a time.Duration flag accepting ParseHuman syntax, e.g.
+//
+//	timeout := timeu.Duration(30 * time.Second)
+//	flag.Var(&timeout, "timeout", "request timeout, e.g. 90s or 2m")
+type Duration time.Duration
+
+// Set implements flag.Value
+func (d *Duration) Set(s string) error {
+	v, err := ParseHuman(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+	return nil
+}
+
+// String implements flag.Value
+func (d *Duration) String() string {
+	return FormatHuman(time.Duration(*d))
+}
@@ -0,0 +1,106 @@
+package timeu_test
+
+import (
+	"flag"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/timeu"
+)
+
+func TestParseHuman(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		err  bool
+	}{
+		{"1h30m", 90 * time.Minute, false},
+		{"2d", 48 * time.Hour, false},
+		{"1w2d", 9 * timeu.Day, false},
+		{"1.5 hours", 90 * time.Minute, false},
+		{"1 week, 2 days", 9 * timeu.Day, false},
+		{"250ms", 250 * time.Millisecond, false},
+		{"-3m", -3 * time.Minute, false},
+		{"90", 90 * time.Second, false},
+		{"2 Mins 5s", 125 * time.Second, false},
+		{"", 0, true},
+		{"h", 0, true},
+		{"3 fortnights", 0, true},
+		{"5", 5 * time.Second, false},
+		{"1.2.3h", 0, true},
+		{"99999999999d", 0, true},
+		{"9223372036854775808ns", 0, true},
+		{"9223372036854774784ns", math.MaxInt64 - 1023, false}, // the last float64 below 2^63
+		{"1e20", 0, true},
+		{"-1e20", 0, true},
+		{"inf", 0, true},
+		{"-Inf", 0, true},
+		{"NaN", 0, true},
+	}
+
+	for _, tt := range tests {
+		d, err := timeu.ParseHuman(tt.in)
+		if (err != nil) != tt.err || d != tt.want {
+			t.Errorf("ParseHuman(%q): expected %v (error %v), received %v (%v)", tt.in, tt.want, tt.err, d, err)
+		}
+	}
+}
+
+func TestFormatHuman(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{48 * time.Hour, "2d"},
+		{90 * time.Minute, "1h30m"},
+		{timeu.Day + 2*time.Hour + 3*time.Minute + 4*time.Second + time.Millisecond, "1d2h3m4s"},
+		{250 * time.Millisecond, "250ms"},
+		{0, "0s"},
+		{-time.Minute, "-1m"},
+		{math.MaxInt64, "106751d23h47m16s"},
+		{math.MinInt64, "-106751d23h47m16s"},
+	}
+
+	for _, tt := range tests {
+		if got := timeu.FormatHuman(tt.in); got != tt.want {
+			t.Errorf("FormatHuman(%v): expected %q, received %q", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{now, "just now"},
+		{now.Add(-3 * time.Minute), "3 minutes ago"},
+		{now.Add(-time.Hour), "1 hour ago"},
+		{now.Add(2 * timeu.Day), "in 2 days"},
+		{now.Add(-3 * timeu.Week), "3 weeks ago"},
+		{now.Add(-60 * timeu.Day), "2 months ago"},
+		{now.Add(800 * timeu.Day), "in 2 years"},
+	}
+
+	for _, tt := range tests {
+		if got := timeu.RelativeTime(tt.t, now); got != tt.want {
+			t.Errorf("RelativeTime(%v): expected %q, received %q", tt.t, tt.want, got)
+		}
+	}
+}
+
+func TestDurationFlag(t *testing.T) {
+	d := timeu.Duration(time.Second)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&d, "timeout", "")
+
+	if err := fs.Parse([]string{"-timeout", "2d"}); err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(d) != 48*time.Hour || d.String() != "2d" {
+		t.Errorf("Expected 2d, received %v", d.String())
+	}
+}
@@ -0,0 +1,319 @@
+// Package validate checks the fields of a struct against rules in their
+// tags, e.g. for decoded API responses or inbound request payloads:
+//
+//	type Signup struct {
+//		Email string   `json:"email" validate:"required,max=254,regexp=^[^@]+@[^@]+$"`
+//		Plan  string   `json:"plan" validate:"oneof=free pro"`
+//		Age   int      `json:"age" validate:"required,min=13"`
+//		Tags  []string `json:"tags" validate:"max=5"`
+//	}
+//
+//	if err := validate.Struct(&s); err != nil {
+//		resp.Error(w, http.StatusBadRequest, err.Error())
+//	}
+//
+// The rules are:
+//
+//	required    not the zero value, nor empty
+//	min=N       at least N, or a length of at least N for strings, slices and maps
+//	max=N       at most N, or a length of at most N
+//	len=N       a length of exactly N
+//	oneof=A B   one of the space separated values
+//	regexp=RE   a string matching RE, which must be the last rule as it may contain commas
+//
+// Rules other than required don't apply to zero values, so optional fields
+// only need to be valid when set: min=13 alone accepts an Age of 0, which
+// required rejects.  Nested structs, pointers to structs and slices of
+// structs are validated too, and structs with a Validate() error method are
+// checked with it.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Validator is implemented by types with custom validation.
+type Validator interface {
+	Validate() error
+}
+
+// Error is the error of a field breaking a rule.
+type Error struct {
+	Path string // e.g. "items[2].name", with JSON names when the fields have them
+	Rule string // e.g. "min=1", or "validate" for Validator errors
+	Err  error
+}
+
+// Error implements the Error method for Errors
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrRule is the error of rules that can't apply to a field, e.g. min=abc,
+// which are bugs rather than invalid input.
+var ErrRule = errors.New("validate: invalid rule")
+
+// Struct validates the struct v, or the struct v points to.  Every field is
+// checked, so the error lists all the problems at once, as *Errors joined
+// with errors.Join.
+func Struct(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: expected a struct, received %T", ErrRule, v)
+	}
+
+	var errs []error
+	check(rv, "", &errs)
+	return errors.Join(errs...)
+}
+
+var (
+	validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+	timeType      = reflect.TypeOf(time.Time{})
+	durationType  = reflect.TypeOf(time.Duration(0))
+)
+
+// check validates the fields of the struct s, whose path is prefix
+func check(s reflect.Value, prefix string, errs *[]error) {
+	custom(s, prefix, errs)
+
+	t := s.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		path := join(prefix, fieldName(f))
+		v := s.Field(i)
+
+		if tag := f.Tag.Get("validate"); tag != "" && tag != "-" {
+			for _, rule := range rules(tag) {
+				if err := apply(v, rule); err != nil {
+					*errs = append(*errs, &Error{path, rule, err})
+					break // one error per field
+				}
+			}
+		}
+
+		if f.Tag.Get("validate") != "-" {
+			nested(v, path, errs)
+		}
+	}
+}
+
+// nested validates the structs in v, if any
+func nested(v reflect.Value, path string, errs *[]error) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			nested(v.Elem(), path, errs)
+		}
+	case reflect.Struct:
+		if v.Type() != timeType {
+			check(v, path, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			nested(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			nested(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()), errs)
+		}
+	}
+}
+
+// custom calls the Validate method of s, if it has one
+func custom(s reflect.Value, path string, errs *[]error) {
+	var err error
+	switch {
+	case s.Type().Implements(validatorType):
+		err = s.Interface().(Validator).Validate()
+	case s.CanAddr() && s.Addr().Type().Implements(validatorType):
+		err = s.Addr().Interface().(Validator).Validate()
+	default:
+		return
+	}
+
+	if err != nil {
+		if path == "" {
+			path = s.Type().Name()
+		}
+		*errs = append(*errs, &Error{path, "validate", err})
+	}
+}
+
+// fieldName returns the JSON name of f, or its Go name
+func fieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return f.Name
+	}
+
+	return name
+}
+
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+
+	return prefix + "." + name
+}
+
+// rules splits a tag on commas, up to a regexp rule
+func rules(tag string) []string {
+	var rules []string
+	for tag != "" {
+		if strings.HasPrefix(tag, "regexp=") {
+			return append(rules, tag)
+		}
+
+		rule, rest, _ := strings.Cut(tag, ",")
+		rules = append(rules, strings.TrimSpace(rule))
+		tag = rest
+	}
+
+	return rules
+}
+
+// apply checks the value v against rule
+func apply(v reflect.Value, rule string) error {
+	name, arg, _ := strings.Cut(rule, "=")
+
+	if name == "required" {
+		if isEmpty(v) {
+			return errors.New("is required")
+		}
+		return nil
+	}
+
+	if isEmpty(v) {
+		return nil
+	}
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+
+	switch name {
+	case "min", "max", "len":
+		return bound(v, name, arg)
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		for _, allowed := range strings.Fields(arg) {
+			if s == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(strings.Fields(arg), ", "))
+	case "regexp":
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("%w: regexp on a %s", ErrRule, v.Type())
+		}
+		re, err := compile(arg)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrRule, err)
+		}
+		if !re.MatchString(v.String()) {
+			return fmt.Errorf("must match %s", arg)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown rule %q", ErrRule, name)
+	}
+}
+
+// bound checks the min, max or len rule, comparing numbers by value, and
+// strings, slices and maps by length
+func bound(v reflect.Value, name, arg string) error {
+	var value, limit float64
+	what := ""
+
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		value = float64(v.Len())
+		if v.Kind() == reflect.String {
+			value = float64(len([]rune(v.String())))
+		}
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("%w: %s=%s", ErrRule, name, arg)
+		}
+		limit = float64(n)
+		what = "length "
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if v.Type() == durationType {
+			d, err := time.ParseDuration(arg)
+			if err != nil {
+				return fmt.Errorf("%w: %s=%s", ErrRule, name, arg)
+			}
+			value, limit = float64(v.Int()), float64(d)
+			break
+		}
+		n, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s=%s", ErrRule, name, arg)
+		}
+		value = v.Convert(reflect.TypeOf(float64(0))).Float()
+		limit = n
+	default:
+		return fmt.Errorf("%w: %s on a %s", ErrRule, name, v.Type())
+	}
+
+	switch {
+	case name == "min" && value < limit:
+		return fmt.Errorf("%smust be at least %s", what, arg)
+	case name == "max" && value > limit:
+		return fmt.Errorf("%smust be at most %s", what, arg)
+	case name == "len" && value != limit:
+		return fmt.Errorf("length must be %s", arg)
+	}
+
+	return nil
+}
+
+// isEmpty reports whether v is the zero value, or an empty slice or map
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+var regexps sync.Map // of *regexp.Regexp by expression
+
+// compile returns the compiled expr, cached
+func compile(expr string) (*regexp.Regexp, error) {
+	if re, ok := regexps.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	regexps.Store(expr, re)
+
+	return re, nil
+}
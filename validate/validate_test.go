@@ -0,0 +1,139 @@
+package validate_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sspencer/goal/validate"
+)
+
+type item struct {
+	Name string `json:"name" validate:"required"`
+	Qty  int    `json:"qty" validate:"min=1,max=10"`
+}
+
+type order struct {
+	ID      string        `json:"id" validate:"required,len=4"`
+	Email   string        `json:"email" validate:"max=20,regexp=^[^@,]+@[^@]+$"`
+	Status  string        `json:"status" validate:"oneof=new paid"`
+	Items   []item        `json:"items" validate:"required,max=2"`
+	Timeout time.Duration `validate:"max=1m"`
+	Ship    *address      `json:"ship"`
+	Note    string
+}
+
+type address struct {
+	Zip string `json:"zip" validate:"regexp=^[0-9]{5}$"`
+}
+
+func (a *address) Validate() error {
+	if a.Zip == "00000" {
+		return errors.New("unknown zip")
+	}
+	return nil
+}
+
+func TestStruct(t *testing.T) {
+	valid := func() order {
+		return order{ID: "A123", Items: []item{{"pen", 1}}}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*order)
+		errs   []string
+	}{
+		{"valid", func(o *order) {}, nil},
+		{"optional set", func(o *order) {
+			o.Email, o.Status, o.Timeout, o.Ship = "a@b.com", "paid", time.Second, &address{"12345"}
+		}, nil},
+		{"required", func(o *order) { o.ID, o.Items = "", nil }, []string{"id: is required", "items: is required"}},
+		{"len", func(o *order) { o.ID = "A1" }, []string{"id: length must be 4"}},
+		{"regexp", func(o *order) { o.Email = "nope" }, []string{"email: must match ^[^@,]+@[^@]+$"}},
+		{"max length", func(o *order) { o.Email = strings.Repeat("a", 20) + "@b.com" }, []string{"email: length must be at most 20"}},
+		{"oneof", func(o *order) { o.Status = "lost" }, []string{"status: must be one of new, paid"}},
+		{"duration", func(o *order) { o.Timeout = time.Hour }, []string{"Timeout: must be at most 1m"}},
+		{"nested", func(o *order) { o.Items = []item{{"pen", 1}, {"", 11}} }, []string{"items[1].name: is required", "items[1].qty: must be at most 10"}},
+		{"max items", func(o *order) { o.Items = []item{{"a", 1}, {"b", 1}, {"c", 1}} }, []string{"items: length must be at most 2"}},
+		{"pointer", func(o *order) { o.Ship = &address{"1"} }, []string{"ship.zip: must match ^[0-9]{5}$"}},
+		{"validator", func(o *order) { o.Ship = &address{"00000"} }, []string{"ship: unknown zip"}},
+	}
+
+	for _, tt := range tests {
+		o := valid()
+		tt.modify(&o)
+		err := validate.Struct(&o)
+
+		var errs []string
+		if err != nil {
+			errs = strings.Split(err.Error(), "\n")
+		}
+
+		if strings.Join(errs, "|") != strings.Join(tt.errs, "|") {
+			t.Errorf("%s: expected %q, received %q", tt.name, tt.errs, errs)
+		}
+	}
+}
+
+func TestStructZero(t *testing.T) {
+	type signup struct {
+		Age      int `json:"age" validate:"required,min=13"`
+		Referrer int `json:"referrer" validate:"min=13"`
+	}
+
+	tests := []struct {
+		name string
+		v    signup
+		errs []string
+	}{
+		{"zero", signup{}, []string{"age: is required"}},
+		{"set", signup{Age: 13, Referrer: 13}, nil},
+		{"below min", signup{Age: 12, Referrer: 12}, []string{"age: must be at least 13", "referrer: must be at least 13"}},
+		{"optional zero", signup{Age: 13}, nil},
+	}
+
+	for _, tt := range tests {
+		err := validate.Struct(&tt.v)
+
+		var errs []string
+		if err != nil {
+			errs = strings.Split(err.Error(), "\n")
+		}
+
+		if strings.Join(errs, "|") != strings.Join(tt.errs, "|") {
+			t.Errorf("%s: expected %q, received %q", tt.name, tt.errs, errs)
+		}
+	}
+}
+
+func TestStructRuleErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{"not a struct", 42},
+		{"unknown rule", &struct {
+			A string `validate:"shiny"`
+		}{"a"}},
+		{"bad bound", &struct {
+			A int `validate:"min=abc"`
+		}{1}},
+	}
+
+	for _, tt := range tests {
+		if err := validate.Struct(tt.v); !errors.Is(err, validate.ErrRule) {
+			t.Errorf("%s: expected ErrRule, received %v", tt.name, err)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	err := validate.Struct(&item{})
+
+	var e *validate.Error
+	if !errors.As(err, &e) || e.Path != "name" || e.Rule != "required" {
+		t.Errorf("Expected a required error on name, received %#v", e)
+	}
+}